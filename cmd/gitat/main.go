@@ -0,0 +1,30 @@
+// Command git-@ is the GitAT git extension, run as 'git @ <command>'.
+package main
+
+import (
+	"os"
+
+	"github.com/potsed/gitAT/internal/config"
+	"github.com/potsed/gitAT/pkg/cli"
+)
+
+// Build information, set with -ldflags by the Makefile
+var (
+	Version    = "v1.1.0"
+	CommitHash = ""
+	BuildDate  = ""
+)
+
+func main() {
+	cli.Version = Version
+	cli.CommitHash = CommitHash
+	cli.BuildDate = BuildDate
+
+	// Outside a repository only help and version work, see cli.App.Run
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = &config.Config{}
+	}
+
+	os.Exit(cli.NewApp(cfg).Execute(os.Args[1:]))
+}
@@ -0,0 +1,361 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// Aliases are stored as at.alias.<name> = <expansion>. An expansion is one
+// or more git @ command lines separated by ";", quoted as in the shell, in
+// which $1..$N are the arguments of the alias and $@ all of them. Without
+// any $ the arguments are appended to the last command line. The CLI runs
+// the command lines in order and stops at the first failure.
+
+const aliasPrefix = "at.alias."
+
+// aliasName matches the names git config accepts as the last part of a key
+var aliasName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*$`)
+
+// Alias is a user-defined command
+type Alias struct {
+	Name      string `json:"name"`
+	Expansion string `json:"expansion"`
+}
+
+// Aliases returns the defined aliases in the order of git config
+func (m *Manager) Aliases() []Alias {
+	out, err := m.git.Run("config", "--get-regexp", "^at.alias.")
+	if err != nil || out == "" {
+		return nil
+	}
+
+	var aliases []Alias
+	for _, line := range strings.Split(out, "\n") {
+		key, expansion, _ := strings.Cut(line, " ")
+		if name := strings.TrimPrefix(key, aliasPrefix); name != key {
+			aliases = append(aliases, Alias{Name: name, Expansion: expansion})
+		}
+	}
+	return aliases
+}
+
+// FindAlias returns the expansion of the alias name
+func (m *Manager) FindAlias(name string) (string, bool) {
+	if !aliasName.MatchString(name) {
+		return "", false
+	}
+	expansion, err := m.git.GetConfig(aliasPrefix + name)
+	return expansion, err == nil && expansion != ""
+}
+
+// aliasWord is a word of an alias command line, made of literal text and
+// $N or $@ parameters
+type aliasWord struct {
+	parts  []aliasPart
+	quoted bool // kept when it expands to nothing, like "" in the shell
+}
+
+// aliasPart is literal text, or the parameter param ("1", "2", ... or "@")
+type aliasPart struct {
+	text  string
+	param string
+}
+
+// parseAlias splits an expansion into command lines of words
+func parseAlias(expansion string) ([][]aliasWord, error) {
+	var (
+		lines  [][]aliasWord
+		line   []aliasWord
+		word   aliasWord
+		inWord bool
+		quote  rune
+	)
+	text := func(s string) {
+		inWord = true
+		if n := len(word.parts); n > 0 && word.parts[n-1].param == "" {
+			word.parts[n-1].text += s
+			return
+		}
+		word.parts = append(word.parts, aliasPart{text: s})
+	}
+	endWord := func() {
+		if inWord {
+			line = append(line, word)
+		}
+		word, inWord = aliasWord{}, false
+	}
+	endLine := func() {
+		endWord()
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		line = nil
+	}
+
+	runes := []rune(expansion)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'' && r != '\'':
+			text(string(r))
+		case r == '\'' || r == '"':
+			switch quote {
+			case 0:
+				quote = r
+			case r:
+				quote = 0
+			default:
+				text(string(r))
+				continue
+			}
+			inWord, word.quoted = true, true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			text(string(runes[i]))
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '@':
+			i++
+			inWord = true
+			word.parts = append(word.parts, aliasPart{param: "@"})
+		case r == '$' && i+1 < len(runes) && runes[i+1] >= '1' && runes[i+1] <= '9':
+			j := i + 1
+			for j < len(runes) && runes[j] >= '0' && runes[j] <= '9' {
+				j++
+			}
+			inWord = true
+			word.parts = append(word.parts, aliasPart{param: string(runes[i+1 : j])})
+			i = j - 1
+		case quote != 0:
+			text(string(r))
+		case r == ';':
+			endLine()
+		case r == ' ' || r == '\t' || r == '\n':
+			endWord()
+		default:
+			text(string(r))
+		}
+	}
+	if quote != 0 {
+		return nil, errs.New(errs.Usage, "Unterminated %c quote in alias: %s", quote, expansion)
+	}
+	endLine()
+	if len(lines) == 0 {
+		return nil, errs.New(errs.Usage, "Alias expands to no command")
+	}
+	return lines, nil
+}
+
+// ExpandAlias returns the command lines an alias runs with args
+func ExpandAlias(expansion string, args []string) ([][]string, error) {
+	lines, err := parseAlias(expansion)
+	if err != nil {
+		return nil, err
+	}
+
+	usesArgs := false
+	var expanded [][]string
+	for _, line := range lines {
+		var words []string
+		for _, word := range line {
+			// A bare $@ is one word per argument
+			if len(word.parts) == 1 && word.parts[0].param == "@" && !word.quoted {
+				usesArgs = true
+				words = append(words, args...)
+				continue
+			}
+
+			var b strings.Builder
+			for _, part := range word.parts {
+				switch part.param {
+				case "":
+					b.WriteString(part.text)
+				case "@":
+					usesArgs = true
+					b.WriteString(strings.Join(args, " "))
+				default:
+					usesArgs = true
+					if n, _ := strconv.Atoi(part.param); n <= len(args) {
+						b.WriteString(args[n-1])
+					}
+				}
+			}
+			// Like the shell, a missing argument leaves no empty word
+			if b.Len() > 0 || word.quoted {
+				words = append(words, b.String())
+			}
+		}
+		if len(words) > 0 {
+			expanded = append(expanded, words)
+		}
+	}
+
+	if !usesArgs && len(expanded) > 0 {
+		last := len(expanded) - 1
+		expanded[last] = append(expanded[last], args...)
+	}
+	return expanded, nil
+}
+
+// Alias handles the alias command
+func (m *Manager) Alias(args []string) error {
+	cmd := aliasCommand()
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("alias")
+	}
+	args = flags.Args()
+
+	if flags.Has("unset") {
+		if len(args) != 1 {
+			return errs.New(errs.Usage, "Usage: git @ alias --unset <name>")
+		}
+		return m.unsetAlias(args[0])
+	}
+
+	switch len(args) {
+	case 0:
+		return m.listAliases()
+	case 1:
+		return m.showAlias(args[0])
+	default:
+		// The expansion may be given quoted or as several words
+		return m.setAlias(args[0], strings.Join(args[1:], " "))
+	}
+}
+
+// listAliases shows every alias
+func (m *Manager) listAliases() error {
+	aliases := m.Aliases()
+	if output.JSONEnabled() {
+		if aliases == nil {
+			aliases = []Alias{}
+		}
+		return output.JSON(map[string]interface{}{"aliases": aliases})
+	}
+
+	if len(aliases) == 0 {
+		output.Info("No aliases defined. Define one with: git @ alias <name> '<command>; <command>'")
+		return nil
+	}
+
+	output.Title("🔗 Aliases")
+	var rows [][]string
+	for _, alias := range aliases {
+		rows = append(rows, []string{alias.Name, alias.Expansion})
+	}
+	output.Table([]string{"Name", "Expansion"}, rows)
+	return nil
+}
+
+// showAlias prints the expansion of an alias
+func (m *Manager) showAlias(name string) error {
+	expansion, ok := m.FindAlias(name)
+	if !ok {
+		return errs.New(errs.NotFound, "Alias '%s' is not defined", name)
+	}
+	if output.JSONEnabled() {
+		return output.JSON(Alias{Name: name, Expansion: expansion})
+	}
+	fmt.Println(expansion)
+	return nil
+}
+
+// setAlias defines an alias in the repository configuration
+func (m *Manager) setAlias(name, expansion string) error {
+	if err := m.requireRepo(); err != nil {
+		return err
+	}
+	if !aliasName.MatchString(name) {
+		return errs.New(errs.Usage, "Invalid alias name '%s': use letters, digits and '-', starting with a letter", name)
+	}
+	if FindCommand(Commands(), name) != nil {
+		return errs.New(errs.Usage, "'%s' is a git @ command; an alias cannot replace it", name)
+	}
+	lines, err := parseAlias(expansion)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if first := line[0]; len(first.parts) != 1 || first.parts[0].param != "" {
+			return errs.New(errs.Usage, "Each command of an alias must start with a command name: %s", expansion)
+		}
+	}
+
+	if err := m.git.SetConfig(aliasPrefix+name, expansion); err != nil {
+		return fmt.Errorf("failed to set alias: %w", err)
+	}
+	fmt.Printf("✅ Alias '%s' = %s\n", name, expansion)
+	return nil
+}
+
+// unsetAlias removes an alias from the repository configuration
+func (m *Manager) unsetAlias(name string) error {
+	if err := m.requireRepo(); err != nil {
+		return err
+	}
+	if _, ok := m.FindAlias(name); !ok {
+		return errs.New(errs.NotFound, "Alias '%s' is not defined", name)
+	}
+	if _, err := m.git.Run("config", "--unset", aliasPrefix+name); err != nil {
+		return errs.Wrap(errs.NotFound, err, "Alias '%s' is not defined in this repository; remove it where it is set", name)
+	}
+	fmt.Printf("✅ Alias '%s' removed\n", name)
+	return nil
+}
+
+// requireRepo fails outside a git repository
+func (m *Manager) requireRepo() error {
+	if m.config.RepoPath == "" {
+		return errs.New(errs.NotARepo, "Not in a git repository")
+	}
+	return nil
+}
+
+func aliasCommand() *Command {
+	return &Command{
+		Name:    "alias",
+		Hint:    "[<name> [<command>...]]",
+		Summary: "List, show or define command aliases",
+		Usage: []string{
+			"",
+			"<name>",
+			"<name> '<command> [<args>]; <command> [<args>]...'",
+			"--unset <name>",
+		},
+		Description: `An alias runs one or more git @ commands. It is stored as
+at.alias.<name> in the repository configuration; define it with
+'git config --global at.alias.<name>' to use it in every repository.
+
+Commands are separated by ';' and run in order; the alias stops at the first
+command that fails. $1, $2, ... are replaced by the arguments of the alias
+and $@ by all of them; a missing argument leaves no word, unless quoted.
+Without any $, the arguments are appended to the last command. Quote the
+definition so the shell keeps ';' and '$'.
+
+Built-in commands always win over an alias of the same name, and aliases
+win over plugins. An alias can use other aliases, but not itself.`,
+		Args: []Arg{
+			{Name: "<name>", Help: "Name of the alias: letters, digits and '-'"},
+			{Name: "<command>", Help: "Command lines the alias runs, separated by ';'"},
+		},
+		Flags: []Flag{
+			{Long: "unset", Help: "Remove the alias"},
+		},
+		Examples: []Example{
+			{"git @ alias ship 'squash -s; pr --draft -o $1'", "Define ship"},
+			{"git @ ship \"Add login\"", "Runs git @ squash -s, then git @ pr --draft -o \"Add login\""},
+			{"git @ alias", "List the aliases"},
+			{"git @ alias ship", "Print what ship runs"},
+			{"git @ alias --unset ship", "Remove ship"},
+		},
+		NoRepo: true,
+		Run:    (*Manager).Alias,
+	}
+}
@@ -0,0 +1,359 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// autostashTag prefixes the message of stashes created by gitAT, followed by
+// the branch the changes belong to
+const autostashTag = "gitat-autostash:"
+
+// legacyAutostashPrefix starts the messages of stashes created by older
+// gitAT versions, which were never restored automatically
+const legacyAutostashPrefix = "Auto-stash before"
+
+// stashEntry is an entry of git stash list
+type stashEntry struct {
+	Ref     string `json:"ref"` // stash@{n}
+	Message string `json:"message"`
+	Date    string `json:"date"`
+	Branch  string `json:"branch"` // branch of a gitAT autostash
+	Legacy  bool   `json:"legacy"` // stash created by an older gitAT version
+}
+
+// stashReport is a gitAT stash as reported by switch --stashes --json
+type stashReport struct {
+	stashEntry
+	Orphaned string `json:"orphaned"` // why it will never be restored, or ""
+	Current  bool   `json:"current"`  // of the checked out branch, not restored yet
+}
+
+// isGitAT reports whether the stash was created by gitAT
+func (s stashEntry) isGitAT() bool {
+	return s.Branch != "" || s.Legacy
+}
+
+// parseStashList parses git stash list --format=%gd%x00%gs%x00%cr
+func parseStashList(out string) []stashEntry {
+	var entries []stashEntry
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+
+		// Subjects look like "On <branch>: <message>" or "WIP on <branch>: ..."
+		entry := stashEntry{Ref: fields[0], Message: fields[1], Date: fields[2]}
+		if _, message, ok := strings.Cut(fields[1], ": "); ok {
+			entry.Message = message
+		}
+
+		if strings.HasPrefix(entry.Message, autostashTag) {
+			entry.Branch = strings.TrimPrefix(entry.Message, autostashTag)
+		} else if strings.HasPrefix(entry.Message, legacyAutostashPrefix) {
+			entry.Legacy = true
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// getStashes returns the stash entries, newest first
+func (m *Manager) getStashes() ([]stashEntry, error) {
+	out, err := m.git.Run("stash", "list", "--format=%gd%x00%gs%x00%cr")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stashes: %w", err)
+	}
+	return parseStashList(out), nil
+}
+
+// isDirty reports whether the working tree has changes, including untracked files
+func (m *Manager) isDirty() bool {
+	status, err := m.git.Run("status", "--porcelain")
+	return err == nil && status != ""
+}
+
+// autostash stashes the changes of branch, tagged so they are restored when
+// returning to it. It reports whether anything was stashed.
+func (m *Manager) autostash(branch string) (bool, error) {
+	if !m.isDirty() {
+		return false, nil
+	}
+
+	if _, err := m.git.Run("stash", "push", "--include-untracked", "-m", autostashTag+branch); err != nil {
+		return false, fmt.Errorf("failed to stash changes: %w", err)
+	}
+	fmt.Printf("Stashed changes of %s\n", branch)
+	return true, nil
+}
+
+// restoreAutostash pops the latest autostash of branch, if any
+func (m *Manager) restoreAutostash(branch string) error {
+	stashes, err := m.getStashes()
+	if err != nil {
+		return err
+	}
+
+	for _, stash := range stashes {
+		if stash.Branch != branch {
+			continue
+		}
+
+		// Restore staged changes too, falling back to a plain pop
+		_, err := m.git.Run("stash", "pop", "--index", stash.Ref)
+		if err != nil {
+			_, err = m.git.Run("stash", "pop", stash.Ref)
+		}
+		if err != nil {
+			fmt.Printf("Warning: Failed to restore stashed changes of %s (%s)\n", branch, stash.Ref)
+			fmt.Println("Resolve the conflicts, then drop it with: git stash drop " + stash.Ref)
+			return nil
+		}
+		fmt.Printf("Restored stashed changes of %s\n", branch)
+		return nil
+	}
+	return nil
+}
+
+// Switch changes branch, stashing the changes of the current branch and
+// restoring the changes stashed for the target branch
+func (m *Manager) Switch(args []string) error {
+	cmd := command("switch")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("switch")
+	}
+
+	// Only the first operation runs, as they are mutually exclusive
+	switch flags.First("stashes", "clean") {
+	case "stashes":
+		if err := cmd.extraArgs(flags, 0); err != nil {
+			return err
+		}
+		return m.listAutostashes()
+	case "clean":
+		if err := cmd.extraArgs(flags, 0); err != nil {
+			return err
+		}
+		return m.cleanAutostashes(flags.Has("yes"))
+	}
+	if flags.Has("yes") {
+		return errs.New(errs.Usage, "--yes only applies to --clean")
+	}
+	if err := cmd.extraArgs(flags, 1); err != nil {
+		return err
+	}
+
+	var target string
+	if len(flags.Args()) > 0 {
+		target = flags.Args()[0]
+	} else {
+		choice, ok, err := m.pickBranch("Switch to", "", nil)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return m.help("switch")
+		}
+		// A remote branch is checked out as a local tracking branch
+		target = choice.Local
+	}
+
+	currentBranch, err := m.git.GetCurrentBranch()
+	if err != nil {
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+	if target == currentBranch {
+		fmt.Printf("Already on %s\n", target)
+		return nil
+	}
+
+	stashed, err := m.autostash(currentBranch)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.git.Run("checkout", target); err != nil {
+		if stashed {
+			m.restoreAutostash(currentBranch)
+		}
+		return errs.Wrap(errs.KindOf(err), err, "Failed to switch to %s", target)
+	}
+	fmt.Printf("Switched to %s\n", target)
+	m.recordBranchSwitch("switch", currentBranch, target)
+
+	if err := m.restoreAutostash(target); err != nil {
+		return err
+	}
+
+	// Work branches become the working branch used by save
+	if m.getWorkType(target) != "" {
+		if err := m.setBranch(target); err != nil {
+			fmt.Printf("Warning: Failed to set working branch: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// orphanReason returns why a gitAT stash will never be restored, or "".
+// A stash of the current branch is not orphaned: it was left behind by a
+// plain git checkout and can still be restored.
+func (m *Manager) orphanReason(stash stashEntry) string {
+	switch {
+	case stash.Legacy:
+		return "created by an older gitAT"
+	case !m.branchExists(stash.Branch):
+		return "branch deleted"
+	default:
+		return ""
+	}
+}
+
+// listAutostashes reports the stashes created by gitAT
+func (m *Manager) listAutostashes() error {
+	stashes, err := m.getStashes()
+	if err != nil {
+		return err
+	}
+	currentBranch, _ := m.git.GetCurrentBranch()
+
+	if output.JSONEnabled() {
+		reports := []stashReport{}
+		for _, stash := range stashes {
+			if stash.isGitAT() {
+				reports = append(reports, stashReport{
+					stashEntry: stash,
+					Orphaned:   m.orphanReason(stash),
+					Current:    stash.Branch == currentBranch,
+				})
+			}
+		}
+		return output.JSON(map[string][]stashReport{"stashes": reports})
+	}
+
+	var rows [][]string
+	var pending []string
+	orphaned := 0
+	for _, stash := range stashes {
+		if !stash.isGitAT() {
+			continue
+		}
+
+		branch := stash.Branch
+		if branch == "" {
+			branch = "-"
+		}
+		state := "restored on switch"
+		if reason := m.orphanReason(stash); reason != "" {
+			state = "orphaned: " + reason
+			orphaned++
+		} else if stash.Branch == currentBranch {
+			state = "not restored yet"
+			pending = append(pending, stash.Ref)
+		}
+		rows = append(rows, []string{stash.Ref, branch, stash.Date, state})
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No gitAT stashes")
+		return nil
+	}
+
+	output.Table([]string{"Stash", "Branch", "Created", "State"}, rows)
+	if len(pending) > 0 {
+		fmt.Println()
+		fmt.Printf("%d stash(es) of %s were not restored. Restore them with: git stash pop --index %s\n", len(pending), currentBranch, pending[0])
+	}
+	if orphaned > 0 {
+		fmt.Println()
+		fmt.Printf("%d orphaned stash(es). Inspect with 'git stash show -p <stash>', drop them with: git @ switch --clean\n", orphaned)
+	}
+	return nil
+}
+
+// cleanAutostashes drops the orphaned gitAT stashes
+func (m *Manager) cleanAutostashes(yes bool) error {
+	stashes, err := m.getStashes()
+	if err != nil {
+		return err
+	}
+	var orphans []stashEntry
+	for _, stash := range stashes {
+		if stash.isGitAT() && m.orphanReason(stash) != "" {
+			orphans = append(orphans, stash)
+		}
+	}
+
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned gitAT stashes")
+		return nil
+	}
+
+	fmt.Println("Orphaned stashes to drop:")
+	for _, stash := range orphans {
+		fmt.Printf("  %s %s (%s)\n", stash.Ref, stash.Message, stash.Date)
+	}
+
+	if !yes {
+		fmt.Print("Drop these stashes? (y/N): ")
+		var confirmation string
+		fmt.Scanln(&confirmation)
+		if !strings.HasPrefix(strings.ToLower(confirmation), "y") {
+			fmt.Println("Operation cancelled")
+			return nil
+		}
+	}
+
+	// Drop the oldest first so the indexes of newer stashes stay valid
+	for i := len(orphans) - 1; i >= 0; i-- {
+		if _, err := m.git.Run("stash", "drop", orphans[i].Ref); err != nil {
+			return fmt.Errorf("failed to drop %s: %w", orphans[i].Ref, err)
+		}
+	}
+
+	fmt.Printf("✅ Dropped %d orphaned stash(es)\n", len(orphans))
+	return nil
+}
+
+func switchCommand() *Command {
+	return &Command{
+		Name:    "switch",
+		Hint:    "[<branch>]",
+		Summary: "Switch branches, stashing and restoring changes per branch",
+		Usage:   []string{"[<branch>]", "--stashes", "--clean [--yes]"},
+		Args:    []Arg{{Name: "<branch>", Source: SourceBranches}},
+		Description: `Switch to another branch without losing uncommitted work. Changes of the
+current branch (including untracked files) are stashed, tagged with the
+branch name, and restored automatically when you switch back to it.
+
+Without a branch, pick it from the local and remote branches when run in a
+terminal.`,
+		Flags: []Flag{
+			{Short: "l", Long: "stashes", Help: "Report gitAT stashes and which ones are orphaned"},
+			{Long: "clean", Help: "Drop orphaned gitAT stashes"},
+			{Short: "y", Long: "yes", Help: "Drop without asking for confirmation"},
+		},
+		Examples: []Example{
+			{"git @ switch feature-login", "Stash changes, switch, restore saved changes"},
+			{"git @ switch main", "Switch back, feature-login changes are stashed"},
+			{"git @ switch --stashes", "Show gitAT stashes"},
+			{"git @ switch --clean", "Drop stashes of deleted branches"},
+		},
+		Sections: []Section{
+			{"ORPHANED STASHES", `A gitAT stash is orphaned when its branch was deleted or it was created by
+an older gitAT version that never restored it. A stash of the current
+branch, left behind by a plain git checkout, is listed as not restored
+yet and never dropped by --clean.`},
+			{"STORAGE", `Stash message: gitat-autostash:<branch>`},
+		},
+		Run: (*Manager).Switch,
+	}
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"sort"
+	"strings"
+)
+
+// Completion sources of flag values and positional arguments
+const (
+	SourceBranches  = "branches"
+	SourceWorkTypes = "work-types"
+	SourceReviewers = "reviewers"
+	SourceRemotes   = "remotes"
+)
+
+// Complete returns the candidates of a completion source
+func (m *Manager) Complete(source string) []string {
+	switch source {
+	case SourceBranches:
+		return m.BranchNames()
+	case SourceWorkTypes:
+		return WorkTypes()
+	case SourceReviewers:
+		return m.Reviewers()
+	case SourceRemotes:
+		return m.RemoteNames()
+	}
+	return nil
+}
+
+// WorkTypes returns the supported work branch types
+func WorkTypes() []string {
+	return append([]string(nil), workTypes...)
+}
+
+// BranchNames returns the local branches, or nil outside a repository
+func (m *Manager) BranchNames() []string {
+	branches, err := m.git.GetBranches()
+	if err != nil {
+		return nil
+	}
+	return branches
+}
+
+// RemoteNames returns the configured remotes
+func (m *Manager) RemoteNames() []string {
+	output, err := m.git.Run("remote")
+	if err != nil || output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// Reviewers returns the reviewers configured in at.pr.reviewer and the
+// per work type at.pr.<type>.reviewer keys, sorted
+func (m *Manager) Reviewers() []string {
+	output, err := m.git.Run("config", "--get-regexp", "^at.pr.")
+	if err != nil {
+		return nil
+	}
+
+	var reviewers []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 || !strings.HasSuffix(fields[0], ".reviewer") || strings.Count(fields[0], ".") > 3 {
+			continue
+		}
+		reviewers = mergeLists(reviewers, splitList(fields[1]))
+	}
+	sort.Strings(reviewers)
+	return reviewers
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// branchContext is the product, feature and issue a branch works on
+type branchContext struct {
+	Product string
+	Feature string
+	Issue   string
+}
+
+// label returns the non-empty context values joined by dots
+func (c branchContext) label() string {
+	var parts []string
+	for _, part := range []string{c.Product, c.Feature, c.Issue} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// inheritedContextKeys are the per-branch keys work copies from the parent.
+// The issue is not inherited: a child branch usually works on another issue.
+var inheritedContextKeys = []string{"product", "feature"}
+
+// branchContextKey returns the git config key of a per-branch value
+func branchContextKey(branch, name string) string {
+	return fmt.Sprintf("branch.%s.at-%s", branch, name)
+}
+
+// contextValue returns branch.<name>.at-<name>, falling back to the
+// repository default at.<repoKey>
+func (m *Manager) contextValue(branch, name, repoKey string) string {
+	if branch != "" {
+		if value, _ := m.git.GetConfig(branchContextKey(branch, name)); value != "" {
+			return value
+		}
+	}
+	value, _ := m.git.GetConfig(repoKey)
+	return value
+}
+
+// getBranchContext returns the context of a branch with repository fallbacks
+func (m *Manager) getBranchContext(branch string) branchContext {
+	ctx := branchContext{
+		Product: m.contextValue(branch, "product", "at.product"),
+		Feature: m.contextValue(branch, "feature", "at.feature"),
+		Issue:   m.branchIssue(branch),
+	}
+	if ctx.Issue == "" {
+		ctx.Issue, _ = m.git.GetConfig("at.task")
+	}
+	return ctx
+}
+
+// currentContext returns the context of the current branch
+func (m *Manager) currentContext() branchContext {
+	branch, _ := m.git.GetCurrentBranch()
+	return m.getBranchContext(branch)
+}
+
+// ownBranchContext returns only the values recorded on the branch itself
+func (m *Manager) ownBranchContext(branch string) branchContext {
+	product, _ := m.git.GetConfig(branchContextKey(branch, "product"))
+	feature, _ := m.git.GetConfig(branchContextKey(branch, "feature"))
+	return branchContext{Product: product, Feature: feature, Issue: m.branchIssue(branch)}
+}
+
+// inheritBranchContext copies the per-branch product and feature of parent
+// to a new branch
+func (m *Manager) inheritBranchContext(branch, parent string) {
+	for _, name := range inheritedContextKeys {
+		value, _ := m.git.GetConfig(branchContextKey(parent, name))
+		if value == "" {
+			continue
+		}
+		if err := m.git.SetConfig(branchContextKey(branch, name), value); err != nil {
+			fmt.Printf("Warning: Failed to copy %s from %s: %v\n", name, parent, err)
+		}
+	}
+}
+
+// contextScope applies the --default flag of product, feature and issue. It
+// returns the work branch to scope the value to, or "" for the repository
+// default, which is also used on trunk and other non-work branches.
+func (m *Manager) contextScope(flags *flagSet) string {
+	if flags.Has("default") {
+		return ""
+	}
+	branch, err := m.git.GetCurrentBranch()
+	if err != nil || m.getWorkType(branch) == "" {
+		return ""
+	}
+	return branch
+}
+
+// setContextValue stores a context value on branch, or as the repository
+// default when branch is "". It returns the previous effective value.
+func (m *Manager) setContextValue(branch, name, repoKey, value string) (string, error) {
+	if branch == "" {
+		old, _ := m.git.GetConfig(repoKey)
+		return old, m.git.SetConfig(repoKey, value)
+	}
+
+	old := m.contextValue(branch, name, repoKey)
+	return old, m.git.SetConfig(branchContextKey(branch, name), value)
+}
+
+// scopeSuffix describes where a context value was stored
+func scopeSuffix(branch string) string {
+	if branch == "" {
+		return " (repository default)"
+	}
+	return fmt.Sprintf(" (branch %s)", branch)
+}
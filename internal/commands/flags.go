@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+)
+
+// Flag declares an option of a command. The same declaration parses the
+// arguments, prints the help and completes the option.
+type Flag struct {
+	Short    string   // single letter, given as -x
+	Long     string   // given as --name; the key of the parsed value
+	Value    string   // placeholder of the value, empty for switches
+	Optional bool     // the value may be left out, leaving it ""
+	Help     string   // one line, wrapped in the help
+	Values   []string // fixed completion candidates of the value
+	Source   string   // dynamic completion source of the value, see Complete
+}
+
+// helpFlag is accepted by every command
+var helpFlag = Flag{Short: "h", Long: "help", Help: "Show this help message"}
+
+// names returns the flag as typed, e.g. "-s, --save"
+func (f Flag) names() string {
+	name := "    --" + f.Long
+	if f.Short != "" {
+		name = "-" + f.Short + ", --" + f.Long
+	}
+	switch {
+	case f.Optional:
+		name += " [<" + f.Value + ">]"
+	case f.Value != "":
+		name += " <" + f.Value + ">"
+	}
+	return name
+}
+
+// flagSet holds the flags and positional arguments of a command line
+type flagSet struct {
+	args   []string
+	order  []string
+	values map[string][]string
+}
+
+// Has reports whether the flag was given
+func (s *flagSet) Has(long string) bool {
+	_, ok := s.values[long]
+	return ok
+}
+
+// Count returns how many times the flag was given
+func (s *flagSet) Count(long string) int {
+	return len(s.values[long])
+}
+
+// String returns the last value of the flag, or ""
+func (s *flagSet) String(long string) string {
+	values := s.values[long]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// Strings returns every value of a repeated flag
+func (s *flagSet) Strings(long string) []string {
+	return s.values[long]
+}
+
+// Args returns the positional arguments
+func (s *flagSet) Args() []string {
+	return s.args
+}
+
+// First returns the first of the flags given on the command line, or ""
+func (s *flagSet) First(longs ...string) string {
+	for _, given := range s.order {
+		for _, long := range longs {
+			if given == long {
+				return long
+			}
+		}
+	}
+	return ""
+}
+
+// Last returns the last of the flags given on the command line, or ""
+func (s *flagSet) Last(longs ...string) string {
+	for i := len(s.order) - 1; i >= 0; i-- {
+		for _, long := range longs {
+			if s.order[i] == long {
+				return long
+			}
+		}
+	}
+	return ""
+}
+
+// parse parses args against the flags of the command. Short switches
+// combine (-sp), values follow the flag or are attached (--base=main,
+// -bmain), and "--" ends the options. -h and --help are always accepted.
+// Unknown options are usage errors.
+func (c *Command) parse(args []string) (*flagSet, error) {
+	set := &flagSet{values: make(map[string][]string)}
+	flags := c.AllFlags()
+
+	add := func(flag Flag, value string) {
+		set.order = append(set.order, flag.Long)
+		set.values[flag.Long] = append(set.values[flag.Long], value)
+	}
+	// value returns the value of flag, attached or as the next argument
+	value := func(flag Flag, attached string, hasAttached bool, i *int) (string, error) {
+		if hasAttached {
+			return attached, nil
+		}
+		if *i+1 < len(args) && !strings.HasPrefix(args[*i+1], "-") {
+			*i++
+			return args[*i], nil
+		}
+		if flag.Optional {
+			return "", nil
+		}
+		return "", errs.New(errs.Usage, "--%s requires a value", flag.Long)
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			set.args = append(set.args, args[i+1:]...)
+			return set, nil
+
+		case strings.HasPrefix(arg, "--"):
+			name, attached, hasAttached := strings.Cut(arg[2:], "=")
+			flag, ok := findFlag(flags, func(f Flag) bool { return f.Long == name })
+			if !ok {
+				return nil, c.unknownOption(arg)
+			}
+			if flag.Value == "" {
+				if hasAttached {
+					return nil, errs.New(errs.Usage, "--%s does not take a value", flag.Long)
+				}
+				add(flag, "")
+				continue
+			}
+			v, err := value(flag, attached, hasAttached, &i)
+			if err != nil {
+				return nil, err
+			}
+			add(flag, v)
+
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for j := 1; j < len(arg); j++ {
+				letter := arg[j : j+1]
+				flag, ok := findFlag(flags, func(f Flag) bool { return f.Short == letter })
+				if !ok {
+					return nil, c.unknownOption("-" + letter)
+				}
+				if flag.Value == "" {
+					add(flag, "")
+					continue
+				}
+				// The rest of a combined flag is its value: -bmain
+				v, err := value(flag, arg[j+1:], j+1 < len(arg), &i)
+				if err != nil {
+					return nil, err
+				}
+				add(flag, v)
+				break
+			}
+
+		default:
+			set.args = append(set.args, arg)
+		}
+	}
+	return set, nil
+}
+
+// findFlag returns the first flag matching match
+func findFlag(flags []Flag, match func(Flag) bool) (Flag, bool) {
+	for _, flag := range flags {
+		if match(flag) {
+			return flag, true
+		}
+	}
+	return Flag{}, false
+}
+
+// extraArgs returns the usage error of positional arguments beyond the
+// first max, or nil
+func (c *Command) extraArgs(set *flagSet, max int) error {
+	if len(set.args) <= max {
+		return nil
+	}
+	return errs.New(errs.Usage, "Unexpected argument '%s' (see 'git @ %s --help')", set.args[max], c.Path())
+}
+
+// unknownOption returns the usage error of an undeclared option
+func (c *Command) unknownOption(arg string) error {
+	return errs.New(errs.Usage, "Unknown option '%s' (see 'git @ %s --help')", arg, c.Path())
+}
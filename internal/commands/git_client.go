@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/potsed/gitAT/internal/config"
+	"github.com/potsed/gitAT/internal/git"
+	"github.com/potsed/gitAT/internal/provider"
+)
+
+// GitClient is the git access used by Manager. *git.Repository runs the git
+// binary; tests use the scriptable fake in internal/git/gittest.
+type GitClient interface {
+	// Run executes a git command and returns its trimmed output
+	Run(args ...string) (string, error)
+
+	// RunWithEnv executes a git command with additional environment variables
+	RunWithEnv(env []string, args ...string) (string, error)
+
+	// RunWithInput executes a git command reading input on its standard input
+	RunWithInput(input string, args ...string) (string, error)
+
+	// Stream executes a long-running git command, showing its output
+	Stream(args ...string) error
+
+	// GetCurrentBranch returns the checked out branch
+	GetCurrentBranch() (string, error)
+
+	// GetConfig returns a git config value
+	GetConfig(key string) (string, error)
+
+	// GetConfigAll returns all values of a multi-valued git config key
+	GetConfigAll(key string) ([]string, error)
+
+	// SetConfig sets a git config value
+	SetConfig(key, value string) error
+
+	// GetBranches returns the local branches
+	GetBranches() ([]string, error)
+
+	// GetMergedBranches returns the local branches merged into branch
+	GetMergedBranches(branch string) ([]string, error)
+
+	// DeleteBranch deletes a local branch
+	DeleteBranch(name string, force bool) error
+}
+
+var _ GitClient = (*git.Repository)(nil)
+
+// NewManagerWithClients creates a commands manager using the given git
+// client and runner for platform CLIs (gh, glab, ...). A nil runner runs
+// the real binaries.
+func NewManagerWithClients(cfg *config.Config, client GitClient, cli provider.Runner) *Manager {
+	return &Manager{
+		config: cfg,
+		git:    client,
+		cli:    cli,
+	}
+}
+
+// SetContext makes the git and platform CLI commands of the manager stop
+// when ctx is cancelled
+func (m *Manager) SetContext(ctx context.Context) {
+	m.ctx = ctx
+	if repo, ok := m.git.(*git.Repository); ok {
+		m.git = repo.WithContext(ctx)
+	}
+}
@@ -0,0 +1,431 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// CommitInfo describes a commit in JSON output
+type CommitInfo struct {
+	Hash    string    `json:"hash"`
+	Short   string    `json:"short"`
+	Subject string    `json:"subject"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+}
+
+// ChangeSet lists the working tree changes by state
+type ChangeSet struct {
+	Staged     []string `json:"staged"`
+	Unstaged   []string `json:"unstaged"`
+	Untracked  []string `json:"untracked"`
+	Conflicted []string `json:"conflicted"`
+}
+
+// Clean reports whether the working tree has no changes
+func (c ChangeSet) Clean() bool {
+	return len(c.Staged)+len(c.Unstaged)+len(c.Untracked)+len(c.Conflicted) == 0
+}
+
+// Divergence compares a branch with another ref
+type Divergence struct {
+	Ref    string `json:"ref"`
+	SHA    string `json:"sha"`
+	Ahead  int    `json:"ahead"`
+	Behind int    `json:"behind"`
+}
+
+// recentCommits returns the last n commits of rev
+func (m *Manager) recentCommits(rev string, n int) ([]CommitInfo, error) {
+	out, err := m.git.Run("log", fmt.Sprintf("-%d", n), "--format=%H%x1f%h%x1f%an%x1f%aI%x1f%s", rev)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []CommitInfo{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[3])
+		commits = append(commits, CommitInfo{
+			Hash:    fields[0],
+			Short:   fields[1],
+			Author:  fields[2],
+			Date:    date,
+			Subject: fields[4],
+		})
+	}
+	return commits, nil
+}
+
+// workingTreeChanges returns the changed files of the working tree
+func (m *Manager) workingTreeChanges() (ChangeSet, error) {
+	changes := ChangeSet{
+		Staged:     []string{},
+		Unstaged:   []string{},
+		Untracked:  []string{},
+		Conflicted: []string{},
+	}
+
+	out, err := m.git.Run("status", "--porcelain=v2")
+	if err != nil {
+		return changes, err
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+
+		// Entries end with the path; renames add the original path after a tab
+		var xy, path string
+		switch line[0] {
+		case '?':
+			changes.Untracked = append(changes.Untracked, line[2:])
+			continue
+		case 'u':
+			if fields := strings.SplitN(line, " ", 11); len(fields) == 11 {
+				changes.Conflicted = append(changes.Conflicted, fields[10])
+			}
+			continue
+		case '1':
+			fields := strings.SplitN(line, " ", 9)
+			if len(fields) != 9 {
+				continue
+			}
+			xy, path = fields[1], fields[8]
+		case '2':
+			fields := strings.SplitN(line, " ", 10)
+			if len(fields) != 10 {
+				continue
+			}
+			xy, path = fields[1], strings.SplitN(fields[9], "\t", 2)[0]
+		default:
+			continue
+		}
+
+		if xy[0] != '.' {
+			changes.Staged = append(changes.Staged, path)
+		}
+		if xy[1] != '.' {
+			changes.Unstaged = append(changes.Unstaged, path)
+		}
+	}
+	return changes, nil
+}
+
+// divergenceFrom compares HEAD with ref, or returns nil when ref is unknown
+func (m *Manager) divergenceFrom(ref string) *Divergence {
+	sha, err := m.git.Run("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil || sha == "" {
+		return nil
+	}
+
+	counts, err := m.git.Run("rev-list", "--left-right", "--count", ref+"...HEAD")
+	if err != nil {
+		return nil
+	}
+	fields := strings.Fields(counts)
+	if len(fields) != 2 {
+		return nil
+	}
+	behind, _ := strconv.Atoi(fields[0])
+	ahead, _ := strconv.Atoi(fields[1])
+	return &Divergence{Ref: ref, SHA: sha, Ahead: ahead, Behind: behind}
+}
+
+// divergenceLabel formats a divergence for text output
+func divergenceLabel(d *Divergence) string {
+	if d == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%d ahead, %d behind)", d.Ref, d.Ahead, d.Behind)
+}
+
+// InfoReport is the document of git @ info
+type InfoReport struct {
+	Repository    string       `json:"repository"`
+	Branch        string       `json:"branch"`
+	WorkingBranch string       `json:"working_branch"`
+	Trunk         string       `json:"trunk"`
+	Type          string       `json:"type"`
+	Product       string       `json:"product"`
+	Feature       string       `json:"feature"`
+	Issue         string       `json:"issue"`
+	Label         string       `json:"label"`
+	Version       string       `json:"version"`
+	Remote        string       `json:"remote"`
+	TrunkStatus   *Divergence  `json:"trunk_status"`
+	Upstream      *Divergence  `json:"upstream"`
+	Changes       ChangeSet    `json:"changes"`
+	Commits       []CommitInfo `json:"commits"`
+}
+
+// Info handles the info command
+func (m *Manager) Info(args []string) error {
+	if len(args) == 1 {
+		switch args[0] {
+		case "-h", "--help", "help", "h":
+			return m.help("info")
+		}
+	}
+
+	report, err := m.Report()
+	if err != nil {
+		return err
+	}
+
+	if output.JSONEnabled() {
+		return output.JSON(report)
+	}
+
+	output.Title("📊 GitAT Info")
+	output.Table([]string{"Setting", "Value"}, [][]string{
+		{"Repository", report.Repository},
+		{"Branch", orDash(report.Branch)},
+		{"Working branch", orDash(report.WorkingBranch)},
+		{"Trunk", divergenceLabel(report.TrunkStatus)},
+		{"Upstream", divergenceLabel(report.Upstream)},
+		{"Type", orDash(report.Type)},
+		{"Context", orDash(report.Context())},
+		{"Label", orDash(report.Label)},
+		{"Version", report.Version},
+		{"Remote", orDash(report.Remote)},
+	})
+
+	fmt.Println()
+	if changes := report.Changes; changes.Clean() {
+		fmt.Println("✅ Working tree clean")
+	} else {
+		fmt.Printf("📝 Changes: %d staged, %d unstaged, %d untracked, %d conflicted\n",
+			len(changes.Staged), len(changes.Unstaged), len(changes.Untracked), len(changes.Conflicted))
+	}
+
+	if len(report.Commits) > 0 {
+		fmt.Println()
+		fmt.Println("Recent commits:")
+		for _, commit := range report.Commits {
+			fmt.Printf("  %s %s\n", commit.Short, commit.Subject)
+		}
+	}
+	return nil
+}
+
+// Report returns the state of the repository shown by git @ info
+func (m *Manager) Report() (InfoReport, error) {
+	repoPath, err := m.git.Run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return InfoReport{}, errs.New(errs.NotARepo, "Not in a git repository")
+	}
+
+	branch, _ := m.git.GetCurrentBranch()
+	ctx := m.getBranchContext(branch)
+	workingBranch, _ := m.git.GetConfig("at.branch")
+	remote, _ := m.git.GetConfig("remote.origin.url")
+	label, _ := m.showLabel()
+	version, _ := m.getVersion()
+	trunk := m.trunkBranch()
+
+	changes, err := m.workingTreeChanges()
+	if err != nil {
+		return InfoReport{}, fmt.Errorf("failed to get changes: %w", err)
+	}
+	commits, _ := m.recentCommits("HEAD", 5)
+
+	report := InfoReport{
+		Repository:    repoPath,
+		Branch:        branch,
+		WorkingBranch: workingBranch,
+		Trunk:         trunk,
+		Type:          m.getWorkType(branch),
+		Product:       ctx.Product,
+		Feature:       ctx.Feature,
+		Issue:         ctx.Issue,
+		Label:         label,
+		Version:       version,
+		Remote:        remote,
+		TrunkStatus:   m.divergenceFrom(trunk),
+		Upstream:      m.divergenceFrom("@{upstream}"),
+		Changes:       changes,
+		Commits:       commits,
+	}
+	if report.Commits == nil {
+		report.Commits = []CommitInfo{}
+	}
+	return report, nil
+}
+
+// Context returns the product, feature and issue of the branch as one label
+func (r InfoReport) Context() string {
+	return branchContext{Product: r.Product, Feature: r.Feature, Issue: r.Issue}.label()
+}
+
+// RepoStats counts the history and refs of the repository
+type RepoStats struct {
+	Commits     int       `json:"commits"`
+	Authors     int       `json:"authors"`
+	Branches    int       `json:"branches"`
+	Tags        int       `json:"tags"`
+	FirstCommit time.Time `json:"first_commit"`
+	Remotes     []Remote  `json:"remotes"`
+}
+
+// Remote is a configured remote and its fetch URL
+type Remote struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Stats returns the statistics of the repository. Counts that git cannot
+// give, e.g. before the first commit, are zero.
+func (m *Manager) Stats() RepoStats {
+	var stats RepoStats
+	if out, err := m.git.Run("rev-list", "--count", "HEAD"); err == nil {
+		stats.Commits, _ = strconv.Atoi(out)
+	}
+	if out, err := m.git.Run("shortlog", "-s", "-e", "HEAD"); err == nil && out != "" {
+		stats.Authors = len(strings.Split(out, "\n"))
+	}
+	if out, err := m.git.Run("for-each-ref", "--format=%(refname)", "refs/heads", "refs/tags"); err == nil && out != "" {
+		for _, ref := range strings.Split(out, "\n") {
+			if strings.HasPrefix(ref, "refs/tags/") {
+				stats.Tags++
+			} else {
+				stats.Branches++
+			}
+		}
+	}
+	// Merged histories have several roots; the last one listed is the oldest
+	if roots, err := m.git.Run("rev-list", "--max-parents=0", "HEAD"); err == nil && roots != "" {
+		lines := strings.Split(roots, "\n")
+		if date, err := m.git.Run("log", "-1", "--format=%aI", lines[len(lines)-1]); err == nil {
+			stats.FirstCommit, _ = time.Parse(time.RFC3339, date)
+		}
+	}
+
+	stats.Remotes = []Remote{}
+	for _, name := range m.RemoteNames() {
+		url, _ := m.git.Run("remote", "get-url", name)
+		stats.Remotes = append(stats.Remotes, Remote{Name: name, URL: url})
+	}
+	return stats
+}
+
+// orDash returns value, or "-" when it is empty
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func infoCommand() *Command {
+	return &Command{
+		Name:    "info",
+		Summary: "Comprehensive status report from all commands",
+		Usage:   []string{"[--json]"},
+		Description: `Show comprehensive status report from all GitAT commands.
+Provides a complete overview of current repository state.`,
+		Examples: []Example{
+			{"git @ info", "Show comprehensive status"},
+			{"git @ info --json", "Report for scripts and editors"},
+			{"git @ info --help", "Show this help"},
+		},
+		Sections: []Section{
+			{"FEATURES", `- Repository status
+- Branch information
+- Configuration summary
+- Recent commits
+- Uncommitted changes
+- Remote status`},
+		},
+		Run: (*Manager).Info,
+	}
+}
+
+// hashReport is the document of git @ hash
+type hashReport struct {
+	Branch    string      `json:"branch"`
+	Head      string      `json:"head"`
+	Trunk     *Divergence `json:"trunk"`
+	MergeBase string      `json:"merge_base"`
+	Parent    *Divergence `json:"parent"`
+	Upstream  *Divergence `json:"upstream"`
+}
+
+// Hash handles the hash command
+func (m *Manager) Hash(args []string) error {
+	if len(args) == 1 {
+		switch args[0] {
+		case "-h", "--help", "help", "h":
+			return m.help("hash")
+		}
+	}
+
+	head, err := m.git.Run("rev-parse", "HEAD")
+	if err != nil {
+		return errs.New(errs.NotFound, "No commits yet")
+	}
+
+	branch, _ := m.git.GetCurrentBranch()
+	trunk := m.trunkBranch()
+	report := hashReport{
+		Branch:   branch,
+		Head:     head,
+		Trunk:    m.divergenceFrom(trunk),
+		Upstream: m.divergenceFrom("@{upstream}"),
+	}
+	if report.Trunk != nil {
+		report.MergeBase, _ = m.git.Run("merge-base", trunk, "HEAD")
+	}
+	if parent := m.getStackParent(branch); parent != "" && parent != trunk {
+		report.Parent = m.divergenceFrom(parent)
+	}
+
+	if output.JSONEnabled() {
+		return output.JSON(report)
+	}
+
+	output.Title("🔗 Branch Status")
+	rows := [][]string{
+		{"Branch", orDash(report.Branch)},
+		{"HEAD", report.Head},
+		{"Trunk", divergenceLabel(report.Trunk)},
+		{"Merge base", orDash(report.MergeBase)},
+	}
+	if report.Parent != nil {
+		rows = append(rows, []string{"Parent", divergenceLabel(report.Parent)})
+	}
+	rows = append(rows, []string{"Upstream", divergenceLabel(report.Upstream)})
+	output.Table([]string{"Ref", "Status"}, rows)
+	return nil
+}
+
+func hashCommand() *Command {
+	return &Command{
+		Name:    "hash",
+		Summary: "Detailed branch status and commit relationships",
+		Usage:   []string{"[--json]"},
+		Description: `Show detailed branch status and commit relationships.
+Provides information about branch divergence and merge bases.`,
+		Examples: []Example{
+			{"git @ hash", "Show branch status and relationships"},
+			{"git @ hash --json", "Status for scripts and editors"},
+			{"git @ hash --help", "Show this help"},
+		},
+		Sections: []Section{
+			{"FEATURES", `- Branch divergence information
+- Commit relationship analysis
+- Merge base details
+- Branch comparison`},
+		},
+		Run: (*Manager).Hash,
+	}
+}
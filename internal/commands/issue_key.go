@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultIssuePattern matches Jira/Linear style keys such as PROJ-123
+const defaultIssuePattern = `[A-Z][A-Z0-9]+-\d+`
+
+// issuePattern returns the regex extracting issue keys, from at.issue.pattern
+func (m *Manager) issuePattern() *regexp.Regexp {
+	pattern, _ := m.git.GetConfig("at.issue.pattern")
+	if pattern == "" {
+		return regexp.MustCompile(defaultIssuePattern)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		fmt.Printf("Warning: Invalid at.issue.pattern '%s', using the default: %v\n", pattern, err)
+		return regexp.MustCompile(defaultIssuePattern)
+	}
+	return re
+}
+
+// matchIssue returns the issue key in s. A pattern with a capture group
+// returns the first group, so '#(\d+)' yields the number alone.
+func matchIssue(re *regexp.Regexp, s string) string {
+	match := re.FindStringSubmatch(s)
+	switch {
+	case match == nil:
+		return ""
+	case len(match) > 1 && match[1] != "":
+		return match[1]
+	default:
+		return match[0]
+	}
+}
+
+// setBranchIssue records the issue of a branch in branch.<name>.at-issue
+func (m *Manager) setBranchIssue(branch, issue string) error {
+	return m.git.SetConfig(fmt.Sprintf("branch.%s.at-issue", branch), issue)
+}
+
+// branchIssue returns the issue a branch works on: the recorded
+// branch.<name>.at-issue, or the key found in the branch name
+func (m *Manager) branchIssue(branch string) string {
+	if branch == "" {
+		return ""
+	}
+	if issue, _ := m.git.GetConfig(fmt.Sprintf("branch.%s.at-issue", branch)); issue != "" {
+		return issue
+	}
+	return matchIssue(m.issuePattern(), branch)
+}
+
+// issueURL returns the link of an issue, from the at.issue.url template
+// (https://jira.example.com/browse/{issue}) or the Jira tracker URL
+func (m *Manager) issueURL(issue string) string {
+	if template, _ := m.git.GetConfig("at.issue.url"); template != "" {
+		return strings.ReplaceAll(template, "{issue}", issue)
+	}
+
+	kind, _ := m.git.GetConfig("at.tracker")
+	base, _ := m.git.GetConfig("at.tracker.url")
+	if kind == "jira" && base != "" {
+		return strings.TrimSuffix(base, "/") + "/browse/" + issue
+	}
+	return ""
+}
+
+// issueTrailer returns the trailer token used by save: Refs, Closes or ""
+// when at.issue.trailer is none
+func (m *Manager) issueTrailer(closes bool) string {
+	if closes {
+		return "Closes"
+	}
+
+	setting, _ := m.git.GetConfig("at.issue.trailer")
+	switch strings.ToLower(setting) {
+	case "none", "false", "off":
+		return ""
+	case "closes":
+		return "Closes"
+	default:
+		return "Refs"
+	}
+}
+
+// prIssues returns the issues linked from a PR: the branch issue, which the
+// PR closes, followed by the issues mentioned in its commits
+func (m *Manager) prIssues(branch string, commits []prCommit) []issueLink {
+	var links []issueLink
+	seen := make(map[string]bool)
+
+	add := func(key, keyword string) {
+		if key == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		links = append(links, issueLink{Key: key, URL: m.issueURL(key), Keyword: keyword})
+	}
+
+	add(m.branchIssue(branch), "Closes")
+
+	re := m.issuePattern()
+	for _, commit := range commits {
+		add(matchIssue(re, commit.Subject), "Refs")
+	}
+	return links
+}
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/internal/tracker"
+)
+
+// maxIssueSlugLength limits the part of the branch name taken from the issue title
+const maxIssueSlugLength = 40
+
+// trackerTokenEnv lists the environment variables holding the token of each
+// tracker, checked after GITAT_TRACKER_TOKEN
+var trackerTokenEnv = map[string][]string{
+	"jira":   {"JIRA_API_TOKEN"},
+	"github": {"GITHUB_TOKEN", "GH_TOKEN"},
+	"gitlab": {"GITLAB_TOKEN"},
+	"linear": {"LINEAR_API_KEY"},
+}
+
+// issueTracker returns the configured tracker backend. at.tracker defaults to
+// the platform of the origin remote.
+func (m *Manager) issueTracker() (tracker.Tracker, error) {
+	kind, _ := m.git.GetConfig("at.tracker")
+	if kind == "" {
+		kind = m.detectPlatform()
+		if kind != "github" && kind != "gitlab" {
+			return nil, errs.New(errs.Usage, "No issue tracker configured\nSet one with: git config at.tracker <%s>", strings.Join(tracker.Kinds, "|"))
+		}
+	}
+
+	cfg := tracker.Config{Kind: kind}
+	cfg.BaseURL, _ = m.git.GetConfig("at.tracker.url")
+	cfg.User, _ = m.git.GetConfig("at.tracker.user")
+	cfg.Project, _ = m.git.GetConfig("at.tracker.project")
+	if cfg.Project == "" && (kind == "github" || kind == "gitlab") {
+		cfg.Project, _ = m.getRepoInfo()
+	}
+
+	cfg.Token = os.Getenv("GITAT_TRACKER_TOKEN")
+	for _, env := range trackerTokenEnv[kind] {
+		if cfg.Token != "" {
+			break
+		}
+		cfg.Token = os.Getenv(env)
+	}
+
+	t, err := tracker.New(cfg)
+	if err != nil {
+		return nil, errs.Wrap(errs.Usage, err, "Invalid issue tracker configuration")
+	}
+	return t, nil
+}
+
+// fetchIssue fetches an issue from the configured tracker
+func (m *Manager) fetchIssue(key string) (*tracker.Issue, error) {
+	t, err := m.issueTracker()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Fetching %s from %s...\n", key, t.Name())
+	issue, err := t.Fetch(key)
+	if err != nil {
+		return nil, errs.Wrap(errs.KindOf(err), err, "Failed to fetch issue %s", key)
+	}
+	return issue, nil
+}
+
+// issueBranchSuffix returns the <KEY>-<slug> part of a branch name for an
+// issue. The key keeps its case so it can be matched back from the branch.
+func (m *Manager) issueBranchSuffix(issue *tracker.Issue) string {
+	key := regexp.MustCompile(`[^A-Za-z0-9-]`).ReplaceAllString(issue.Key, "")
+
+	slug := ""
+	if issue.Title != "" {
+		slug = m.formatBranchName(issue.Title)
+	}
+	if len(slug) > maxIssueSlugLength {
+		slug = slug[:maxIssueSlugLength]
+		// Cut at a word boundary when there is one
+		if idx := strings.LastIndex(slug, "-"); idx > maxIssueSlugLength/2 {
+			slug = slug[:idx]
+		}
+		slug = strings.Trim(slug, "-")
+	}
+
+	switch {
+	case key == "":
+		return slug
+	case slug == "":
+		return key
+	default:
+		return key + "-" + slug
+	}
+}
+
+// showIssueSummary prints the fetched issue
+func showIssueSummary(issue *tracker.Issue) {
+	fmt.Printf("  %s: %s\n", issue.Key, issue.Title)
+	fmt.Printf("  Type: %s, Status: %s\n", issue.Type, issue.Status)
+	if issue.URL != "" {
+		fmt.Printf("  %s\n", issue.URL)
+	}
+	if issue.Closed() {
+		fmt.Printf("Warning: %s is already %s\n", issue.Key, strings.ToLower(issue.Status))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/potsed/gitAT/internal/label"
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// Default templates, used when the matching config key is unset
+const (
+	defaultLabelTemplate   = "{?[{context}]}"
+	defaultIDTemplate      = "{product}:{major}{minor}{fix}"
+	defaultPRTitleTemplate = "{subject}"
+)
+
+// labelPlaceholders lists the placeholders available in every template
+var labelPlaceholders = []string{
+	"product", "feature", "issue", "task", "context", "type", "branch",
+	"version", "major", "minor", "fix", "user", "date",
+}
+
+// labelValues returns the placeholder values for the current branch
+func (m *Manager) labelValues() label.Values {
+	branch, _ := m.git.GetCurrentBranch()
+	ctx := m.getBranchContext(branch)
+
+	version, _ := m.getVersion()
+	major, _ := m.git.GetConfig("at.major")
+	minor, _ := m.git.GetConfig("at.minor")
+	fix, _ := m.git.GetConfig("at.fix")
+	user, _ := m.git.GetConfig("user.name")
+
+	return label.Values{
+		"product": ctx.Product,
+		"feature": ctx.Feature,
+		"issue":   ctx.Issue,
+		"task":    ctx.Issue,
+		"context": ctx.label(),
+		"type":    m.getWorkType(branch),
+		"branch":  branch,
+		"version": version,
+		"major":   major,
+		"minor":   minor,
+		"fix":     fix,
+		"user":    user,
+		"date":    time.Now().Format("2006-01-02"),
+	}
+}
+
+// templateError is a template in key that cannot be rendered
+type templateError struct {
+	Key      string
+	Template string
+	Err      error
+}
+
+// Error returns the key, the template and why it is invalid
+func (e *templateError) Error() string {
+	return fmt.Sprintf("invalid %s template '%s': %v", e.Key, e.Template, e.Err)
+}
+
+// Unwrap returns the error of the renderer
+func (e *templateError) Unwrap() error {
+	return e.Err
+}
+
+// renderTemplate renders the template stored in key, or fallback when it is
+// unset. extra adds command specific placeholders such as {subject}. An
+// invalid template is a *templateError.
+func (m *Manager) renderTemplate(key, fallback string, extra label.Values) (string, error) {
+	template, _ := m.git.GetConfig(key)
+	if template == "" {
+		template = fallback
+	}
+
+	values := m.labelValues()
+	for name, value := range extra {
+		values[name] = value
+	}
+
+	out, err := label.Render(template, values)
+	if err != nil {
+		return "", &templateError{Key: key, Template: template, Err: err}
+	}
+	return out, nil
+}
+
+// renderTemplateOrDefault renders the template stored in key, warning and
+// using fallback when the configured template is invalid
+func (m *Manager) renderTemplateOrDefault(key, fallback string, extra label.Values) string {
+	out, err := m.renderTemplate(key, fallback, extra)
+	if err == nil {
+		return out
+	}
+
+	output.Warning("%v, using the default", err)
+	values := m.labelValues()
+	for name, value := range extra {
+		values[name] = value
+	}
+	out, _ = label.Render(fallback, values)
+	return out
+}
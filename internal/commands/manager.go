@@ -1,33 +1,68 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/huh"
 	"github.com/potsed/gitAT/internal/config"
+	"github.com/potsed/gitAT/internal/errs"
 	"github.com/potsed/gitAT/internal/git"
+	"github.com/potsed/gitAT/internal/label"
+	"github.com/potsed/gitAT/internal/picker"
+	"github.com/potsed/gitAT/internal/provider"
+	"github.com/potsed/gitAT/internal/tracker"
 	"github.com/potsed/gitAT/pkg/output"
 )
 
+// workTypes lists the supported work branch types (Conventional Commits)
+var workTypes = []string{"hotfix", "feature", "bugfix", "release", "chore", "docs", "style", "refactor", "perf", "test", "ci", "build", "revert"}
+
 // Manager handles all GitAT commands
 type Manager struct {
 	config *config.Config
-	git    *git.Repository
+	git    GitClient
+	cli    provider.Runner // platform CLIs, nil runs the real binaries
+	ctx    context.Context // cancels platform CLIs, see SetContext
+	plan   *git.Planner    // records changes instead of making them, see SetDryRun
+	batch  bool            // no terminal prompts, see SetInteractive
+	picker picker.Func     // asks for omitted branches, nil without a terminal
 }
 
 // NewManager creates a new commands manager
 func NewManager(cfg *config.Config) *Manager {
-	return &Manager{
+	m := &Manager{
 		config: cfg,
 		git:    git.NewRepository(cfg.RepoPath),
 	}
+	if picker.Available() {
+		m.picker = picker.Run
+	}
+	return m
+}
+
+// SetInteractive allows the prompts and forms drawn on the terminal. Without
+// them, commands that need a confirmation fail instead, e.g. when their
+// output is captured by the TUI.
+func (m *Manager) SetInteractive(interactive bool) {
+	m.batch = !interactive
+}
+
+// requireTerminal fails when prompts are not allowed
+func (m *Manager) requireTerminal(action string) error {
+	if m.batch {
+		return errs.New(errs.Usage, "%s needs a confirmation; run it in a terminal", action)
+	}
+	return nil
 }
 
 // writeVersionLog writes version change logs to a file
@@ -39,45 +74,18 @@ func (m *Manager) writeVersionLog(message string) error {
 	}
 	gitRoot = strings.TrimSpace(gitRoot)
 
-	// Create logs directory if it doesn't exist
-	logsDir := filepath.Join(gitRoot, ".git", "gitat-logs")
-	if err := os.MkdirAll(logsDir, 0755); err != nil {
-		return fmt.Errorf("failed to create logs directory: %w", err)
-	}
-
-	// Create log file path
-	logFile := filepath.Join(logsDir, "version-changes.log")
-
-	// Open file in append mode, create if doesn't exist
-	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
-	}
-	defer file.Close()
-
 	// Write log entry with timestamp
+	logFile := filepath.Join(gitRoot, ".git", "gitat-logs", "version-changes.log")
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	logEntry := fmt.Sprintf("[%s] %s\n", timestamp, message)
 
-	_, err = io.WriteString(file, logEntry)
-	if err != nil {
-		return fmt.Errorf("failed to write to log file: %w", err)
-	}
-
-	return nil
+	return m.appendFile(logFile, []byte(logEntry))
 }
 
 // Work handles the work command
 func (m *Manager) Work(args []string) error {
-	if len(args) == 0 {
-		return m.showWorkUsage()
-	}
-
-	if len(args) == 1 {
-		switch args[0] {
-		case "-h", "--help", "help", "h":
-			return m.showWorkUsage()
-		}
+	if len(args) == 0 || isHelp(args) {
+		return m.help("work")
 	}
 
 	return m.createWorkBranch(args)
@@ -85,56 +93,78 @@ func (m *Manager) Work(args []string) error {
 
 // Helper methods for work functionality
 func (m *Manager) createWorkBranch(args []string) error {
-	var workType, description, fullName string
-
-	// Parse arguments
-	for i := 0; i < len(args); i++ {
-		arg := args[i]
-		switch arg {
-		case "-n", "--name":
-			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
-				fullName = args[i+1]
-				i++ // Skip next argument
-			} else {
-				return fmt.Errorf("error: --name requires a value")
-			}
-		default:
-			if workType == "" {
-				workType = arg
-			} else if description == "" {
-				description = arg
-			} else {
-				return fmt.Errorf("error: Too many arguments")
-			}
+	cmd := command("work")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("work")
+	}
+	if err := cmd.extraArgs(flags, 2); err != nil {
+		return err
+	}
+
+	var workType, description string
+	if positional := flags.Args(); len(positional) > 0 {
+		workType = positional[0]
+		if len(positional) > 1 {
+			description = positional[1]
 		}
 	}
+	fullName, issueKey := flags.String("name"), flags.String("issue")
+	worktree, noWorktree := flags.Has("worktree"), flags.Has("no-worktree")
 
 	// Validate we're in a git repository
-	_, err := m.git.Run("rev-parse", "--git-dir")
+	_, err = m.git.Run("rev-parse", "--git-dir")
 	if err != nil {
-		return fmt.Errorf("error: Not in a git repository")
+		return errs.New(errs.NotARepo, "Not in a git repository")
 	}
 
 	// Get current branch
 	currentBranch, err := m.git.GetCurrentBranch()
 	if err != nil {
-		return fmt.Errorf("error: Not on a branch (detached HEAD state)")
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+
+	useWorktree := m.useWorktree(worktree, noWorktree)
+
+	// Derive the work type and description from the issue
+	var issue *tracker.Issue
+	if issueKey != "" {
+		issue, err = m.fetchIssue(issueKey)
+		if err != nil {
+			return err
+		}
+		showIssueSummary(issue)
+
+		if workType == "" {
+			workType = issue.WorkType()
+		}
 	}
 
 	// If full name provided, use it directly
 	if fullName != "" {
-		return m.createWorkBranchFromName(fullName, currentBranch)
+		if useWorktree {
+			err = m.createWorktreeBranch(fullName, currentBranch)
+		} else {
+			err = m.createWorkBranchFromName(fullName, currentBranch)
+		}
+		if err != nil {
+			return err
+		}
+		m.recordWorkIssue(fullName, issue)
+		return nil
 	}
 
 	// Validate work type
 	if workType == "" {
-		return fmt.Errorf("error: Work type is required\nAvailable types: hotfix, feature, bugfix, release, chore, docs, style, refactor, perf, test, ci, build, revert")
+		return errs.New(errs.Usage, "Work type is required\nAvailable types: %s", strings.Join(workTypes, ", "))
 	}
 
 	// Validate work type against allowed types
-	allowedTypes := []string{"hotfix", "feature", "bugfix", "release", "chore", "docs", "style", "refactor", "perf", "test", "ci", "build", "revert"}
 	validType := false
-	for _, t := range allowedTypes {
+	for _, t := range workTypes {
 		if workType == t {
 			validType = true
 			break
@@ -142,22 +172,30 @@ func (m *Manager) createWorkBranch(args []string) error {
 	}
 
 	if !validType {
-		return fmt.Errorf("error: Invalid work type '%s'\nAvailable types: %s", workType, strings.Join(allowedTypes, ", "))
+		return errs.New(errs.Usage, "Invalid work type '%s'\nAvailable types: %s", workType, strings.Join(workTypes, ", "))
 	}
 
 	// Prompt for description if not provided
-	if description == "" {
+	if description == "" && issue == nil {
 		fmt.Printf("Creating %s branch\n", workType)
 		fmt.Printf("Please enter a description for the %s: ", workType)
 
 		fmt.Scanln(&description)
 		if description == "" {
-			return fmt.Errorf("error: Description cannot be empty")
+			return errs.New(errs.Usage, "Description cannot be empty")
 		}
 	}
 
 	// Format description into kebab-case
-	formattedDescription := m.formatBranchName(description)
+	var formattedDescription string
+	if issue != nil {
+		if description != "" {
+			issue.Title = description
+		}
+		formattedDescription = m.issueBranchSuffix(issue)
+	} else {
+		formattedDescription = m.formatBranchName(description)
+	}
 
 	// Create branch name
 	branchName := fmt.Sprintf("%s-%s", workType, formattedDescription)
@@ -172,23 +210,29 @@ func (m *Manager) createWorkBranch(args []string) error {
 		// Validate trunk branch exists
 		_, err := m.git.Run("rev-parse", "--verify", trunkBranch)
 		if err != nil {
-			return fmt.Errorf("error: Trunk branch '%s' does not exist\nPlease ensure the trunk branch exists or configure it with: git @ _trunk <branch>", trunkBranch)
+			return errs.New(errs.NotFound, "Trunk branch '%s' does not exist\nPlease ensure the trunk branch exists or configure it with: git @ _trunk <branch>", trunkBranch)
+		}
+
+		// Worktrees branch from the updated trunk without switching
+		if useWorktree {
+			m.updateTrunk(trunkBranch, currentBranch)
+			return m.createWorktreeBranch(branchName, trunkBranch)
 		}
 
 		// Switch to trunk branch first
 		fmt.Printf("Switching to trunk branch: %s\n", trunkBranch)
 		_, err = m.git.Run("checkout", trunkBranch)
 		if err != nil {
-			return fmt.Errorf("error: Failed to switch to trunk branch '%s'", trunkBranch)
+			return fmt.Errorf("error: Failed to switch to trunk branch '%s': %w", trunkBranch, err)
 		}
 
 		// Update trunk branch
 		fmt.Println("Updating trunk branch...")
 		_, err = m.git.Run("remote", "get-url", "origin")
 		if err == nil {
-			_, err = m.git.Run("pull", "origin", trunkBranch)
+			err = m.git.Stream("pull", "origin", trunkBranch)
 			if err != nil {
-				fmt.Println("Warning: Failed to pull latest changes from remote, but continuing...")
+				fmt.Printf("Warning: Failed to pull latest changes from remote, but continuing... (%v)\n", err)
 			}
 		}
 
@@ -196,7 +240,30 @@ func (m *Manager) createWorkBranch(args []string) error {
 	}
 
 	// Create the work branch
-	return m.createWorkBranchFromName(branchName, currentBranch)
+	if useWorktree {
+		err = m.createWorktreeBranch(branchName, currentBranch)
+	} else {
+		err = m.createWorkBranchFromName(branchName, currentBranch)
+	}
+	if err != nil {
+		return err
+	}
+
+	m.recordWorkIssue(branchName, issue)
+	return nil
+}
+
+// recordWorkIssue records the issue of a new work branch on the branch
+// only, so other branches never get it
+func (m *Manager) recordWorkIssue(branch string, issue *tracker.Issue) {
+	if issue == nil || !m.branchExists(branch) {
+		return
+	}
+	if err := m.setBranchIssue(branch, issue.Key); err != nil {
+		fmt.Printf("Warning: Failed to record the branch issue: %v\n", err)
+	} else {
+		fmt.Printf("Issue of %s set to %s\n", branch, issue.Key)
+	}
 }
 
 func (m *Manager) createWorkBranchFromName(branchName, baseBranch string) error {
@@ -204,13 +271,13 @@ func (m *Manager) createWorkBranchFromName(branchName, baseBranch string) error
 
 	// Validate branch name
 	if !m.validateBranchName(branchName) {
-		return fmt.Errorf("error: Invalid branch name '%s'\nBranch names must contain only alphanumeric characters, hyphens, underscores, and slashes", branchName)
+		return errs.New(errs.Usage, "Invalid branch name '%s'\nBranch names must contain only alphanumeric characters, hyphens, underscores, and slashes", branchName)
 	}
 
 	// Check if branch already exists
 	_, err := m.git.Run("rev-parse", "--verify", branchName)
 	if err == nil {
-		return fmt.Errorf("error: Branch '%s' already exists", branchName)
+		return errs.New(errs.PolicyViolation, "Branch '%s' already exists", branchName)
 	}
 
 	// Check for uncommitted changes
@@ -238,10 +305,18 @@ func (m *Manager) createWorkBranchFromName(branchName, baseBranch string) error
 
 	// Create and switch to work branch
 	fmt.Printf("Creating %s branch: %s\n", workType, branchName)
+	previousBranch, _ := m.git.GetCurrentBranch()
 	_, err = m.git.Run("checkout", "-b", branchName)
 	if err != nil {
-		return fmt.Errorf("error: Failed to create %s branch '%s'", workType, branchName)
+		return fmt.Errorf("error: Failed to create %s branch '%s': %w", workType, branchName, err)
+	}
+
+	// Record the parent so stacked branches can be restacked
+	if err := m.setStackParent(branchName, baseBranch); err != nil {
+		fmt.Printf("Warning: Failed to record parent branch: %v\n", err)
 	}
+	m.inheritBranchContext(branchName, baseBranch)
+	m.recordBranchSwitch("work", previousBranch, branchName)
 
 	// Set working branch to new branch
 	fmt.Printf("Setting working branch to %s branch...\n", workType)
@@ -417,15 +492,8 @@ func (m *Manager) getTitleType(workType string) string {
 
 // Hotfix handles the hotfix command
 func (m *Manager) Hotfix(args []string) error {
-	if len(args) == 0 {
-		return m.createHotfix([]string{})
-	}
-
-	if len(args) == 1 {
-		switch args[0] {
-		case "-h", "--help", "help", "h":
-			return m.showHotfixUsage()
-		}
+	if isHelp(args) {
+		return m.help("hotfix")
 	}
 
 	return m.createHotfix(args)
@@ -433,39 +501,39 @@ func (m *Manager) Hotfix(args []string) error {
 
 // Helper methods for hotfix functionality
 func (m *Manager) createHotfix(args []string) error {
-	var hotfixName string
-
-	// Parse arguments
-	for i := 0; i < len(args); i++ {
-		arg := args[i]
-		switch arg {
-		case "-n", "--name":
-			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
-				hotfixName = args[i+1]
-				i++ // Skip next argument
-			} else {
-				return fmt.Errorf("error: --name requires a value")
-			}
-		default:
-			// If no name provided yet, use this as name
-			if hotfixName == "" {
-				hotfixName = arg
-			} else {
-				return fmt.Errorf("error: Unknown option '%s'", arg)
-			}
-		}
+	cmd := command("hotfix")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("hotfix")
 	}
 
+	// The name is given as --name or as the argument
+	limit := 1
+	if flags.Has("name") {
+		limit = 0
+	}
+	if err := cmd.extraArgs(flags, limit); err != nil {
+		return err
+	}
+	hotfixName := flags.String("name")
+	if len(flags.Args()) > 0 {
+		hotfixName = flags.Args()[0]
+	}
+	worktree, noWorktree := flags.Has("worktree"), flags.Has("no-worktree")
+
 	// Validate we're in a git repository
-	_, err := m.git.Run("rev-parse", "--git-dir")
+	_, err = m.git.Run("rev-parse", "--git-dir")
 	if err != nil {
-		return fmt.Errorf("error: Not in a git repository")
+		return errs.New(errs.NotARepo, "Not in a git repository")
 	}
 
 	// Get current branch
-	_, err = m.git.GetCurrentBranch()
+	currentBranch, err := m.git.GetCurrentBranch()
 	if err != nil {
-		return fmt.Errorf("error: Not on a branch (detached HEAD state)")
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
 	}
 
 	// Get trunk branch
@@ -477,7 +545,7 @@ func (m *Manager) createHotfix(args []string) error {
 	// Validate trunk branch exists
 	_, err = m.git.Run("rev-parse", "--verify", trunkBranch)
 	if err != nil {
-		return fmt.Errorf("error: Trunk branch '%s' does not exist\nPlease ensure the trunk branch exists or configure it with: git @ _trunk <branch>", trunkBranch)
+		return errs.New(errs.NotFound, "Trunk branch '%s' does not exist\nPlease ensure the trunk branch exists or configure it with: git @ _trunk <branch>", trunkBranch)
 	}
 
 	// Prompt for hotfix name if not provided
@@ -490,7 +558,7 @@ func (m *Manager) createHotfix(args []string) error {
 
 		fmt.Scanln(&hotfixName)
 		if hotfixName == "" {
-			return fmt.Errorf("error: Hotfix description cannot be empty")
+			return errs.New(errs.Usage, "Hotfix description cannot be empty")
 		}
 	}
 
@@ -501,13 +569,19 @@ func (m *Manager) createHotfix(args []string) error {
 
 	// Validate hotfix name
 	if !m.validateBranchName(hotfixName) {
-		return fmt.Errorf("error: Invalid branch name '%s'\nBranch names must contain only alphanumeric characters, hyphens, underscores, and slashes", hotfixName)
+		return errs.New(errs.Usage, "Invalid branch name '%s'\nBranch names must contain only alphanumeric characters, hyphens, underscores, and slashes", hotfixName)
 	}
 
 	// Check if hotfix branch already exists
 	_, err = m.git.Run("rev-parse", "--verify", hotfixName)
 	if err == nil {
-		return fmt.Errorf("error: Branch '%s' already exists", hotfixName)
+		return errs.New(errs.PolicyViolation, "Branch '%s' already exists", hotfixName)
+	}
+
+	// Worktrees leave the current checkout and its changes untouched
+	if m.useWorktree(worktree, noWorktree) {
+		m.updateTrunk(trunkBranch, currentBranch)
+		return m.createWorktreeBranch(hotfixName, trunkBranch)
 	}
 
 	// Check for uncommitted changes
@@ -537,16 +611,16 @@ func (m *Manager) createHotfix(args []string) error {
 	fmt.Printf("Switching to trunk branch: %s\n", trunkBranch)
 	_, err = m.git.Run("checkout", trunkBranch)
 	if err != nil {
-		return fmt.Errorf("error: Failed to switch to trunk branch '%s'", trunkBranch)
+		return fmt.Errorf("error: Failed to switch to trunk branch '%s': %w", trunkBranch, err)
 	}
 
 	// Ensure trunk branch is up to date
 	fmt.Println("Updating trunk branch...")
 	_, err = m.git.Run("remote", "get-url", "origin")
 	if err == nil {
-		_, err = m.git.Run("pull", "origin", trunkBranch)
+		err = m.git.Stream("pull", "origin", trunkBranch)
 		if err != nil {
-			fmt.Println("Warning: Failed to pull latest changes from remote, but continuing...")
+			fmt.Printf("Warning: Failed to pull latest changes from remote, but continuing... (%v)\n", err)
 		}
 	}
 
@@ -554,8 +628,14 @@ func (m *Manager) createHotfix(args []string) error {
 	fmt.Printf("Creating hotfix branch: %s\n", hotfixName)
 	_, err = m.git.Run("checkout", "-b", hotfixName)
 	if err != nil {
-		return fmt.Errorf("error: Failed to create hotfix branch '%s'", hotfixName)
+		return fmt.Errorf("error: Failed to create hotfix branch '%s': %w", hotfixName, err)
+	}
+
+	// Record the parent so the PR targets the trunk
+	if err := m.setStackParent(hotfixName, trunkBranch); err != nil {
+		fmt.Printf("Warning: Failed to record parent branch: %v\n", err)
 	}
+	m.recordBranchSwitch("hotfix", currentBranch, hotfixName)
 
 	// Set working branch to hotfix branch
 	fmt.Println("Setting working branch to hotfix branch...")
@@ -588,7 +668,7 @@ func (m *Manager) Save(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showSaveUsage()
+			return m.help("save")
 		}
 	}
 
@@ -597,7 +677,7 @@ func (m *Manager) Save(args []string) error {
 		// Check for dangerous characters
 		message := strings.Join(args, " ")
 		if strings.ContainsAny(message, ";|`$(){}") {
-			return fmt.Errorf("error: Invalid message. Use only alphanumeric characters, dots, underscores, and hyphens")
+			return errs.New(errs.Usage, "Invalid message. Use only alphanumeric characters, dots, underscores, and hyphens")
 		}
 	}
 
@@ -606,15 +686,26 @@ func (m *Manager) Save(args []string) error {
 
 // Helper methods for save functionality
 func (m *Manager) saveWork(args []string) error {
+	// Separate the issue trailer flags from the message
+	flags, err := command("save").parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("save")
+	}
+	closes, noIssue := flags.Has("closes"), flags.Has("no-issue")
+	args = flags.Args()
+
 	currentBranch, err := m.git.GetCurrentBranch()
 	if err != nil {
-		return fmt.Errorf("error: Not on a branch (detached HEAD state)")
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
 	}
 
 	workingBranch, _ := m.git.GetConfig("at.branch")
 	repoPath, err := m.git.Run("rev-parse", "--show-toplevel")
 	if err != nil {
-		return fmt.Errorf("error: Not in a git repository")
+		return errs.New(errs.NotARepo, "Not in a git repository")
 	}
 	repoPath = strings.TrimSpace(repoPath)
 
@@ -630,11 +721,14 @@ func (m *Manager) saveWork(args []string) error {
 
 	// Check branch protection
 	if currentBranch == "master" || currentBranch == "develop" {
-		return fmt.Errorf("error: Cannot save changes on %s. Create a new branch instead!", currentBranch)
+		return errs.New(errs.PolicyViolation, "Cannot save changes on %s. Create a new branch instead!", currentBranch)
 	}
 
 	if currentBranch == "prod" {
 		output.Warning("You are on the production branch!")
+		if err := m.requireTerminal("Committing to prod"); err != nil {
+			return err
+		}
 
 		// Use huh for confirmation
 		var confirmed bool
@@ -668,7 +762,7 @@ func (m *Manager) saveWork(args []string) error {
 			}
 		}
 	} else if currentBranch != workingBranch {
-		return fmt.Errorf("error: Cannot save changes. You're not on the correct working branch '%s'\nCurrent branch: '%s'\nTo fix this, run: git @ branch '%s'", workingBranch, currentBranch, currentBranch)
+		return errs.New(errs.PolicyViolation, "Cannot save changes. You're not on the correct working branch '%s'\nCurrent branch: '%s'\nTo fix this, run: git @ branch '%s'", workingBranch, currentBranch, currentBranch)
 	}
 
 	// Generate commit message with label and user message
@@ -702,11 +796,21 @@ func (m *Manager) saveWork(args []string) error {
 		return fmt.Errorf("failed to add changes: %w", err)
 	}
 
-	_, err = m.git.Run("commit", "-m", message)
+	commitArgs := []string{"commit", "-m", message}
+
+	// Link the commit to the issue of the branch
+	if issue := m.branchIssue(currentBranch); issue != "" && !noIssue {
+		if trailer := m.issueTrailer(closes); trailer != "" {
+			commitArgs = append(commitArgs, "--trailer", fmt.Sprintf("%s: %s", trailer, issue))
+		}
+	}
+
+	_, err = m.git.Run(commitArgs...)
 	if err != nil {
 		return fmt.Errorf("failed to commit changes: %w", err)
 	}
 
+	m.recordTimeEvent(timeSave, currentBranch, "save")
 	output.SaveSuccess(currentBranch, message)
 	return nil
 }
@@ -747,83 +851,36 @@ func (m *Manager) getWorkTypePrefix(branchName string) string {
 
 // Squash handles the squash command
 func (m *Manager) Squash(args []string) error {
-	if len(args) == 0 {
-		return m.squashToParent("", false)
+	flags, err := squashCommand().parse(args)
+	if err != nil {
+		return err
 	}
 
-	if len(args) >= 1 {
-		// Handle help first
-		if args[0] == "-h" || args[0] == "--help" || args[0] == "help" || args[0] == "h" {
-			return m.showSquashUsage()
-		}
-
-		// Handle auto command (requires value)
-		if args[0] == "-a" || args[0] == "--auto" {
-			if len(args) < 2 {
-				return fmt.Errorf("error: --auto requires a value (on|off|status)")
-			}
-			return m.handleAutoSquash(args[1])
-		}
-
-		// Parse flags for combined operations
-		var operations []string
-		var targetBranch string
-
-		// Parse all arguments
-		for i := 0; i < len(args); i++ {
-			arg := args[i]
-
-			// Handle combined flags like -sp
-			if strings.HasPrefix(arg, "-") && len(arg) > 1 {
-				// Parse each character in the flag
-				for j := 1; j < len(arg); j++ {
-					switch arg[j] {
-					case 's':
-						operations = append(operations, "save")
-					case 'p':
-						operations = append(operations, "pr")
-					}
-				}
-			} else {
-				// Handle individual flags
-				switch arg {
-				case "-s", "--save":
-					operations = append(operations, "save")
-				case "-p", "--pr":
-					operations = append(operations, "pr")
-				}
-			}
-
-			// If this is not a flag and we haven't set targetBranch yet, it's the target
-			if !strings.HasPrefix(arg, "-") && targetBranch == "" && arg != "save" && arg != "pr" {
-				targetBranch = arg
-			}
-		}
-
-		// Execute operations
-		if len(operations) > 0 {
-			// Handle PR operation (takes precedence)
-			for _, op := range operations {
-				if op == "pr" {
-					return m.squashForPR()
-				}
-			}
-
-			// Handle save operation
-			for _, op := range operations {
-				if op == "save" {
-					return m.squashToParent(targetBranch, true)
-				}
-			}
+	switch {
+	case flags.Has("help"):
+		return m.help("squash")
+	case flags.Has("auto"):
+		return m.handleAutoSquash(flags.String("auto"))
+	case flags.Has("pr"):
+		// PR squashing takes precedence over save
+		return m.squashForPR()
+	}
+
+	var targetBranch string
+	if len(flags.Args()) > 0 {
+		targetBranch = flags.Args()[0]
+	} else if m.canPickBranch() {
+		// Ask, starting on the parent it would be squashed onto
+		parent, _ := m.detectParentBranch()
+		choice, ok, err := m.pickBranch("Squash onto", parent, nil)
+		if err != nil {
+			return err
 		}
-
-		// If no operations specified but we have a target branch
-		if targetBranch != "" {
-			return m.squashToParent(targetBranch, false)
+		if ok {
+			targetBranch = choice.Name
 		}
 	}
-
-	return m.showSquashUsage()
+	return m.squashToParent(targetBranch, flags.Has("save"))
 }
 
 // Helper methods for squash functionality
@@ -842,11 +899,15 @@ func (m *Manager) squashToParent(targetBranch string, doSave bool) error {
 
 	headSHA, err = m.getHeadSHA(targetBranch)
 	if err != nil {
-		return fmt.Errorf("error: Branch \"%s\" does not exist locally", targetBranch)
+		return errs.New(errs.NotFound, "Branch \"%s\" does not exist locally", targetBranch)
 	}
 
 	fmt.Printf("Target branch: %s (SHA: %s)\n", targetBranch, headSHA)
 	err = m.performSquash(headSHA)
+	if errs.Is(err, errs.NothingToDo) && doSave {
+		fmt.Println(err)
+		return m.saveWork([]string{})
+	}
 	if err != nil {
 		return err
 	}
@@ -869,12 +930,12 @@ func (m *Manager) squashForPR() error {
 
 	currentBranch, err := m.git.GetCurrentBranch()
 	if err != nil {
-		return fmt.Errorf("error: Not on a branch (detached HEAD state)")
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
 	}
 
 	// Check if we're on the trunk branch
 	if currentBranch == trunkBranch {
-		return fmt.Errorf("error: Cannot squash PR from %s to itself", trunkBranch)
+		return errs.New(errs.PolicyViolation, "Cannot squash PR from %s to itself", trunkBranch)
 	}
 
 	// Get the number of commits ahead of trunk branch
@@ -885,8 +946,7 @@ func (m *Manager) squashForPR() error {
 
 	commitCount := strings.TrimSpace(output)
 	if commitCount == "0" || commitCount == "1" {
-		fmt.Println("Only one commit or no commits to squash")
-		return nil
+		return errs.New(errs.NothingToDo, "Only one commit or no commits to squash")
 	}
 
 	fmt.Printf("Found %s commits to squash for PR\n", commitCount)
@@ -907,35 +967,12 @@ func (m *Manager) squashForPR() error {
 		return fmt.Errorf("error: Failed to create temporary branch")
 	}
 
-	// Cherry-pick all commits from current branch
-	output, err = m.git.Run("rev-list", "--reverse", fmt.Sprintf("%s..HEAD", baseCommit))
-	if err != nil {
-		// Clean up on failure
-		m.git.Run("checkout", currentBranch)
-		m.git.Run("branch", "-D", tempBranch)
-		return fmt.Errorf("error: Failed to get commit list")
-	}
-
-	commitHashes := strings.Split(strings.TrimSpace(output), "\n")
-	cherryPickSuccess := true
-
-	for _, commitHash := range commitHashes {
-		commitHash = strings.TrimSpace(commitHash)
-		if commitHash != "" {
-			_, err = m.git.Run("cherry-pick", commitHash)
-			if err != nil {
-				fmt.Printf("Error: Failed to cherry-pick commit %s\n", commitHash)
-				cherryPickSuccess = false
-				break
-			}
-		}
-	}
-
-	if !cherryPickSuccess {
+	// Pick all commits from current branch into a single commit
+	if err := m.commitSquashed(baseCommit, currentBranch); err != nil {
 		// Clean up on failure
-		m.git.Run("checkout", currentBranch)
+		m.git.Run("checkout", "-f", currentBranch)
 		m.git.Run("branch", "-D", tempBranch)
-		return fmt.Errorf("error: Squashing failed due to conflicts")
+		return err
 	}
 
 	// Reset current branch to temp branch
@@ -958,6 +995,36 @@ func (m *Manager) squashForPR() error {
 	return nil
 }
 
+// commitSquashed applies the commits of base..head onto the checked out
+// branch as one commit, titled by the first commit and listing the others
+func (m *Manager) commitSquashed(base, head string) error {
+	output, err := m.git.Run("rev-list", "--reverse", fmt.Sprintf("%s..%s", base, head))
+	if err != nil {
+		return fmt.Errorf("error: Failed to get commit list")
+	}
+
+	for _, commitHash := range strings.Fields(output) {
+		if _, err := m.git.Run("cherry-pick", "--no-commit", commitHash); err != nil {
+			fmt.Printf("❌ Failed to cherry-pick commit %s\n", commitHash)
+			m.git.Run("cherry-pick", "--abort")
+			m.git.Run("reset", "--hard")
+			return errs.New(errs.Conflict, "Squashing failed due to conflicts")
+		}
+	}
+
+	subjects, _ := m.git.Run("log", "--reverse", "--format=%s", fmt.Sprintf("%s..%s", base, head))
+	lines := strings.Split(subjects, "\n")
+	commitArgs := []string{"commit", "--allow-empty", "-m", lines[0]}
+	if len(lines) > 1 {
+		commitArgs = append(commitArgs, "-m", "* "+strings.Join(lines[1:], "\n* "))
+	}
+	if _, err := m.git.Run(commitArgs...); err != nil {
+		m.git.Run("reset", "--hard")
+		return fmt.Errorf("❌ Failed to commit squashed changes")
+	}
+	return nil
+}
+
 func (m *Manager) handleAutoSquash(action string) error {
 	switch action {
 	case "on", "true", "enable", "1":
@@ -967,7 +1034,7 @@ func (m *Manager) handleAutoSquash(action string) error {
 	case "status", "show", "check":
 		return m.showAutoSquashStatus()
 	default:
-		return fmt.Errorf("error: Invalid auto action '%s'. Use 'on', 'off', or 'status'", action)
+		return errs.New(errs.Usage, "Invalid auto action '%s'. Use 'on', 'off', or 'status'", action)
 	}
 }
 
@@ -1023,14 +1090,19 @@ func (m *Manager) detectParentBranch() (string, error) {
 		return "", err
 	}
 
-	// Method 1: Check git config for upstream branch
+	// Method 1: Use the parent recorded when the branch was created
+	if parentBranch := m.getStackParent(currentBranch); parentBranch != "" && m.branchExists(parentBranch) {
+		return parentBranch, nil
+	}
+
+	// Method 2: Check git config for upstream branch
 	parentBranch, _ := m.git.GetConfig(fmt.Sprintf("branch.%s.merge", currentBranch))
 	if parentBranch != "" {
 		parentBranch = strings.TrimPrefix(parentBranch, "refs/heads/")
 		return parentBranch, nil
 	}
 
-	// Method 2: Check if current branch has an upstream tracking branch
+	// Method 3: Check if current branch has an upstream tracking branch
 	output, err := m.git.Run("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
 	if err == nil {
 		parentBranch := strings.TrimSpace(output)
@@ -1038,7 +1110,7 @@ func (m *Manager) detectParentBranch() (string, error) {
 		return parentBranch, nil
 	}
 
-	// Method 3: Find the branch that the current branch diverged from
+	// Method 4: Find the branch that the current branch diverged from
 	output, err = m.git.Run("branch", "--list")
 	if err != nil {
 		return "", err
@@ -1085,13 +1157,13 @@ func (m *Manager) detectParentBranch() (string, error) {
 		return bestParentBranch, nil
 	}
 
-	// Method 4: Fallback to configured trunk branch
+	// Method 5: Fallback to configured trunk branch
 	trunkBranch, _ := m.git.GetConfig("at.trunk")
 	if trunkBranch != "" {
 		return trunkBranch, nil
 	}
 
-	// Method 5: Try common branch names
+	// Method 6: Try common branch names
 	commonBranches := []string{"main", "master", "develop", "development"}
 	for _, branch := range commonBranches {
 		_, err := m.git.Run("rev-parse", "--verify", branch)
@@ -1114,7 +1186,7 @@ func (m *Manager) getHeadSHA(branch string) (string, error) {
 func (m *Manager) performSquash(targetSHA string) error {
 	currentBranch, err := m.git.GetCurrentBranch()
 	if err != nil {
-		return fmt.Errorf("error: Not on a branch (detached HEAD state)")
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
 	}
 
 	// Validate target SHA
@@ -1125,7 +1197,7 @@ func (m *Manager) performSquash(targetSHA string) error {
 	// Verify target SHA exists
 	_, err = m.git.Run("rev-parse", "--verify", targetSHA)
 	if err != nil {
-		return fmt.Errorf("❌ Target SHA does not exist: %s", targetSHA)
+		return errs.New(errs.NotFound, "Target SHA does not exist: %s", targetSHA)
 	}
 
 	// Get the number of commits to squash
@@ -1141,8 +1213,7 @@ func (m *Manager) performSquash(targetSHA string) error {
 	}
 
 	if count <= 1 {
-		fmt.Println("Only one commit or no commits to squash")
-		return nil
+		return errs.New(errs.NothingToDo, "Only one commit or no commits to squash")
 	}
 
 	fmt.Printf("Squashing %d commits...\n", count)
@@ -1166,7 +1237,7 @@ func (m *Manager) performSquash(targetSHA string) error {
 	if hasUncommitted || hasStaged {
 		fmt.Println("⚠️  Working directory has uncommitted changes")
 		fmt.Println("   Stashing changes before squashing...")
-		_, err = m.git.Run("stash", "push", "-m", "Auto-stash before squashing")
+		_, err = m.git.Run("stash", "push", "-m", autostashTag+currentBranch)
 		if err != nil {
 			return fmt.Errorf("❌ Failed to stash uncommitted changes")
 		}
@@ -1188,43 +1259,16 @@ func (m *Manager) performSquash(targetSHA string) error {
 		return fmt.Errorf("❌ Failed to create temporary branch for squashing")
 	}
 
-	// Cherry-pick all commits from current branch to temp branch
-	output, err = m.git.Run("rev-list", "--reverse", fmt.Sprintf("%s..%s", targetSHA, originalHead))
-	if err != nil {
-		// Clean up on failure
-		m.git.Run("checkout", currentBranch)
-		m.git.Run("branch", "-D", tempBranch)
-		if hasUncommitted || hasStaged {
-			fmt.Println("   Restoring stashed changes...")
-			m.git.Run("stash", "pop")
-		}
-		return fmt.Errorf("error: Failed to get commit list")
-	}
-
-	commitHashes := strings.Split(strings.TrimSpace(output), "\n")
-	cherryPickSuccess := true
-
-	for _, commitHash := range commitHashes {
-		commitHash = strings.TrimSpace(commitHash)
-		if commitHash != "" {
-			_, err = m.git.Run("cherry-pick", commitHash)
-			if err != nil {
-				fmt.Printf("❌ Failed to cherry-pick commit %s\n", commitHash)
-				cherryPickSuccess = false
-				break
-			}
-		}
-	}
-
-	if !cherryPickSuccess {
+	// Pick all commits from current branch into a single commit on temp branch
+	if err := m.commitSquashed(targetSHA, originalHead); err != nil {
 		// Clean up on failure
-		m.git.Run("checkout", currentBranch)
+		m.git.Run("checkout", "-f", currentBranch)
 		m.git.Run("branch", "-D", tempBranch)
 		if hasUncommitted || hasStaged {
 			fmt.Println("   Restoring stashed changes...")
 			m.git.Run("stash", "pop")
 		}
-		return fmt.Errorf("❌ Squashing failed due to conflicts")
+		return err
 	}
 
 	// Reset current branch to temp branch (this creates the squashed commit)
@@ -1268,104 +1312,227 @@ func (m *Manager) performSquash(targetSHA string) error {
 // PullRequest handles the pr command
 func (m *Manager) PullRequest(args []string) error {
 	if len(args) == 0 {
-		return m.createPR("", "", "", false, "", "")
+		return m.createPR(prOptions{})
 	}
 
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showPRUsage()
+			return m.help("pr")
 		}
 	}
 
+	// Handle subcommands
+	switch args[0] {
+	case "status":
+		return m.prStatus(args[1:])
+	case "list":
+		return m.prList()
+	}
+
 	return m.parsePRArgs(args)
 }
 
-// Helper methods for PR functionality
+// prOptions holds the options of a pull request creation
+type prOptions struct {
+	title         string
+	description   string
+	baseBranch    string
+	openBrowser   bool
+	forceSquash   bool
+	forceNoSquash bool
+	noEmoji       bool
+	draft         bool
+	ready         bool
+	reviewers     []string
+	labels        []string
+	assignees     []string
+	milestone     string
+}
+
+// defaultPRLabels holds the built-in labels per work type, used when no
+// at.pr.<type>.label is configured
+var defaultPRLabels = map[string][]string{
+	"hotfix": {"urgent"},
+}
+
+// parsePRArgs creates a PR from the command line options
 func (m *Manager) parsePRArgs(args []string) error {
-	var title, description, baseBranch string
-	var openBrowser bool
-	var forceSquash, forceNoSquash string
-
-	// Parse arguments
-	for i := 0; i < len(args); i++ {
-		arg := args[i]
-		switch arg {
-		case "-t", "--title":
-			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
-				title = args[i+1]
-				i++ // Skip next argument
-			} else {
-				return fmt.Errorf("error: --title requires a value")
-			}
-		case "-d", "--description":
-			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
-				description = args[i+1]
-				i++ // Skip next argument
-			} else {
-				return fmt.Errorf("error: --description requires a value")
-			}
-		case "-b", "--base":
-			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
-				baseBranch = args[i+1]
-				i++ // Skip next argument
-			} else {
-				return fmt.Errorf("error: --base requires a value")
-			}
-		case "-o", "--open":
-			openBrowser = true
-		case "-s", "--squash":
-			forceSquash = "true"
-		case "-S", "--no-squash":
-			forceNoSquash = "true"
-		default:
-			// If no title provided yet, use this as title
-			if title == "" {
-				title = arg
-			} else {
-				return fmt.Errorf("error: Unknown option '%s'", arg)
-			}
+	flags, err := prCommand().parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("pr")
+	}
+
+	opts := prOptions{
+		title:         flags.String("title"),
+		description:   flags.String("description"),
+		baseBranch:    flags.String("base"),
+		openBrowser:   flags.Has("open"),
+		forceSquash:   flags.Has("squash"),
+		forceNoSquash: flags.Has("no-squash"),
+		noEmoji:       flags.Has("no-emoji"),
+		draft:         flags.Has("draft"),
+		ready:         flags.Has("ready"),
+		milestone:     flags.String("milestone"),
+	}
+	for _, value := range flags.Strings("reviewer") {
+		opts.reviewers = append(opts.reviewers, splitList(value)...)
+	}
+	for _, value := range flags.Strings("label") {
+		opts.labels = append(opts.labels, splitList(value)...)
+	}
+	for _, value := range flags.Strings("assignee") {
+		opts.assignees = append(opts.assignees, splitList(value)...)
+	}
+
+	// The first positional argument is the title
+	positional := flags.Args()
+	if len(positional) > 0 && opts.title == "" {
+		opts.title, positional = positional[0], positional[1:]
+	}
+	if len(positional) > 0 {
+		return errs.New(errs.Usage, "Unexpected argument '%s' (see 'git @ pr --help')", positional[0])
+	}
+
+	if opts.draft && opts.ready {
+		return errs.New(errs.Usage, "--draft and --ready cannot be used together")
+	}
+
+	// -b without a branch picks it
+	if flags.Has("base") && opts.baseBranch == "" {
+		currentBranch, _ := m.git.GetCurrentBranch()
+		choice, ok, err := m.pickBranch("Pull request into", m.stackBaseBranch(currentBranch), nil)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errs.New(errs.Usage, "--base requires a value")
+		}
+		opts.baseBranch = choice.Local
+	}
+
+	return m.createPR(opts)
+}
+
+// applyPRDefaults merges the configured PR defaults for the branch work type
+// into opts. Flags given on the command line always win.
+func (m *Manager) applyPRDefaults(opts *prOptions, branchName string) {
+	workType := m.getWorkType(branchName)
+
+	// Draft: flag > at.pr.<type>.draft > at.pr.draft
+	if !opts.draft && !opts.ready {
+		draft := ""
+		if workType != "" {
+			draft, _ = m.git.GetConfig(fmt.Sprintf("at.pr.%s.draft", workType))
+		}
+		if draft == "" {
+			draft, _ = m.git.GetConfig("at.pr.draft")
+		}
+		opts.draft = draft == "true"
+	}
+
+	// Milestone: flag > at.pr.<type>.milestone > at.pr.milestone
+	if opts.milestone == "" && workType != "" {
+		opts.milestone, _ = m.git.GetConfig(fmt.Sprintf("at.pr.%s.milestone", workType))
+	}
+	if opts.milestone == "" {
+		opts.milestone, _ = m.git.GetConfig("at.pr.milestone")
+	}
+
+	// Lists are combined: flags + work type defaults + repository defaults
+	opts.reviewers = mergeLists(opts.reviewers, m.prConfigList("reviewer", workType, nil))
+	opts.labels = mergeLists(opts.labels, m.prConfigList("label", workType, defaultPRLabels[workType]))
+	opts.assignees = mergeLists(opts.assignees, m.prConfigList("assignee", workType, nil))
+}
+
+// prConfigList returns the values of at.pr.<type>.<key> followed by the values
+// of at.pr.<key>. fallback is used when the work type has no configured value.
+func (m *Manager) prConfigList(key, workType string, fallback []string) []string {
+	var values []string
+
+	if workType != "" {
+		typed, err := m.git.GetConfigAll(fmt.Sprintf("at.pr.%s.%s", workType, key))
+		if err != nil {
+			typed = fallback
+		}
+		for _, value := range typed {
+			values = append(values, splitList(value)...)
+		}
+	}
+
+	global, _ := m.git.GetConfigAll("at.pr." + key)
+	for _, value := range global {
+		values = append(values, splitList(value)...)
+	}
+
+	return values
+}
+
+// splitList splits a comma-separated value into trimmed, non-empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
 		}
 	}
+	return items
+}
 
-	return m.createPR(title, description, baseBranch, openBrowser, forceSquash, forceNoSquash)
+// mergeLists appends the items of extra missing from base, preserving order
+func mergeLists(base, extra []string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, item := range append(append([]string{}, base...), extra...) {
+		if !seen[item] {
+			seen[item] = true
+			merged = append(merged, item)
+		}
+	}
+	return merged
 }
 
-func (m *Manager) createPR(title, description, baseBranch string, openBrowser bool, forceSquash, forceNoSquash string) error {
+func (m *Manager) createPR(opts prOptions) error {
 	// Validate we're in a git repository
 	_, err := m.git.Run("rev-parse", "--git-dir")
 	if err != nil {
-		return fmt.Errorf("error: Not in a git repository")
+		return errs.New(errs.NotARepo, "Not in a git repository")
 	}
 
 	// Get current branch
 	currentBranch, err := m.git.GetCurrentBranch()
 	if err != nil {
-		return fmt.Errorf("error: Not on a branch (detached HEAD state)")
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
 	}
 
-	// Set default base branch if not provided
+	// Set default base branch if not provided: the open parent of a
+	// stacked branch, otherwise the trunk
+	baseBranch := opts.baseBranch
 	if baseBranch == "" {
-		baseBranch, _ = m.git.GetConfig("at.trunk")
-		if baseBranch == "" {
-			baseBranch = "main"
+		baseBranch = m.stackBaseBranch(currentBranch)
+		if baseBranch != m.trunkBranch() {
+			fmt.Printf("Stacked branch: targeting parent %s\n", baseBranch)
 		}
 	}
 
 	// Check if we're trying to create PR from trunk branch
 	if currentBranch == baseBranch {
-		return fmt.Errorf("error: Cannot create PR from %s to itself", baseBranch)
+		return errs.New(errs.PolicyViolation, "Cannot create PR from %s to itself", baseBranch)
 	}
 
 	// Check if there are commits between the branches
-	output, err := m.git.Run("rev-list", "--count", fmt.Sprintf("%s..%s", baseBranch, currentBranch))
+	count, err := m.git.Run("rev-list", "--count", fmt.Sprintf("%s..%s", baseBranch, currentBranch))
 	if err != nil {
 		return fmt.Errorf("error: Cannot find merge base with %s", baseBranch)
 	}
 
-	commitCount := strings.TrimSpace(output)
+	commitCount := strings.TrimSpace(count)
 	if commitCount == "0" {
-		return fmt.Errorf("error: No commits between %s and %s\nCannot create a PR without any commits to merge.", currentBranch, baseBranch)
+		return errs.New(errs.NothingToDo, "No commits between %s and %s\nCannot create a PR without any commits to merge.", currentBranch, baseBranch)
 	}
 
 	// Check for uncommitted changes
@@ -1385,11 +1552,27 @@ func (m *Manager) createPR(title, description, baseBranch string, openBrowser bo
 		}
 	}
 
+	// Re-running pr on a branch with an open PR updates it
+	platform := m.detectPlatform()
+	backend := provider.New(platform, m.runCLI)
+
+	var existing *provider.PullRequest
+	if backend != nil {
+		// Without the CLI the PR is created in the web interface instead
+		existing, err = backend.Find(currentBranch)
+		if err != nil && !errors.Is(err, provider.ErrCLIMissing) {
+			return errs.Wrap(errs.KindOf(err), err, "Failed to look up the open PR of %s", currentBranch)
+		}
+		if existing != nil && !m.confirmPRUpdate(existing) {
+			return nil
+		}
+	}
+
 	// Determine if we should squash commits
 	shouldSquash := false
-	if forceSquash == "true" {
+	if opts.forceSquash {
 		shouldSquash = true
-	} else if forceNoSquash == "true" {
+	} else if opts.forceNoSquash {
 		shouldSquash = false
 	} else {
 		// Check configuration setting
@@ -1403,28 +1586,49 @@ func (m *Manager) createPR(title, description, baseBranch string, openBrowser bo
 	if shouldSquash {
 		fmt.Println("Auto-squashing commits before creating PR...")
 		err = m.squashForPR()
-		if err != nil {
+		if errs.Is(err, errs.NothingToDo) {
+			fmt.Println(err)
+		} else if err != nil {
 			return fmt.Errorf("error: Failed to squash commits: %w", err)
+		} else {
+			fmt.Println("✅ Commits squashed successfully")
 		}
-		fmt.Println("✅ Commits squashed successfully")
 	}
 
 	// Set default title if not provided
+	title := opts.title
 	if title == "" {
 		title, err = m.getDefaultPRTitle()
 		if err != nil {
+			var templateErr *templateError
+			if errors.As(err, &templateErr) {
+				output.Warning("%v, using the default", templateErr)
+			}
 			title = fmt.Sprintf("Update from %s", currentBranch)
 		}
 	}
 
 	// Generate automatic description if not provided
+	description := opts.description
 	if description == "" {
 		fmt.Println("Generating automatic description based on changed files...")
-		description = m.generateAutoDescription(baseBranch, currentBranch)
+		generated := m.generateAutoDescription(baseBranch, currentBranch, m.useDescriptionEmoji(opts))
+		if existing != nil {
+			description = mergeAutoDescription(existing.Description, generated)
+		} else {
+			description = wrapAutoDescription(generated)
+		}
 	}
 
-	// Get platform and repo info
-	platform := m.detectPlatform()
+	// Updates only change what was asked for, such as the draft state
+	if existing != nil {
+		return m.updatePR(backend, existing, opts, title, description)
+	}
+
+	// Apply configured defaults for this work type
+	m.applyPRDefaults(&opts, currentBranch)
+
+	// Get repo info
 	repoInfo, err := m.getRepoInfo()
 	if err != nil {
 		return err
@@ -1433,33 +1637,88 @@ func (m *Manager) createPR(title, description, baseBranch string, openBrowser bo
 	fmt.Printf("Creating PR for %s repository: %s\n", platform, repoInfo)
 	fmt.Printf("From: %s → To: %s\n", currentBranch, baseBranch)
 	fmt.Printf("Title: %s\n", title)
-	if description != "" {
+	if opts.description == "" {
 		fmt.Println("Description: Auto-generated based on changed files")
 	}
+	if opts.draft {
+		fmt.Println("Draft: yes")
+	}
+	if len(opts.reviewers) > 0 {
+		fmt.Printf("Reviewers: %s\n", strings.Join(opts.reviewers, ", "))
+	}
+	if len(opts.labels) > 0 {
+		fmt.Printf("Labels: %s\n", strings.Join(opts.labels, ", "))
+	}
+	if len(opts.assignees) > 0 {
+		fmt.Printf("Assignees: %s\n", strings.Join(opts.assignees, ", "))
+	}
+	if opts.milestone != "" {
+		fmt.Printf("Milestone: %s\n", opts.milestone)
+	}
+
+	createOpts := provider.CreateOptions{
+		Title:       title,
+		Description: description,
+		Base:        baseBranch,
+		Head:        currentBranch,
+		Draft:       opts.draft,
+		Reviewers:   opts.reviewers,
+		Labels:      opts.labels,
+		Assignees:   opts.assignees,
+		Milestone:   opts.milestone,
+	}
 
-	// Try to create PR using CLI tools
+	// Try to create PR using the platform CLI tools
 	success := false
 
-	switch platform {
-	case "github":
-		if m.createGitHubPR(title, description, baseBranch, currentBranch) {
-			success = true
-		}
-	case "gitlab":
-		if m.createGitLabMR(title, description, baseBranch, currentBranch) {
+	if backend != nil {
+		prURL, err := backend.Create(createOpts)
+		if err != nil {
+			fmt.Println(err)
+		} else {
 			success = true
+			fmt.Println()
+			fmt.Printf("✅ PR created: %s\n", prURL)
+
+			if opts.openBrowser && prURL != "" {
+				fmt.Println("Opening in browser...")
+				m.openURL(prURL)
+			}
 		}
 	}
 
 	// If CLI failed or not supported, provide web URL
 	if !success {
-		webURL := m.generateWebURL(platform, repoInfo, currentBranch, baseBranch)
+		webURL := m.generateWebURL(platform, repoInfo, currentBranch, baseBranch, createOpts)
 
 		fmt.Println()
 		fmt.Println("PR creation via CLI not available. Please create the PR manually:")
 		fmt.Printf("URL: %s\n", webURL)
 
-		if openBrowser {
+		// Only GitHub accepts labels, assignees and milestone in the URL
+		var manual []string
+		if opts.draft {
+			manual = append(manual, "draft state")
+		}
+		if len(opts.reviewers) > 0 {
+			manual = append(manual, "reviewers")
+		}
+		if platform != "github" {
+			if len(opts.labels) > 0 {
+				manual = append(manual, "labels")
+			}
+			if len(opts.assignees) > 0 {
+				manual = append(manual, "assignees")
+			}
+			if opts.milestone != "" {
+				manual = append(manual, "milestone")
+			}
+		}
+		if len(manual) > 0 {
+			fmt.Printf("Note: set the %s in the web interface\n", strings.Join(manual, ", "))
+		}
+
+		if opts.openBrowser {
 			fmt.Println("Opening in browser...")
 			m.openURL(webURL)
 		}
@@ -1468,6 +1727,34 @@ func (m *Manager) createPR(title, description, baseBranch string, openBrowser bo
 	return nil
 }
 
+// runCLI runs an external command (gh, glab, ...) from the repository root
+func (m *Manager) runCLI(name string, args ...string) (string, error) {
+	if m.dryRun() && cliMutates(args) {
+		m.plan.Add("%s %s", name, git.FormatArgs(args))
+		return "", nil
+	}
+	if m.cli != nil {
+		return m.cli(name, args...)
+	}
+	ctx := m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return provider.ExecRunnerContext(ctx, m.config.RepoPath)(name, args...)
+}
+
+// getWorkType returns the work type of a branch, or "" when the branch
+// does not follow the <type>-<description> naming
+func (m *Manager) getWorkType(branchName string) string {
+	workType := strings.Split(branchName, "-")[0]
+	for _, t := range workTypes {
+		if workType == t {
+			return workType
+		}
+	}
+	return ""
+}
+
 func (m *Manager) detectPlatform() string {
 	// Get the primary remote URL
 	remoteURL, _ := m.git.GetConfig("remote.origin.url")
@@ -1532,231 +1819,33 @@ func (m *Manager) getDefaultPRTitle() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return strings.TrimSpace(output), nil
-}
-
-func (m *Manager) generateAutoDescription(baseBranch, currentBranch string) string {
-	// Get list of changed files
-	output, err := m.git.Run("diff", "--name-only", fmt.Sprintf("%s..%s", baseBranch, currentBranch))
-	if err != nil {
-		return "No files changed compared to " + baseBranch
-	}
-
-	changedFiles := strings.Split(strings.TrimSpace(output), "\n")
-	if len(changedFiles) == 0 || (len(changedFiles) == 1 && changedFiles[0] == "") {
-		return "No files changed compared to " + baseBranch
-	}
-
-	// Count files by type
-	totalFiles := 0
-	addedFiles := 0
-	modifiedFiles := 0
-	deletedFiles := 0
-
-	// Get file status information
-	statusOutput, _ := m.git.Run("diff", "--name-status", fmt.Sprintf("%s..%s", baseBranch, currentBranch))
-	statusLines := strings.Split(strings.TrimSpace(statusOutput), "\n")
-
-	// Create a map of file statuses
-	fileStatuses := make(map[string]string)
-	for _, line := range statusLines {
-		parts := strings.Fields(line)
-		if len(parts) >= 2 {
-			fileStatuses[parts[1]] = parts[0]
-		}
-	}
-
-	// Analyze each changed file
-	for _, file := range changedFiles {
-		file = strings.TrimSpace(file)
-		if file != "" {
-			totalFiles++
-
-			// Check if file was added, modified, or deleted
-			if status, exists := fileStatuses[file]; exists {
-				if status == "A" {
-					addedFiles++
-				} else if status == "D" {
-					deletedFiles++
-				} else {
-					modifiedFiles++
-				}
-			} else {
-				modifiedFiles++
-			}
-		}
-	}
-
-	// Generate summary
-	description := "# 📋 Pull Request Summary\n\n"
-	description += fmt.Sprintf("This PR contains changes from branch `%s` targeting `%s`.\n\n", currentBranch, baseBranch)
-
-	description += "## 📊 Changes Overview\n\n"
-	description += "| Metric | Count |\n"
-	description += "|--------|-------|\n"
-	description += fmt.Sprintf("| **Total Files** | %d |\n", totalFiles)
-
-	if addedFiles > 0 {
-		description += fmt.Sprintf("| **Added** | %d |\n", addedFiles)
-	}
-	if modifiedFiles > 0 {
-		description += fmt.Sprintf("| **Modified** | %d |\n", modifiedFiles)
-	}
-	if deletedFiles > 0 {
-		description += fmt.Sprintf("| **Deleted** | %d |\n", deletedFiles)
-	}
-
-	description += "\n## 📁 File Analysis\n\n"
-
-	// Group files by directory/type
-	fileTypes := make(map[string]bool)
-	dirs := make(map[string]bool)
 
-	// Process changed files to build file types and directories
-	for _, file := range changedFiles {
-		file = strings.TrimSpace(file)
-		if file != "" {
-			// Get file extension
-			parts := strings.Split(file, ".")
-			ext := "no-extension"
-			if len(parts) > 1 {
-				ext = parts[len(parts)-1]
-			}
-			fileTypes[ext] = true
+	// at.pr.title can decorate the last commit subject, e.g. with the issue
+	subject := strings.TrimSpace(output)
+	return m.renderTemplate("at.pr.title", defaultPRTitleTemplate, label.Values{"subject": subject})
+}
 
-			// Get directory
-			dir := "root"
-			if strings.Contains(file, "/") {
-				dir = file[:strings.LastIndex(file, "/")]
-			}
-			dirs[dir] = true
-		}
-	}
+func (m *Manager) generateWebURL(platform, repoInfo, currentBranch, baseBranch string, opts provider.CreateOptions) string {
+	switch platform {
+	case "github":
+		webURL := fmt.Sprintf("https://github.com/%s/compare/%s...%s", repoInfo, baseBranch, currentBranch)
 
-	// Add file type summary
-	if len(fileTypes) > 0 {
-		description += "### 🔤 File Types\n\n"
-		description += "This PR affects the following file types:\n\n"
-		for ext := range fileTypes {
-			description += fmt.Sprintf("- `%s`\n", ext)
+		// GitHub pre-fills the PR form from query parameters
+		query := url.Values{}
+		query.Set("expand", "1")
+		if opts.Title != "" {
+			query.Set("title", opts.Title)
 		}
-		description += "\n"
-	}
-
-	// Add directory summary if multiple directories
-	if len(dirs) > 1 {
-		description += "### 📂 Directories Affected\n\n"
-		description += "Changes span across the following directories:\n\n"
-		for dir := range dirs {
-			description += fmt.Sprintf("- `%s`\n", dir)
+		if len(opts.Labels) > 0 {
+			query.Set("labels", strings.Join(opts.Labels, ","))
 		}
-		description += "\n"
-	}
-
-	// List all changed files
-	description += "## 📝 Changed Files\n\n"
-	description += "<details>\n<summary>📋 Click to view all changed files</summary>\n\n"
-	description += "```\n"
-
-	for _, file := range changedFiles {
-		file = strings.TrimSpace(file)
-		if file != "" {
-			// Get file status
-			status := "✏️" // Default to modified
-			if statusCode, exists := fileStatuses[file]; exists {
-				if statusCode == "A" {
-					status = "➕"
-				} else if statusCode == "D" {
-					status = "🗑️"
-				}
-			}
-
-			description += fmt.Sprintf("%s %s\n", status, file)
+		if len(opts.Assignees) > 0 {
+			query.Set("assignees", strings.Join(opts.Assignees, ","))
 		}
-	}
-
-	description += "```\n</details>\n\n"
-
-	// Add commit summary
-	commitCount, _ := m.git.Run("rev-list", "--count", fmt.Sprintf("%s..%s", baseBranch, currentBranch))
-	if commitCount != "" && commitCount != "0" && commitCount != "1" {
-		description += "## 🔄 Commits\n\n"
-		description += fmt.Sprintf("This PR includes **%s commits**.\n\n", commitCount)
-		description += "<details>\n<summary>📜 Click to view commit history</summary>\n\n"
-		description += "```\n"
-
-		// Get commit history
-		commitHistory, _ := m.git.Run("log", "--oneline", "-5", fmt.Sprintf("%s..%s", baseBranch, currentBranch))
-		if commitHistory != "" {
-			description += strings.TrimSpace(commitHistory) + "\n"
+		if opts.Milestone != "" {
+			query.Set("milestone", opts.Milestone)
 		}
-
-		description += "```\n</details>\n\n"
-	}
-
-	// Add footer
-	description += "---\n\n"
-	description += "*This description was automatically generated based on the changes in this PR.*\n"
-
-	return description
-}
-
-func (m *Manager) createGitHubPR(title, description, baseBranch, currentBranch string) bool {
-	// Check if gh CLI is available
-	_, err := m.git.Run("gh", "--version")
-	if err != nil {
-		fmt.Println("GitHub CLI (gh) not installed. Please install it or use the web interface.")
-		fmt.Println("Install: https://cli.github.com/")
-		return false
-	}
-
-	// Check if user is authenticated
-	_, err = m.git.Run("gh", "auth", "status")
-	if err != nil {
-		fmt.Println("GitHub CLI not authenticated. Please run 'gh auth login' first.")
-		return false
-	}
-
-	// Create PR
-	if description != "" {
-		_, err = m.git.Run("gh", "pr", "create", "--title", title, "--body", description, "--base", baseBranch, "--head", currentBranch)
-	} else {
-		_, err = m.git.Run("gh", "pr", "create", "--title", title, "--base", baseBranch, "--head", currentBranch)
-	}
-
-	return err == nil
-}
-
-func (m *Manager) createGitLabMR(title, description, baseBranch, currentBranch string) bool {
-	// Check if glab CLI is available
-	_, err := m.git.Run("glab", "--version")
-	if err != nil {
-		fmt.Println("GitLab CLI (glab) not installed. Please install it or use the web interface.")
-		fmt.Println("Install: https://gitlab.com/gitlab-org/cli")
-		return false
-	}
-
-	// Check if user is authenticated
-	_, err = m.git.Run("glab", "auth", "status")
-	if err != nil {
-		fmt.Println("GitLab CLI not authenticated. Please run 'glab auth login' first.")
-		return false
-	}
-
-	// Create MR
-	if description != "" {
-		_, err = m.git.Run("glab", "mr", "create", "--title", title, "--description", description, "--source-branch", currentBranch, "--target-branch", baseBranch)
-	} else {
-		_, err = m.git.Run("glab", "mr", "create", "--title", title, "--source-branch", currentBranch, "--target-branch", baseBranch)
-	}
-
-	return err == nil
-}
-
-func (m *Manager) generateWebURL(platform, repoInfo, currentBranch, baseBranch string) string {
-	switch platform {
-	case "github":
-		return fmt.Sprintf("https://github.com/%s/compare/%s...%s", repoInfo, baseBranch, currentBranch)
+		return webURL + "?" + query.Encode()
 	case "gitlab":
 		return fmt.Sprintf("https://gitlab.com/%s/-/merge_requests/new?source_branch=%s&target_branch=%s", repoInfo, currentBranch, baseBranch)
 	case "bitbucket":
@@ -1768,167 +1857,188 @@ func (m *Manager) generateWebURL(platform, repoInfo, currentBranch, baseBranch s
 
 func (m *Manager) openURL(url string) {
 	// Try to open URL in browser
-	_, err := m.git.Run("open", url)
+	_, err := m.runCLI("open", url)
 	if err != nil {
-		_, err = m.git.Run("xdg-open", url)
+		_, err = m.runCLI("xdg-open", url)
 		if err != nil {
 			fmt.Printf("Please open this URL in your browser: %s\n", url)
 		}
 	}
 }
 
-func (m *Manager) showWorkUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ work <type> [<description>] [options]
-
-DESCRIPTION:
-  Create work branches following Conventional Commits specification.
-  Supports all standard commit types for organized development workflow.
+func workCommand() *Command {
+	return &Command{
+		Name:    "work",
+		Hint:    "<type> <description>",
+		Summary: "Create work branches following Conventional Commits",
+		Usage:   []string{"<type> [<description>] [options]", "[<type>] --issue <key> [options]"},
+		Args:    []Arg{{Name: "<type>", Source: SourceWorkTypes}},
+		Description: `Create work branches following Conventional Commits specification.
+Supports all standard commit types for organized development workflow.
 
 WORK TYPES (Conventional Commits):
-  hotfix    : Urgent fixes for production (PATCH version)
-  feature   : New features (MINOR version)
-  bugfix    : Bug fixes (PATCH version)
-  release   : Release preparation (PATCH version)
-  chore     : Maintenance tasks
-  docs      : Documentation changes
-  style     : Code style changes
-  refactor  : Code refactoring
-  perf      : Performance improvements
-  test      : Test additions/changes
-  ci        : CI/CD changes
-  build     : Build system changes
-  revert    : Revert commits
-
-OPTIONS:
-  -n, --name <name>     Specify full branch name
-  -h, --help           Show this help message
-
-EXAMPLES:
-  git @ work hotfix "fix-login-bug"           # Creates hotfix-fix-login-bug
-  git @ work feature "add-user-auth"          # Creates feature-add-user-auth
-  git @ work bugfix "fix-crash-on-startup"    # Creates bugfix-fix-crash-on-startup
-  git @ work docs "update-api-documentation"  # Creates docs-update-api-documentation
-  git @ work chore "update-dependencies"      # Creates chore-update-dependencies
-  git @ work feature "Incorrect Branch Name"  # Creates feature-incorrect-branch-name
-  git @ work hotfix "Fix Login Bug!"          # Creates hotfix-fix-login-bug
-
-BRANCH NAMING:
-  Format: <type>-<description>
-  Automatic formatting: Descriptions are automatically converted to kebab-case
-  Examples:
-    hotfix-fix-login-bug
-    feature-add-user-auth
-    bugfix-fix-crash-on-startup
-    docs-update-api-documentation
-    chore-update-dependencies
-    feature-incorrect-branch-name (from "Incorrect Branch Name")
-    hotfix-fix-login-bug (from "Fix Login Bug!")
-
-CONVENTIONAL COMMITS INTEGRATION:
-  - Branch types follow Conventional Commits specification
-  - Commit messages will include [TYPE] prefix
-  - Supports semantic versioning correlation
-  - Integrates with git @ branch --<type> listing
-
-WORKFLOW:
-  1. Creates branch from current branch or trunk
-  2. Switches to new work branch
-  3. Sets working branch to new branch
-  4. Provides next steps guidance
-`)
-	return nil
+hotfix    : Urgent fixes for production (PATCH version)
+feature   : New features (MINOR version)
+bugfix    : Bug fixes (PATCH version)
+release   : Release preparation (PATCH version)
+chore     : Maintenance tasks
+docs      : Documentation changes
+style     : Code style changes
+refactor  : Code refactoring
+perf      : Performance improvements
+test      : Test additions/changes
+ci        : CI/CD changes
+build     : Build system changes
+revert    : Revert commits`,
+		Flags: []Flag{
+			{Short: "n", Long: "name", Value: "name", Help: "Specify full branch name"},
+			{Short: "i", Long: "issue", Value: "key", Help: "Build the branch from a tracker issue (see below); with --name, only record it"},
+			{Short: "w", Long: "worktree", Help: "Create the branch in a new worktree (see git @ worktree)"},
+			{Long: "no-worktree", Help: "Check out in place even if at.worktree is enabled"},
+		},
+		Examples: []Example{
+			{"git @ work hotfix \"fix-login-bug\"", "Creates hotfix-fix-login-bug"},
+			{"git @ work feature \"add-user-auth\"", "Creates feature-add-user-auth"},
+			{"git @ work bugfix \"fix-crash-on-startup\"", "Creates bugfix-fix-crash-on-startup"},
+			{"git @ work docs \"update-api-documentation\"", "Creates docs-update-api-documentation"},
+			{"git @ work chore \"update-dependencies\"", "Creates chore-update-dependencies"},
+			{"git @ work feature \"Incorrect Branch Name\"", "Creates feature-incorrect-branch-name"},
+			{"git @ work hotfix \"Fix Login Bug!\"", "Creates hotfix-fix-login-bug"},
+		},
+		Sections: []Section{
+			{"BRANCH NAMING", `Format: <type>-<description>
+Automatic formatting: Descriptions are automatically converted to kebab-case
+Examples:
+  hotfix-fix-login-bug
+  feature-add-user-auth
+  bugfix-fix-crash-on-startup
+  docs-update-api-documentation
+  chore-update-dependencies
+  feature-incorrect-branch-name (from "Incorrect Branch Name")
+  hotfix-fix-login-bug (from "Fix Login Bug!")`},
+			{"CONVENTIONAL COMMITS INTEGRATION", `- Branch types follow Conventional Commits specification
+- Commit messages will include [TYPE] prefix
+- Supports semantic versioning correlation
+- Integrates with git @ branch --<type> listing`},
+			{"WORKFLOW", `1. Creates branch from current branch or trunk
+2. Switches to new work branch
+3. Sets working branch to new branch
+4. Records the base branch as parent (see git @ stack)
+5. Provides next steps guidance`},
+			{"STACKED BRANCHES", `Running work from an unmerged work branch stacks the new branch on it.
+'git @ pr' then targets the parent and 'git @ restack' keeps the stack
+rebased when the parent changes or merges.`},
+			{"ISSUE TRACKER", `git @ work --issue PROJ-123           # feature-PROJ-123-add-user-auth
+git @ work bugfix --issue PROJ-124    # Override the derived work type
+git @ work --issue 42                 # GitHub/GitLab issue #42
+
+The issue title, type and status are fetched from the tracker. The work
+type is derived from the issue type and labels (bug -> bugfix, incident
+-> hotfix, task -> chore, ...), the title becomes the branch slug and
+the issue key is recorded in branch.<name>.at-issue.
+
+git config at.tracker jira             # jira, github, gitlab or linear
+git config at.tracker.url <url>        # API base URL (required for Jira)
+git config at.tracker.user <email>     # Jira Cloud account (basic auth)
+git config at.tracker.project <path>   # owner/repo for GitHub/GitLab
+Token: GITAT_TRACKER_TOKEN, or JIRA_API_TOKEN, GITHUB_TOKEN, GITLAB_TOKEN,
+LINEAR_API_KEY`},
+		},
+		Run: (*Manager).Work,
+	}
 }
 
-func (m *Manager) showHotfixUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ hotfix [options] [<name>]
-
-DESCRIPTION:
-  Create a hotfix branch for urgent fixes that need to be deployed immediately.
-  Creates a new branch from the trunk branch (master/main) and switches to it.
-
-FEATURES:
-  ✅ Creates hotfix branch from trunk (master/main)
-  ✅ Saves current WIP state before switching
-  ✅ Integrates with existing GitAT workflow
-  ✅ Interactive name prompt if not provided
-  ✅ Validates branch name and repository state
-
-WORKFLOW:
-  1. Saves current WIP state (if any)
-  2. Switches to trunk branch
-  3. Creates new hotfix branch from trunk
-  4. Switches to hotfix branch
-  5. Sets working branch to hotfix branch
-
-OPTIONS:
-  -n, --name <name>     Specify hotfix branch name
-  -h, --help           Show this help message
-
-EXAMPLES:
-  git @ hotfix                    # Interactive name prompt
-  git @ hotfix "fix-login-bug"    # Create hotfix with specific name
-  git @ hotfix -n "security-patch" # Create hotfix with name option
-  git @ hotfix --name "urgent-fix" # Create hotfix with name option
-
-BRANCH NAMING:
-  Format: hotfix-description (single hotfix- prefix)
-  Examples:
-    hotfix-fix-login-bug
-    hotfix-security-patch
-    hotfix-urgent-database-fix
-
-INTEGRATION:
-  - Uses git @ wip to save/restore work state
-  - Uses git @ branch to set working branch
-  - Uses git @ _trunk to get trunk branch name
-  - Follows GitAT workflow patterns
-`)
-	return nil
+func hotfixCommand() *Command {
+	return &Command{
+		Name:    "hotfix",
+		Hint:    "<description>",
+		Summary: "Create hotfix branches for urgent fixes",
+		Usage:   []string{"[options] [<name>]"},
+		Description: `Create a hotfix branch for urgent fixes that need to be deployed immediately.
+Creates a new branch from the trunk branch (master/main) and switches to it.`,
+		Flags: []Flag{
+			{Short: "n", Long: "name", Value: "name", Help: "Specify hotfix branch name"},
+			{Short: "w", Long: "worktree", Help: "Create the hotfix in a new worktree, leaving the current checkout and its changes untouched"},
+			{Long: "no-worktree", Help: "Check out in place even if at.worktree is enabled"},
+		},
+		Examples: []Example{
+			{"git @ hotfix", "Interactive name prompt"},
+			{"git @ hotfix \"fix-login-bug\"", "Create hotfix with specific name"},
+			{"git @ hotfix -n \"security-patch\" # Create hotfix with name option", ""},
+			{"git @ hotfix --name \"urgent-fix\" # Create hotfix with name option", ""},
+			{"git @ hotfix -w \"fix-login-bug\"", "Create hotfix in a separate worktree"},
+		},
+		Sections: []Section{
+			{"FEATURES", `✅ Creates hotfix branch from trunk (master/main)
+✅ Saves current WIP state before switching
+✅ Integrates with existing GitAT workflow
+✅ Interactive name prompt if not provided
+✅ Validates branch name and repository state`},
+			{"WORKFLOW", `1. Saves current WIP state (if any)
+2. Switches to trunk branch
+3. Creates new hotfix branch from trunk
+4. Switches to hotfix branch
+5. Sets working branch to hotfix branch`},
+			{"BRANCH NAMING", `Format: hotfix-description (single hotfix- prefix)
+Examples:
+  hotfix-fix-login-bug
+  hotfix-security-patch
+  hotfix-urgent-database-fix`},
+			{"INTEGRATION", `- Uses git @ wip to save/restore work state
+- Uses git @ branch to set working branch
+- Uses git @ _trunk to get trunk branch name
+- Follows GitAT workflow patterns`},
+		},
+		Run: (*Manager).Hotfix,
+	}
 }
 
-func (m *Manager) showSaveUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ save [<message>]
-
-DESCRIPTION:
-  Securely save current changes with comprehensive validation and security checks.
-  This is the primary command for committing changes in GitAT workflow.
-
-FEATURES:
-  • Auto-branch setup: Sets working branch if not configured
-  • Security validation: Validates inputs and paths
-  • Branch protection: Prevents saves on master/develop
-  • Production warnings: Confirms before saving to prod
-  • Safe execution: Uses secure command execution
-
-EXAMPLES:
-  git @ save                           # Save with default message
-  git @ save "Add user authentication" # Save with custom message
-  git @ save "Fix login bug"           # Save with descriptive message
-
-VALIDATION:
-  Messages must contain only:
-  • Alphanumeric characters (a-z, A-Z, 0-9)
-  • Dots (.), underscores (_), hyphens (-)
-  • Spaces and common punctuation
-
-SECURITY:
-  • All inputs are validated against dangerous patterns
-  • Path operations are restricted to repository root
-  • Commands are executed safely
-  • Security events are logged
-
-BRANCH PROTECTION:
-  • Cannot save on master or develop branches
-  • Production branch requires confirmation
-  • Must be on configured working branch
-`)
-	return nil
+func saveCommand() *Command {
+	return &Command{
+		Name:    "save",
+		Hint:    "\"message\"",
+		Summary: "Securely save changes with validation",
+		Usage:   []string{"[<message>]"},
+		Description: `Securely save current changes with comprehensive validation and security checks.
+This is the primary command for committing changes in GitAT workflow.`,
+		Flags: []Flag{
+			{Long: "closes", Help: "Add a Closes: <issue> trailer instead of Refs:"},
+			{Long: "no-issue", Help: "Do not add an issue trailer"},
+		},
+		Examples: []Example{
+			{"git @ save", "Save with default message"},
+			{"git @ save \"Add user authentication\" # Save with custom message", ""},
+			{"git @ save \"Fix login bug\"", "Save with descriptive message"},
+			{"git @ save --closes \"Fix login bug\"", "Commit that closes the branch issue"},
+		},
+		Sections: []Section{
+			{"FEATURES", `• Auto-branch setup: Sets working branch if not configured
+• Security validation: Validates inputs and paths
+• Branch protection: Prevents saves on master/develop
+• Production warnings: Confirms before saving to prod
+• Safe execution: Uses secure command execution`},
+			{"ISSUE TRAILERS", `On branches with an issue (see git @ issue), commits get a trailer such
+as 'Refs: PROJ-123'. Configure it with at.issue.trailer (refs|closes|none).`},
+			{"VALIDATION", `Messages must contain only:
+• Alphanumeric characters (a-z, A-Z, 0-9)
+• Dots (.), underscores (_), hyphens (-)
+• Spaces and common punctuation`},
+			{"SECURITY", `• All inputs are validated against dangerous patterns
+• Path operations are restricted to repository root
+• Commands are executed safely
+• Security events are logged`},
+			{"BRANCH PROTECTION", `• Cannot save on master or develop branches
+• Production branch requires confirmation
+• Must be on configured working branch`},
+		},
+		Run: (*Manager).Save,
+	}
 }
 
 func (m *Manager) setWIP() error {
 	currentBranch, err := m.git.GetCurrentBranch()
 	if err != nil {
-		return fmt.Errorf("error: Not on a branch (detached HEAD state)")
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
 	}
 
 	oldWIP, _ := m.git.GetConfig("at.wip")
@@ -1952,236 +2062,206 @@ func (m *Manager) setBranch(branchName string) error {
 }
 
 func (m *Manager) showLabel() (string, error) {
-	product, _ := m.git.GetConfig("at.product")
-	feature, _ := m.git.GetConfig("at.feature")
-	issue, _ := m.git.GetConfig("at.task")
-
-	if product == "" || feature == "" || issue == "" {
-		return "", nil
-	}
-
-	label := fmt.Sprintf("[%s.%s.%s]", product, feature, issue)
-	return label, nil
+	// at.label holds the template, by default [product.feature.issue] with
+	// unset parts left out
+	return m.renderTemplateOrDefault("at.label", defaultLabelTemplate, nil), nil
 }
 
-func (m *Manager) showSquashUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ squash [options] [<target-branch>]
-
-DESCRIPTION:
-  Squash multiple commits into a single, consolidated commit by combining
-  all commits ahead of the target branch into one clean commit. Automatically
-  detects the parent branch based on where the current branch was created from.
-
-OPTIONS:
-  -s, --save           Run 'git @ save' after squashing
-  -p, --pr             Squash for PR (uses configured trunk branch)
-  -a, --auto           Enable/disable automatic PR squashing
-  -h, --help           Show this help
-
-EXAMPLES:
-  git @ squash                      # Squash to parent branch (auto-detected)
-  git @ squash -s                   # Squash to parent and save
-  git @ squash -sp                  # Combined flags: squash and save, then PR
-  git @ squash develop              # Squash to specific branch
-  git @ squash master -s            # Squash to master and save
-  git @ squash --pr                 # Squash for PR using trunk branch
-  git @ squash --auto on            # Enable automatic squashing
-  git @ squash --auto off           # Disable automatic squashing
-  git @ squash --auto status        # Show automatic squashing status
-
-PR SQUASHING:
-  When using --pr, the command will:
-  1. Use the configured trunk branch (at.trunk) as target
-  2. Squash commits ahead of the trunk branch
-  3. Preserve commit messages in the final squashed commit
-
-AUTOMATIC PR SQUASHING:
-  Configure automatic squashing for git @ pr:
-  git @ squash --auto on            # Enable automatic squashing
-  git @ squash --auto off           # Disable automatic squashing
-  git @ squash --auto status        # Show current setting
-
-PROCESS:
-  1. Auto-detects parent branch (or uses specified target)
-  2. Validates target branch exists
-  3. Retrieves HEAD SHA of target branch
-  4. Creates temporary branch from target
-  5. Cherry-picks all commits from current branch
-  6. Resets current branch to squashed state
-  7. Optionally runs 'git @ save'
-
-USE CASES:
-  - Clean up commit history before PR
-  - Remove intermediate commits from feature branch
-  - Create single clean commit from multiple commits
-  - Automatic squashing before creating PRs
-  - Consolidate related changes into meaningful commits
-  - Simplify rollback operations
-
-WARNING:
-  You may need to force push after squashing if branch is shared.
-  Use with caution on shared branches.
-
-GIT COMMANDS USED:
-  - git rev-parse --verify --quiet --long ${BRANCH}
-  - git cherry-pick ${COMMIT}
-  - git reset --hard ${BRANCH}
-  - git checkout -b ${TEMP_BRANCH}
-
-SECURITY:
-  All squash operations are validated and logged.
-`)
-	return nil
+func squashCommand() *Command {
+	return &Command{
+		Name:    "squash",
+		Hint:    "[branch]",
+		Summary: "Squash commits with auto-detection of parent branch",
+		Usage:   []string{"[options] [<target-branch>]"},
+		Args:    []Arg{{Name: "<target-branch>", Source: SourceBranches}},
+		Description: `Squash multiple commits into a single, consolidated commit by combining
+all commits ahead of the target branch into one clean commit. Automatically
+detects the parent branch based on where the current branch was created from.
+In a terminal, the target branch is picked from a list starting on that
+parent.`,
+		Flags: []Flag{
+			{Short: "s", Long: "save", Help: "Run 'git @ save' after squashing"},
+			{Short: "p", Long: "pr", Help: "Squash for PR (uses configured trunk branch)"},
+			{Short: "a", Long: "auto", Value: "on|off|status", Help: "Enable/disable automatic PR squashing", Values: []string{"on", "off", "status"}},
+		},
+		Examples: []Example{
+			{"git @ squash", "Squash to parent branch (auto-detected)"},
+			{"git @ squash -s", "Squash to parent and save"},
+			{"git @ squash -sp", "Combined flags: squash and save, then PR"},
+			{"git @ squash develop", "Squash to specific branch"},
+			{"git @ squash master -s", "Squash to master and save"},
+			{"git @ squash --pr", "Squash for PR using trunk branch"},
+			{"git @ squash --auto on", "Enable automatic squashing"},
+			{"git @ squash --auto off", "Disable automatic squashing"},
+			{"git @ squash --auto status", "Show automatic squashing status"},
+		},
+		Sections: []Section{
+			{"PR SQUASHING", `When using --pr, the command will:
+1. Use the configured trunk branch (at.trunk) as target
+2. Squash commits ahead of the trunk branch
+3. Preserve commit messages in the final squashed commit`},
+			{"AUTOMATIC PR SQUASHING", `Configure automatic squashing for git @ pr:
+git @ squash --auto on            # Enable automatic squashing
+git @ squash --auto off           # Disable automatic squashing
+git @ squash --auto status        # Show current setting`},
+			{"PROCESS", `1. Auto-detects parent branch (or uses specified target)
+2. Validates target branch exists
+3. Retrieves HEAD SHA of target branch
+4. Creates temporary branch from target
+5. Cherry-picks all commits from current branch
+6. Resets current branch to squashed state
+7. Optionally runs 'git @ save'`},
+			{"USE CASES", `- Clean up commit history before PR
+- Remove intermediate commits from feature branch
+- Create single clean commit from multiple commits
+- Automatic squashing before creating PRs
+- Consolidate related changes into meaningful commits
+- Simplify rollback operations`},
+			{"WARNING", `You may need to force push after squashing if branch is shared.
+Use with caution on shared branches.`},
+			{"GIT COMMANDS USED", `- git rev-parse --verify --quiet --long ${BRANCH}
+- git cherry-pick ${COMMIT}
+- git reset --hard ${BRANCH}
+- git checkout -b ${TEMP_BRANCH}`},
+			{"SECURITY", `All squash operations are validated and logged.`},
+		},
+		Run: (*Manager).Squash,
+	}
 }
 
-func (m *Manager) showPRUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ pr [<title>] [options]
-
-DESCRIPTION:
-  Create a Pull Request (PR) or Merge Request (MR) for the current branch.
-  Automatically detects the Git hosting platform and uses appropriate tools.
-
-PLATFORMS SUPPORTED:
-  ✅ GitHub: Uses 'gh' CLI or provides web URL
-  ✅ GitLab: Uses 'glab' CLI or provides web URL
-  ✅ Bitbucket: Provides web URL
-  ✅ Generic: Provides web URL with branch info
-
-FEATURES:
-  ✅ Auto-platform detection
-  ✅ CLI tool integration (gh, glab)
-  ✅ Web URL fallback
-  ✅ Branch validation
-  ✅ Commit message integration
-  ✅ Custom title and description
-  ✅ Automatic commit squashing (configurable)
-  ✅ Automatic description generation from changed files
-
-EXAMPLES:
-  git @ pr                                    # Create PR with default title and auto-generated description
-  git @ pr "Add user authentication"          # Create PR with custom title and auto-generated description
-  git @ pr -d "Detailed description here"     # Create PR with custom description
-  git @ pr -b main                            # Create PR targeting main branch
-  git @ pr -h                                 # Show this help
-
-OPTIONS:
-  -t, --title <title>       PR title (defaults to last commit message)
-  -d, --description <desc>  PR description
-  -b, --base <branch>       Target branch (defaults to configured trunk)
-  -o, --open               Open PR in browser after creation
-  -s, --squash             Force squash commits before PR (overrides setting)
-  -S, --no-squash          Force no squash (overrides setting)
-  -h, --help               Show this help message
-
-AUTOMATIC FEATURES:
-  - Uses last commit message as default title
-  - Generates description from changed files (when not provided)
-  - Includes branch name and commit info
-  - Validates current branch is not trunk
-  - Checks for uncommitted changes
-  - Auto-squash commits if at.pr.squash is enabled
-
-CONFIGURATION:
-  git config at.pr.squash true    # Enable automatic squashing
-  git config at.pr.squash false   # Disable automatic squashing
-`)
-	return nil
+func prCommand() *Command {
+	return &Command{
+		Name:    "pr",
+		Hint:    "[options]",
+		Summary: "Create Pull Requests with auto-description generation",
+		Usage:   []string{"[<title>] [options]", "status", "list"},
+		Description: `Create a Pull Request (PR) or Merge Request (MR) for the current branch.
+Automatically detects the Git hosting platform and uses appropriate tools.`,
+		Flags: []Flag{
+			{Short: "t", Long: "title", Value: "title", Help: "PR title (defaults to last commit message, see at.pr.title)"},
+			{Short: "d", Long: "description", Value: "desc", Help: "PR description"},
+			{Short: "b", Long: "base", Value: "branch", Optional: true, Help: "Target branch (defaults to the parent of a stacked branch, otherwise the configured trunk); without a branch, pick it", Source: SourceBranches},
+			{Short: "o", Long: "open", Help: "Open PR in browser after creation"},
+			{Short: "s", Long: "squash", Help: "Force squash commits before PR (overrides setting)"},
+			{Short: "S", Long: "no-squash", Help: "Force no squash (overrides setting)"},
+			{Short: "D", Long: "draft", Help: "Create the PR as a draft"},
+			{Short: "R", Long: "ready", Help: "Create the PR ready for review, or mark an existing draft PR as ready"},
+			{Short: "r", Long: "reviewer", Value: "users", Help: "Request reviews (repeatable or comma-separated)", Source: SourceReviewers},
+			{Short: "l", Long: "label", Value: "labels", Help: "Add labels (repeatable or comma-separated)"},
+			{Short: "a", Long: "assignee", Value: "users", Help: "Assign users (repeatable or comma-separated)"},
+			{Short: "m", Long: "milestone", Value: "name", Help: "Add the PR to a milestone"},
+			{Long: "no-emoji", Help: "Generate the description without emojis"},
+		},
+		Subcommands: []*Command{
+			prStatusCommand(),
+			{Name: "list", Summary: "Show the PRs of all work branches"},
+		},
+		Examples: []Example{
+			{"git @ pr", "Create PR with default title and auto-generated description"},
+			{"git @ pr \"Add user authentication\"", "Create PR with custom title and auto-generated description"},
+			{"git @ pr -d \"Detailed description here\"", "Create PR with custom description"},
+			{"git @ pr -b main", "Create PR targeting main branch"},
+			{"git @ pr --draft -r alice,bob", "Create draft PR and request reviews"},
+			{"git @ pr -l bug -l backend -a @me", "Create PR with labels and assignee"},
+			{"git @ pr -m \"v1.2\"", "Create PR in milestone v1.2"},
+			{"git @ pr status", "Show PR, reviews and checks of current branch"},
+			{"git @ pr list", "Show PRs of all work branches"},
+			{"git @ pr --ready", "Update the open PR and mark it ready"},
+		},
+		Sections: []Section{
+			{"PLATFORMS SUPPORTED", `✅ GitHub: Uses 'gh' CLI or provides web URL
+✅ GitLab: Uses 'glab' CLI or provides web URL
+✅ Bitbucket: Provides web URL
+✅ Generic: Provides web URL with branch info`},
+			{"FEATURES", `✅ Auto-platform detection
+✅ CLI tool integration (gh, glab)
+✅ Web URL fallback
+✅ Branch validation
+✅ Commit message integration
+✅ Custom title and description
+✅ Automatic commit squashing (configurable)
+✅ Automatic description generation from changed files`},
+			{"AUTOMATIC FEATURES", `- Uses last commit message as default title
+- Generates description from changed files (when not provided), with
+  per-file line counts, renames and commits grouped by type
+- Includes branch name and commit info
+- Validates current branch is not trunk
+- Checks for uncommitted changes
+- Auto-squash commits if at.pr.squash is enabled`},
+			{"UPDATING AN EXISTING PR", `When the branch already has an open PR, pr offers to update it instead of
+creating a duplicate. The branch is pushed with --force-with-lease, the
+title is refreshed and the generated part of the description is replaced.
+Text written outside the gitAT markers in the description is kept:
+  <!-- gitat:auto-description:start --> ... <!-- gitat:auto-description:end -->`},
+			{"CONFIGURATION", `git config at.pr.squash true    # Enable automatic squashing
+git config at.pr.squash false   # Disable automatic squashing
+git config at.pr.emoji false    # Generate descriptions without emojis
+
+Defaults for all PRs:
+git config at.pr.draft true                 # Create PRs as drafts
+git config --add at.pr.reviewer alice       # Always request review from alice
+git config --add at.pr.label needs-review   # Always add a label
+git config --add at.pr.assignee @me         # Always assign yourself
+git config at.pr.milestone "v1.2"           # Default milestone
+
+Defaults per work type (at.pr.<type>.<option>):
+git config --add at.pr.hotfix.label urgent  # Label hotfix PRs (built-in default)
+git config at.pr.docs.draft false           # Docs PRs are never drafts
+
+Flags are combined with the configured reviewers, labels and assignees.
+Draft and milestone flags override the configuration.`},
+		},
+		Run: (*Manager).PullRequest,
+	}
 }
 
 // Branch handles the branch command
 func (m *Manager) Branch(args []string) error {
-	if len(args) == 0 {
-		// Show configured working branch
-		return m.showBranch()
+	flags, err := branchCommand().parse(args)
+	if err != nil {
+		return err
 	}
-
-	if len(args) >= 1 {
-		// Handle help first
-		if args[0] == "-h" || args[0] == "--help" || args[0] == "help" || args[0] == "h" {
-			return m.showBranchUsage()
-		}
-
-		// Parse flags for combined operations
-		var operations []string
-
-		// Parse all arguments
-		for _, arg := range args {
-			// Handle combined flags like -nc
-			if strings.HasPrefix(arg, "-") && len(arg) > 1 {
-				// Parse each character in the flag
-				for i := 1; i < len(arg); i++ {
-					switch arg[i] {
-					case 'n':
-						operations = append(operations, "new")
-					case 'c':
-						operations = append(operations, "current")
-					case 's':
-						operations = append(operations, "set")
-					}
-				}
-			} else {
-				// Handle individual flags
-				switch arg {
-				case "-n", "--new", "n", "new":
-					operations = append(operations, "new")
-				case "-c", "--current", "c", "current":
-					operations = append(operations, "current")
-				case "-s", "--set", "s", "set", ".":
-					operations = append(operations, "set")
-				case "--hotfix":
-					return m.listBranchesByType("hotfix-")
-				case "--feature":
-					return m.listBranchesByType("feature-")
-				case "--bugfix":
-					return m.listBranchesByType("bugfix-")
-				case "--release":
-					return m.listBranchesByType("release-")
-				case "--chore":
-					return m.listBranchesByType("chore-")
-				case "--docs":
-					return m.listBranchesByType("docs-")
-				case "--style":
-					return m.listBranchesByType("style-")
-				case "--refactor":
-					return m.listBranchesByType("refactor-")
-				case "--perf":
-					return m.listBranchesByType("perf-")
-				case "--test":
-					return m.listBranchesByType("test-")
-				case "--ci":
-					return m.listBranchesByType("ci-")
-				case "--build":
-					return m.listBranchesByType("build-")
-				case "--revert":
-					return m.listBranchesByType("revert-")
-				case "--all-types":
-					return m.listAllWorkTypes()
-				default:
-					// Set working branch to specified name
-					return m.setBranch(arg)
-				}
-			}
-		}
-
-		// Execute operations (only the first one, as these are mutually exclusive)
-		if len(operations) > 0 {
-			switch operations[0] {
-			case "new":
-				return m.newWorkingBranch()
-			case "current":
-				return m.currentBranch()
-			case "set":
-				return m.setBranchToCurrent()
-			}
-		}
+	if flags.Has("help") {
+		return m.help("branch")
+	}
+
+	// Only the first operation runs, as they are mutually exclusive
+	operations := append([]string{"new", "current", "set", "pick", "all-types"}, workTypes...)
+	switch operation := flags.First(operations...); operation {
+	case "new":
+		return m.newWorkingBranch()
+	case "pick":
+		return m.pickWorkingBranch()
+	case "current":
+		return m.currentBranch()
+	case "set":
+		return m.setBranchToCurrent()
+	case "all-types":
+		return m.listAllWorkTypes()
+	case "":
+	default:
+		return m.listBranchesByType(operation + "-")
 	}
 
-	return m.showBranchUsage()
+	switch positional := flags.Args(); {
+	case len(positional) == 0:
+		// Show configured working branch
+		return m.showBranch()
+	case positional[0] == ".":
+		return m.setBranchToCurrent()
+	default:
+		// Set working branch to specified name
+		return m.setBranch(positional[0])
+	}
 }
 
 // Helper methods for branch management
 func (m *Manager) showBranch() error {
 	branch, err := m.git.GetConfig("at.branch")
+	if output.JSONEnabled() {
+		current, _ := m.git.GetCurrentBranch()
+		return output.JSON(map[string]string{"working_branch": branch, "current_branch": current})
+	}
 	if err != nil {
 		// No branch configured
 		fmt.Println("")
@@ -2194,7 +2274,10 @@ func (m *Manager) showBranch() error {
 func (m *Manager) currentBranch() error {
 	branch, err := m.git.GetCurrentBranch()
 	if err != nil {
-		return fmt.Errorf("error: Not on a branch (detached HEAD state)")
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+	if output.JSONEnabled() {
+		return output.JSON(map[string]string{"current_branch": branch})
 	}
 	fmt.Println(branch)
 	return nil
@@ -2203,15 +2286,27 @@ func (m *Manager) currentBranch() error {
 func (m *Manager) setBranchToCurrent() error {
 	currentBranch, err := m.git.GetCurrentBranch()
 	if err != nil {
-		return fmt.Errorf("error: Not on a branch (detached HEAD state)")
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
 	}
 	return m.setBranch(currentBranch)
 }
 
+func (m *Manager) pickWorkingBranch() error {
+	working, _ := m.git.GetConfig("at.branch")
+	choice, ok, err := m.pickBranch("Working branch", working, func(c branchChoice) bool { return !c.Remote })
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.New(errs.Usage, "--pick needs a terminal and another local branch; give the branch name instead")
+	}
+	return m.setBranch(choice.Name)
+}
+
 func (m *Manager) newWorkingBranch() error {
 	_, err := m.git.GetCurrentBranch()
 	if err != nil {
-		return fmt.Errorf("error: Not on a branch (detached HEAD state)")
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
 	}
 
 	// Create new branch name with timestamp
@@ -2237,6 +2332,10 @@ func (m *Manager) newWorkingBranch() error {
 }
 
 func (m *Manager) listBranchesByType(prefix string) error {
+	if output.JSONEnabled() {
+		return m.printBranchesJSON(prefix)
+	}
+
 	branchType := strings.TrimSuffix(prefix, "-")
 	fmt.Printf("📋 %s branches:\n\n", branchType)
 
@@ -2272,6 +2371,9 @@ func (m *Manager) listBranchesByType(prefix string) error {
 			} else {
 				fmt.Printf("     📝 %s\n", strings.TrimSpace(lastCommit))
 			}
+			if context := m.getBranchContext(branch).label(); context != "" {
+				fmt.Printf("     🏷️  %s\n", context)
+			}
 			fmt.Println()
 		}
 	}
@@ -2296,9 +2398,16 @@ func (m *Manager) listBranchesByType(prefix string) error {
 }
 
 func (m *Manager) listAllWorkTypes() error {
+	if output.JSONEnabled() {
+		var prefixes []string
+		for _, workType := range workTypes {
+			prefixes = append(prefixes, workType+"-")
+		}
+		return m.printBranchesJSON(prefixes...)
+	}
+
 	fmt.Println("📊 All work type branches:")
 
-	workTypes := []string{"hotfix", "feature", "bugfix", "release", "chore", "docs", "style", "refactor", "perf", "test", "ci", "build", "revert"}
 	currentBranch, _ := m.git.GetCurrentBranch()
 
 	// Get all local branches
@@ -2329,6 +2438,9 @@ func (m *Manager) listAllWorkTypes() error {
 				if branch == currentBranch {
 					status = " (current)"
 				}
+				if context := m.ownBranchContext(branch).label(); context != "" {
+					status += " [" + context + "]"
+				}
 				fmt.Printf("    🌿 %s%s\n", branch, status)
 			}
 			fmt.Println()
@@ -2341,494 +2453,622 @@ func (m *Manager) listAllWorkTypes() error {
 	return nil
 }
 
-func (m *Manager) showBranchUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ branch [<branch-name>] [options]
-
-DESCRIPTION:
-  Manage working branch configuration and list branches by type.
-  Shows current working branch, sets new working branch, or lists branches.
-
-OPTIONS:
-  (no options)           Show configured working branch
-  <branch-name>          Set working branch to specified name
-  -c, --current          Show current Git branch
-  -s, --set, .           Set working branch to current branch
-  -n, --new              Create new feature branch with timestamp
-  --hotfix               List hotfix branches
-  --feature              List feature branches
-  --bugfix               List bugfix branches
-  --release              List release branches
-  --chore                List chore branches
-  --docs                 List docs branches
-  --style                List style branches
-  --refactor             List refactor branches
-  --perf                 List perf branches
-  --test                 List test branches
-  --ci                   List ci branches
-  --build                List build branches
-  --revert               List revert branches
-  --all-types            List all work type branches
-  -h, --help             Show this help
-
-EXAMPLES:
-  git @ branch                    # Show configured working branch
-  git @ branch feature-auth       # Set working branch to feature-auth
-  git @ branch -c                 # Show current Git branch
-  git @ branch -s                 # Set working branch to current branch
-  git @ branch -n                 # Create new feature branch
-  git @ branch -nc                # Combined flags (first operation takes precedence)
-  git @ branch --hotfix           # List hotfix branches
-  git @ branch --all-types        # List all work type branches
-
-WORKFLOW:
-  Use working branch to track which branch you're actively working on.
-  This helps with context switching and branch management.
-`)
-	return nil
+// BranchEntry describes a local branch and the work it holds
+type BranchEntry struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Current bool   `json:"current"`
+	Product string `json:"product"`
+	Feature string `json:"feature"`
+	Issue   string `json:"issue"`
 }
 
-// Sweep handles the sweep command
-func (m *Manager) Sweep(args []string) error {
-	if len(args) == 1 {
-		switch args[0] {
-		case "-h", "--help", "help", "h":
-			return m.showSweepUsage()
+// Branches returns the local branches with their work type and context.
+// Type is empty for branches that are not work branches.
+func (m *Manager) Branches() ([]BranchEntry, error) {
+	branches, err := m.git.GetBranches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	currentBranch, _ := m.git.GetCurrentBranch()
+
+	entries := []BranchEntry{}
+	for _, branch := range branches {
+		ctx := m.getBranchContext(branch)
+		entries = append(entries, BranchEntry{
+			Name:    branch,
+			Type:    m.getWorkType(branch),
+			Current: branch == currentBranch,
+			Product: ctx.Product,
+			Feature: ctx.Feature,
+			Issue:   ctx.Issue,
+		})
+	}
+	return entries, nil
+}
+
+// printBranchesJSON prints the local branches starting with one of prefixes
+func (m *Manager) printBranchesJSON(prefixes ...string) error {
+	branches, err := m.Branches()
+	if err != nil {
+		return err
+	}
+
+	entries := []BranchEntry{}
+	for _, branch := range branches {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(branch.Name, prefix) {
+				entries = append(entries, branch)
+				break
+			}
 		}
 	}
+	return output.JSON(map[string][]BranchEntry{"branches": entries})
+}
+
+// DeleteBranch deletes a local branch. Like sweep it keeps the current
+// branch, the trunk and the working branch. Without force, git refuses
+// branches that are not merged.
+func (m *Manager) DeleteBranch(name string, force bool) error {
+	currentBranch, _ := m.git.GetCurrentBranch()
+	workingBranch, _ := m.git.GetConfig("at.branch")
+	switch name {
+	case currentBranch:
+		return errs.New(errs.PolicyViolation, "Cannot delete the current branch %s", name)
+	case m.trunkBranch():
+		return errs.New(errs.PolicyViolation, "Cannot delete the trunk branch %s", name)
+	case workingBranch:
+		return errs.New(errs.PolicyViolation, "Cannot delete the working branch %s (see 'git @ branch')", name)
+	}
 
-	// TODO: Implement sweep command
-	fmt.Println("Sweep command not yet implemented")
+	if err := m.git.DeleteBranch(name, force); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", name, err)
+	}
 	return nil
 }
 
-func (m *Manager) showSweepUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ sweep [options]
+// branchTypeFlags lists the branches of one work type each
+func branchTypeFlags() []Flag {
+	var flags []Flag
+	for _, workType := range workTypes {
+		flags = append(flags, Flag{Long: workType, Help: fmt.Sprintf("List %s branches", workType)})
+	}
+	return append(flags, Flag{Long: "all-types", Help: "List all work type branches"})
+}
 
-DESCRIPTION:
-  Clean up local branches that have been merged or deleted remotely.
-  Safely removes branches that are no longer needed.
+func branchCommand() *Command {
+	return &Command{
+		Name:    "branch",
+		Summary: "Manage working branch configuration",
+		Usage:   []string{"[<branch-name>] [options]"},
+		Description: `Manage working branch configuration and list branches by type.
+Shows current working branch, sets new working branch, or lists branches.
+Without options, shows the configured working branch.`,
+		Args: []Arg{
+			{Name: "<branch-name>", Help: "Set working branch to specified name", Source: SourceBranches},
+		},
+		Flags: append([]Flag{
+			{Short: "c", Long: "current", Help: "Show current Git branch"},
+			{Short: "s", Long: "set", Help: "Set working branch to current branch (also: .)"},
+			{Short: "n", Long: "new", Help: "Create new feature branch with timestamp"},
+			{Short: "p", Long: "pick", Help: "Pick the working branch from the local branches"},
+		}, branchTypeFlags()...),
+		Examples: []Example{
+			{"git @ branch", "Show configured working branch"},
+			{"git @ branch feature-auth", "Set working branch to feature-auth"},
+			{"git @ branch -c", "Show current Git branch"},
+			{"git @ branch -s", "Set working branch to current branch"},
+			{"git @ branch -p", "Pick the working branch"},
+			{"git @ branch -n", "Create new feature branch"},
+			{"git @ branch -nc", "Combined flags (first operation takes precedence)"},
+			{"git @ branch --hotfix", "List hotfix branches"},
+			{"git @ branch --all-types", "List all work type branches"},
+		},
+		Sections: []Section{
+			{"WORKFLOW", `Use working branch to track which branch you're actively working on.
+This helps with context switching and branch management.`},
+		},
+		Run: (*Manager).Branch,
+	}
+}
 
-OPTIONS:
-  -h, --help           Show this help message
+// Sweep handles the sweep command
+func (m *Manager) Sweep(args []string) error {
+	cmd := command("sweep")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("sweep")
+	}
+	if err := cmd.extraArgs(flags, 0); err != nil {
+		return err
+	}
+	if flags.Has("dry-run") && !m.dryRun() {
+		m.SetDryRun(git.NewPlanner())
+	}
+	yes := flags.Has("yes")
 
-EXAMPLES:
-  git @ sweep                    # Clean up merged and deleted branches
-  git @ sweep --help             # Show this help
+	currentBranch, err := m.git.GetCurrentBranch()
+	if err != nil {
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
 
-FEATURES:
-  - Removes merged branches
-  - Removes branches deleted from remote
-  - Preserves current branch
-  - Preserves configured working branch
-  - Safe operation with confirmation
-`)
-	return nil
-}
+	trunk := m.trunkBranch()
+	workingBranch, _ := m.git.GetConfig("at.branch")
 
-// Info handles the info command
-func (m *Manager) Info(args []string) error {
-	if len(args) == 1 {
-		switch args[0] {
-		case "-h", "--help", "help", "h":
-			return m.showInfoUsage()
+	// Refresh remote branches so deleted ones show as gone
+	if _, err := m.git.Run("remote", "get-url", "origin"); err == nil {
+		fmt.Println("Fetching origin...")
+		if err := m.git.Stream("fetch", "--prune", "origin"); err != nil {
+			fmt.Printf("Warning: Failed to fetch origin: %v\n", err)
 		}
 	}
 
-	// TODO: Implement info command
-	fmt.Println("Info command not yet implemented")
-	return nil
-}
+	candidates, err := m.sweepCandidates(trunk)
+	if err != nil {
+		return err
+	}
+
+	var branches []string
+	for _, branch := range candidates {
+		if branch.name == currentBranch || branch.name == trunk || branch.name == workingBranch {
+			continue
+		}
+		branches = append(branches, branch.name)
+	}
+
+	if len(branches) == 0 {
+		fmt.Println("No branches to sweep")
+		return m.pruneWorktrees(true)
+	}
 
-func (m *Manager) showInfoUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ info [options]
+	reasons := make(map[string]string)
+	for _, branch := range candidates {
+		reasons[branch.name] = branch.reason
+	}
 
-DESCRIPTION:
-  Show comprehensive status report from all GitAT commands.
-  Provides a complete overview of current repository state.
+	fmt.Println("Branches to delete:")
+	for _, branch := range branches {
+		line := fmt.Sprintf("  %s (%s)", branch, reasons[branch])
+		if wt := m.findWorktree(branch); wt != nil {
+			line += fmt.Sprintf(", worktree %s", wt.Path)
+		}
+		fmt.Println(line)
+	}
 
-OPTIONS:
-  -h, --help           Show this help message
+	// A dry run plans the deletions without asking
+	if !yes && !m.dryRun() {
+		fmt.Print("Delete these branches? (y/N): ")
+		var confirmation string
+		fmt.Scanln(&confirmation)
+		if !strings.HasPrefix(strings.ToLower(confirmation), "y") {
+			fmt.Println("Operation cancelled")
+			return nil
+		}
+	}
 
-EXAMPLES:
-  git @ info                    # Show comprehensive status
-  git @ info --help             # Show this help
+	removed, deleted := "Removed", "Deleted"
+	if m.dryRun() {
+		removed, deleted = "Would remove", "Would delete"
+	}
 
-FEATURES:
-  - Repository status
-  - Branch information
-  - Configuration summary
-  - Recent commits
-  - Uncommitted changes
-  - Remote status
-`)
-	return nil
-}
+	count := 0
+	for _, branch := range branches {
+		// A branch checked out in a worktree cannot be deleted
+		if wt := m.findWorktree(branch); wt != nil {
+			if _, err := m.git.Run("worktree", "remove", wt.Path); err != nil {
+				fmt.Printf("Warning: Skipping %s, its worktree %s has changes\n", branch, wt.Path)
+				continue
+			}
+			fmt.Printf("🗑️  %s worktree %s\n", removed, wt.Path)
+		}
 
-// Hash handles the hash command
-func (m *Manager) Hash(args []string) error {
-	if len(args) == 1 {
-		switch args[0] {
-		case "-h", "--help", "help", "h":
-			return m.showHashUsage()
+		// Gone branches may have been squash-merged, so git cannot tell
+		// they are merged
+		force := reasons[branch] == "gone"
+		if err := m.git.DeleteBranch(branch, force); err != nil {
+			fmt.Printf("Warning: Failed to delete %s: %v\n", branch, err)
+			continue
 		}
+		fmt.Printf("🗑️  %s %s\n", deleted, branch)
+		count++
+	}
+
+	if err := m.pruneWorktrees(true); err != nil {
+		return err
 	}
 
-	// TODO: Implement hash command
-	fmt.Println("Hash command not yet implemented")
+	if !m.dryRun() {
+		fmt.Printf("✅ Swept %d branch(es)\n", count)
+	}
 	return nil
 }
 
-func (m *Manager) showHashUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ hash [options]
+// sweepCandidate is a branch that sweep may delete
+type sweepCandidate struct {
+	name   string
+	reason string // merged or gone
+}
 
-DESCRIPTION:
-  Show detailed branch status and commit relationships.
-  Provides information about branch divergence and merge bases.
+// sweepCandidates returns the branches merged into trunk and the branches
+// whose upstream was deleted, sorted by name
+func (m *Manager) sweepCandidates(trunk string) ([]sweepCandidate, error) {
+	merged, err := m.git.GetMergedBranches(trunk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merged branches: %w", err)
+	}
+
+	reasons := make(map[string]string)
+	for _, branch := range merged {
+		if branch = strings.TrimSpace(branch); branch != "" {
+			reasons[branch] = "merged"
+		}
+	}
 
-OPTIONS:
-  -h, --help           Show this help message
+	tracking, _ := m.git.Run("for-each-ref", "--format=%(refname:short) %(upstream:track)", "refs/heads")
+	for _, line := range strings.Split(tracking, "\n") {
+		branch, track, _ := strings.Cut(line, " ")
+		if track == "[gone]" && reasons[branch] == "" {
+			reasons[branch] = "gone"
+		}
+	}
 
-EXAMPLES:
-  git @ hash                    # Show branch status and relationships
-  git @ hash --help             # Show this help
+	var candidates []sweepCandidate
+	for branch, reason := range reasons {
+		candidates = append(candidates, sweepCandidate{name: branch, reason: reason})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].name < candidates[j].name
+	})
+	return candidates, nil
+}
 
-FEATURES:
-  - Branch divergence information
-  - Commit relationship analysis
-  - Merge base details
-  - Branch comparison
-  - Commit graph visualization
-`)
-	return nil
+func sweepCommand() *Command {
+	return &Command{
+		Name:    "sweep",
+		Summary: "Clean up local branches (merged + remote-deleted)",
+		Usage:   []string{"[options]"},
+		Description: `Clean up local branches that have been merged or deleted remotely.
+Safely removes branches that are no longer needed.`,
+		Flags: []Flag{
+			{Short: "n", Long: "dry-run", Help: "Show the commands that would run, deleting nothing"},
+			{Short: "y", Long: "yes", Help: "Delete without asking for confirmation"},
+		},
+		Examples: []Example{
+			{"git @ sweep", "Clean up merged and deleted branches"},
+			{"git @ sweep -n", "Show what would be deleted"},
+			{"git @ sweep --help", "Show this help"},
+		},
+		Sections: []Section{
+			{"FEATURES", `- Removes branches merged into the trunk
+- Removes branches whose upstream was deleted from the remote
+- Removes the worktrees of swept branches (if they have no changes)
+- Prunes worktrees whose directories were deleted
+- Preserves trunk, current branch and configured working branch
+- Safe operation with confirmation`},
+		},
+		Run: (*Manager).Sweep,
+	}
 }
 
 // Product handles the product command
 func (m *Manager) Product(args []string) error {
+	flags, err := command("product").parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("product")
+	}
+	branch, args := m.contextScope(flags), flags.Args()
 	if len(args) == 0 {
-		// Show current product name
-		product, err := m.git.GetConfig("at.product")
-		if err != nil {
-			fmt.Println("")
-			return nil
+		// Show the product of the current branch
+		value := m.contextValue(branch, "product", "at.product")
+		if output.JSONEnabled() {
+			return output.JSON(map[string]string{"product": value, "branch": branch})
 		}
-		fmt.Println(product)
+		fmt.Println(value)
 		return nil
 	}
 
-	if len(args) == 1 {
-		switch args[0] {
-		case "-h", "--help", "help", "h":
-			return m.showProductUsage()
-		}
-	}
-
 	// Set product name
 	productName := strings.Join(args, " ")
 
 	// Validate product name
 	if strings.ContainsAny(productName, ";|`$(){}") {
-		return fmt.Errorf("error: Invalid product name. Use only alphanumeric characters, dots, underscores, and hyphens")
+		return errs.New(errs.Usage, "Invalid product name. Use only alphanumeric characters, dots, underscores, and hyphens")
 	}
 
-	oldProduct, _ := m.git.GetConfig("at.product")
-	err := m.git.SetConfig("at.product", productName)
+	oldProduct, err := m.setContextValue(branch, "product", "at.product", productName)
 	if err != nil {
 		return fmt.Errorf("failed to set product: %w", err)
 	}
 
-	fmt.Printf("Project updated to: %s from %s\n", productName, oldProduct)
+	fmt.Printf("Project updated to: %s from %s%s\n", productName, oldProduct, scopeSuffix(branch))
 	return nil
 }
 
-func (m *Manager) showProductUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ product [<product-name>]
-
-DESCRIPTION:
-  Set or get the current product name for GitAT workflow management.
-  The product name is used in commit labels and configuration.
-  Examples: gitAT, myApp, apiService
-
-EXAMPLES:
-  git @ product                    # Show current product name
-  git @ product gitAT              # Set product name to "gitAT"
-  git @ product myApp              # Set product name to "myApp"
-  git @ product apiService         # Set product name to "apiService"
-
-VALIDATION:
-  Product names must contain only:
-  - Alphanumeric characters (a-z, A-Z, 0-9)
-  - Dots (.)
-  - Underscores (_)
-  - Hyphens (-)
-
-STORAGE:
-  Saved in git config: at.product
-
-SECURITY:
-  All inputs are validated against dangerous characters and patterns.
-`)
-	return nil
+func productCommand() *Command {
+	return &Command{
+		Name:    "product",
+		Hint:    "[<name>]",
+		Summary: "Product name configuration",
+		Usage:   []string{"[<product-name>] [--default]"},
+		Description: `Set or get the current product name for GitAT workflow management.
+The product name is used in commit labels and configuration.
+Examples: gitAT, myApp, apiService
+
+On a work branch the product is stored for that branch only; elsewhere,
+or with --default, it sets the repository default used by branches
+without their own value.`,
+		Flags: []Flag{
+			{Short: "d", Long: "default", Help: "Show or set the repository default"},
+		},
+		Examples: []Example{
+			{"git @ product", "Show current product name"},
+			{"git @ product gitAT", "Set product name to \"gitAT\""},
+			{"git @ product myApp", "Set product name to \"myApp\""},
+			{"git @ product apiService --default # Set the repository default", ""},
+		},
+		Sections: []Section{
+			{"VALIDATION", `Product names must contain only:
+- Alphanumeric characters (a-z, A-Z, 0-9)
+- Dots (.)
+- Underscores (_)
+- Hyphens (-)`},
+			{"STORAGE", `Saved in git config: branch.<name>.at-product, default at.product`},
+			{"SECURITY", `All inputs are validated against dangerous characters and patterns.`},
+		},
+		Run: (*Manager).Product,
+	}
 }
 
 // Feature handles the feature command
 func (m *Manager) Feature(args []string) error {
+	flags, err := command("feature").parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("feature")
+	}
+	branch, args := m.contextScope(flags), flags.Args()
 	if len(args) == 0 {
-		// Show current feature name
-		feature, err := m.git.GetConfig("at.feature")
-		if err != nil {
-			fmt.Println("")
-			return nil
+		// Show the feature of the current branch
+		value := m.contextValue(branch, "feature", "at.feature")
+		if output.JSONEnabled() {
+			return output.JSON(map[string]string{"feature": value, "branch": branch})
 		}
-		fmt.Println(feature)
+		fmt.Println(value)
 		return nil
 	}
 
-	if len(args) == 1 {
-		switch args[0] {
-		case "-h", "--help", "help", "h":
-			return m.showFeatureUsage()
-		}
-	}
-
 	// Set feature name
 	featureName := strings.Join(args, " ")
 
 	// Validate feature name
 	if strings.ContainsAny(featureName, ";|`$(){}") {
-		return fmt.Errorf("error: Invalid feature name. Use only alphanumeric characters, dots, underscores, and hyphens")
+		return errs.New(errs.Usage, "Invalid feature name. Use only alphanumeric characters, dots, underscores, and hyphens")
 	}
 
-	oldFeature, _ := m.git.GetConfig("at.feature")
-	err := m.git.SetConfig("at.feature", featureName)
+	oldFeature, err := m.setContextValue(branch, "feature", "at.feature", featureName)
 	if err != nil {
 		return fmt.Errorf("failed to set feature: %w", err)
 	}
 
-	fmt.Printf("Feature updated to: %s from %s\n", featureName, oldFeature)
+	fmt.Printf("Feature updated to: %s from %s%s\n", featureName, oldFeature, scopeSuffix(branch))
 	return nil
 }
 
-func (m *Manager) showFeatureUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ feature [<feature-name>]
-
-DESCRIPTION:
-  Set or get the current feature name for GitAT workflow management.
-  The feature name is used in commit labels and helps track what you're working on.
-
-EXAMPLES:
-  git @ feature                    # Show current feature name
-  git @ feature user-auth          # Set feature to "user-auth"
-  git @ feature payment-integration # Set feature to "payment-integration"
-
-VALIDATION:
-  Feature names must contain only:
-  - Alphanumeric characters (a-z, A-Z, 0-9)
-  - Dots (.)
-  - Underscores (_)
-  - Hyphens (-)
-
-STORAGE:
-  Saved in git config: at.feature
-
-SECURITY:
-  All inputs are validated against dangerous characters and patterns.
-`)
-	return nil
+func featureCommand() *Command {
+	return &Command{
+		Name:    "feature",
+		Hint:    "[<name>]",
+		Summary: "Feature name configuration",
+		Usage:   []string{"[<feature-name>] [--default]"},
+		Description: `Set or get the current feature name for GitAT workflow management.
+The feature name is used in commit labels and helps track what you're working on.
+
+On a work branch the feature is stored for that branch only, so switching
+branches never stamps the wrong feature on commits. Elsewhere, or with
+--default, it sets the repository default. 'git @ work' copies the
+product and feature of the branch it starts from.`,
+		Flags: []Flag{
+			{Short: "d", Long: "default", Help: "Show or set the repository default"},
+		},
+		Examples: []Example{
+			{"git @ feature", "Show current feature name"},
+			{"git @ feature user-auth", "Set feature to \"user-auth\""},
+			{"git @ feature payment-integration # Set feature to \"payment-integration\"", ""},
+		},
+		Sections: []Section{
+			{"VALIDATION", `Feature names must contain only:
+- Alphanumeric characters (a-z, A-Z, 0-9)
+- Dots (.)
+- Underscores (_)
+- Hyphens (-)`},
+			{"STORAGE", `Saved in git config: branch.<name>.at-feature, default at.feature`},
+			{"SECURITY", `All inputs are validated against dangerous characters and patterns.`},
+		},
+		Run: (*Manager).Feature,
+	}
 }
 
 // Issue handles the issue command
 func (m *Manager) Issue(args []string) error {
+	flags, err := command("issue").parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("issue")
+	}
+	branch, args := m.contextScope(flags), flags.Args()
 	if len(args) == 0 {
-		// Show current issue ID
-		issue, err := m.git.GetConfig("at.task")
-		if err != nil {
-			fmt.Println("")
-			return nil
+		// Show the issue of the current branch
+		issue := m.getBranchContext(branch).Issue
+		if branch == "" {
+			issue, _ = m.git.GetConfig("at.task")
+		}
+		if output.JSONEnabled() {
+			return output.JSON(map[string]string{"issue": issue, "branch": branch})
 		}
 		fmt.Println(issue)
 		return nil
 	}
 
-	if len(args) == 1 {
-		switch args[0] {
-		case "-h", "--help", "help", "h":
-			return m.showIssueUsage()
-		}
-	}
-
 	// Set issue ID
 	issueID := strings.Join(args, " ")
 
-	oldIssue, _ := m.git.GetConfig("at.task")
-	err := m.git.SetConfig("at.task", issueID)
-	if err != nil {
+	oldIssue := m.getBranchContext(branch).Issue
+	if branch == "" {
+		oldIssue, _ = m.git.GetConfig("at.task")
+	}
+	if _, err := m.setContextValue(branch, "issue", "at.task", issueID); err != nil {
 		return fmt.Errorf("failed to set issue: %w", err)
 	}
 
-	fmt.Printf("Task updated to: %s from %s\n", issueID, oldIssue)
+	fmt.Printf("Task updated to: %s from %s%s\n", issueID, oldIssue, scopeSuffix(branch))
 	return nil
 }
 
-func (m *Manager) showIssueUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ issue [<issue-id>]
-
-DESCRIPTION:
-  Set or get the current issue/task identifier for tracking.
-  The issue ID is used in commit labels and helps link commits to issues.
-
-EXAMPLES:
-  git @ issue                    # Show current issue ID
-  git @ issue PROJ-123           # Set issue to "PROJ-123"
-  git @ issue BUG-456            # Set issue to "BUG-456"
-
-STORAGE:
-  Saved in git config: at.task
-
-SECURITY:
-  All issue operations are validated and logged.
-`)
-	return nil
+func issueCommand() *Command {
+	return &Command{
+		Name:    "issue",
+		Hint:    "[<id>]",
+		Summary: "Issue/task identifier configuration",
+		Usage:   []string{"[<issue-id>] [--default]"},
+		Description: `Set or get the current issue/task identifier for tracking.
+The issue ID is used in commit labels and helps link commits to issues.
+
+The issue of a branch is found in this order:
+  1. branch.<name>.at-issue (set by 'git @ issue' and 'git @ work --issue')
+  2. The key in the branch name matching at.issue.pattern
+  3. at.task (repository default)
+
+On a work branch 'git @ issue <id>' sets the issue of that branch;
+elsewhere, or with --default, it sets at.task.
+
+'git @ save' adds a Refs: <issue> trailer to commits of the branch and
+'git @ pr' links the issue in the generated description.`,
+		Flags: []Flag{
+			{Short: "d", Long: "default", Help: "Show or set the repository default"},
+		},
+		Examples: []Example{
+			{"git @ issue", "Show the issue of the current branch"},
+			{"git @ issue PROJ-123", "Set issue to \"PROJ-123\""},
+			{"git @ issue BUG-456", "Set issue to \"BUG-456\""},
+			{"git @ work --issue PROJ-123", "Create a branch from the issue and set it"},
+			{"git @ save --closes \"Fix\"", "Commit with a Closes: trailer instead of Refs:"},
+		},
+		Sections: []Section{
+			{"CONFIGURATION", `git config at.issue.pattern '[A-Z][A-Z0-9]+-\d+'   # Issue key regex (default)
+git config at.issue.pattern '#?(\d+)'              # Numeric GitHub/GitLab issues
+git config at.issue.trailer refs|closes|none       # Trailer added by save
+git config at.issue.url 'https://jira.example.com/browse/{issue}'`},
+			{"STORAGE", `Saved in git config: branch.<name>.at-issue, default at.task`},
+			{"SECURITY", `All issue operations are validated and logged.`},
+		},
+		Run: (*Manager).Issue,
+	}
 }
 
 // Version handles the version command
 func (m *Manager) Version(args []string) error {
-	if len(args) == 0 {
-		// Show current version
-		version, err := m.getVersion()
-		if err != nil {
-			return fmt.Errorf("failed to get version: %w", err)
-		}
-
-		output.Title("📦 GitAT Version")
-		output.Info("Current version: %s", version)
-
-		// Show version components
-		major, _ := m.git.GetConfig("at.major")
-		minor, _ := m.git.GetConfig("at.minor")
-		fix, _ := m.git.GetConfig("at.fix")
-
-		output.Table(
-			[]string{"Component", "Value"},
-			[][]string{
-				{"Major", major},
-				{"Minor", minor},
-				{"Fix", fix},
-			},
-		)
-
-		return nil
+	flags, err := versionCommand().parse(args)
+	if err != nil {
+		return err
 	}
 
-	if len(args) >= 1 {
-		// Handle help first
-		if args[0] == "-h" || args[0] == "--help" || args[0] == "help" || args[0] == "h" {
-			return m.showVersionUsage()
-		}
-
-		// Handle tag and reset (these are single operations)
-		if args[0] == "-t" || args[0] == "--tag" {
-			version, err := m.getVersion()
-			if err != nil {
-				return fmt.Errorf("failed to get version: %w", err)
-			}
-			output.Code("v" + version)
-			return nil
-		}
-
-		if args[0] == "--reset" {
-			return m.resetVersion()
-		}
-
-		if args[0] == "--set" {
-			return m.setVersion()
-		}
-
-		// Handle multiple increment flags
-		var operations []string
-
-		// Parse all arguments for increment operations
-		for _, arg := range args {
-			// Handle combined flags like -Mmb
-			if strings.HasPrefix(arg, "-") && len(arg) > 1 {
-				// Parse each character in the flag
-				for i := 1; i < len(arg); i++ {
-					switch arg[i] {
-					case 'M':
-						operations = append(operations, "major")
-					case 'm':
-						operations = append(operations, "minor")
-					case 'b':
-						operations = append(operations, "fix")
-					}
-				}
-			} else {
-				// Handle individual flags
-				switch arg {
-				case "-M", "--major":
-					operations = append(operations, "major")
-				case "-m", "--minor":
-					operations = append(operations, "minor")
-				case "-b", "--bump":
-					operations = append(operations, "fix")
-				}
-			}
-		}
-
-		// Execute operations in order: major, minor, fix
-		if len(operations) > 0 {
-			// Sort operations to ensure correct order
-			orderedOps := make([]string, 0)
-
-			// Add major first if present
-			for _, op := range operations {
-				if op == "major" {
-					orderedOps = append(orderedOps, op)
-				}
-			}
-
-			// Add minor second if present
-			for _, op := range operations {
-				if op == "minor" {
-					orderedOps = append(orderedOps, op)
-				}
-			}
-
-			// Add fix last if present
-			for _, op := range operations {
-				if op == "fix" {
-					orderedOps = append(orderedOps, op)
-				}
-			}
-
-			// Execute operations
-			for _, op := range orderedOps {
-				switch op {
-				case "major":
-					if err := m.incrementMajor(); err != nil {
-						return err
-					}
-				case "minor":
-					if err := m.incrementMinor(); err != nil {
-						return err
-					}
-				case "fix":
-					if err := m.incrementFix(); err != nil {
-						return err
-					}
-				}
-			}
-
-			// Show final version
-			version, err := m.getVersion()
-			if err != nil {
-				return fmt.Errorf("failed to get final version: %w", err)
+	// Tag, reset and set are single operations
+	switch {
+	case flags.Has("help"):
+		return m.help("version")
+	case flags.Has("tag"):
+		version, err := m.getVersion()
+		if err != nil {
+			return fmt.Errorf("failed to get version: %w", err)
+		}
+		if output.JSONEnabled() {
+			return output.JSON(map[string]string{"tag": "v" + version})
+		}
+		output.Code("v" + version)
+		return nil
+	case flags.Has("reset"):
+		return m.resetVersion()
+	case flags.Has("set"):
+		return m.setVersion()
+	}
+
+	if len(flags.Args()) > 0 {
+		return errs.New(errs.Usage, "Unexpected argument '%s' (see 'git @ version --help')", flags.Args()[0])
+	}
+
+	// Increments run in order: major, minor, fix
+	increments := []struct {
+		flag      string
+		increment func() error
+	}{
+		{"major", m.incrementMajor},
+		{"minor", m.incrementMinor},
+		{"bump", m.incrementFix},
+	}
+	incremented := false
+	for _, inc := range increments {
+		for i := 0; i < flags.Count(inc.flag); i++ {
+			if err := inc.increment(); err != nil {
+				return err
 			}
-			output.Info("Final version: %s", version)
-			return nil
+			incremented = true
+		}
+	}
+
+	if incremented {
+		// Show final version
+		version, err := m.getVersion()
+		if err != nil {
+			return fmt.Errorf("failed to get final version: %w", err)
 		}
+		output.Info("Final version: %s", version)
+		return nil
+	}
+
+	// Show current version
+	version, err := m.getVersion()
+	if err != nil {
+		return fmt.Errorf("failed to get version: %w", err)
 	}
 
-	return m.showVersionUsage()
+	// Show version components
+	major, _ := m.git.GetConfig("at.major")
+	minor, _ := m.git.GetConfig("at.minor")
+	fix, _ := m.git.GetConfig("at.fix")
+
+	if output.JSONEnabled() {
+		return output.JSON(map[string]string{
+			"version": version,
+			"tag":     "v" + version,
+			"major":   major,
+			"minor":   minor,
+			"fix":     fix,
+		})
+	}
+
+	output.Title("📦 GitAT Version")
+	output.Info("Current version: %s", version)
+
+	output.Table(
+		[]string{"Component", "Value"},
+		[][]string{
+			{"Major", major},
+			{"Minor", minor},
+			{"Fix", fix},
+		},
+	)
+
+	return nil
 }
 
 func (m *Manager) getVersion() (string, error) {
@@ -2860,6 +3100,9 @@ func (m *Manager) resetVersion() error {
 	// Show warning and confirmation
 	output.Warning("⚠️  This will reset version from %s to 0.0.0", currentVersion)
 	output.Info("This action cannot be undone!")
+	if err := m.requireTerminal("Resetting the version"); err != nil {
+		return err
+	}
 
 	// Use huh for confirmation
 	var confirmed bool
@@ -2916,6 +3159,9 @@ func (m *Manager) setVersion() error {
 
 	output.Title("🎯 Set Version")
 	output.Info("Current version: %s", currentVersion)
+	if err := m.requireTerminal("Setting the version"); err != nil {
+		return err
+	}
 
 	// Create form fields for version components
 	var major, minor, fix string
@@ -3159,48 +3405,44 @@ func (m *Manager) incrementFix() error {
 	return nil
 }
 
-func (m *Manager) showVersionUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ version [options]
-
-DESCRIPTION:
-  Manage semantic versioning for your project.
-  Uses MAJOR.MINOR.FIX format (e.g., 1.2.3).
-
-OPTIONS:
-  (no options)           Show current version
-  -M, --major           Increment major version (resets minor and fix to 0)
-  -m, --minor           Increment minor version (resets fix to 0)
-  -b, --bump            Increment fix version
-  -t, --tag             Show version tag (e.g., "v1.2.3")
-  --set                 Set version interactively (opens form)
-  --reset               Reset version to 0.0.0 (requires confirmation)
-  -h, --help            Show this help
-
-EXAMPLES:
-  git @ version                    # Show current version (e.g., "1.2.3")
-  git @ version -M                 # Increment major: 1.2.3 → 2.0.0
-  git @ version -m                 # Increment minor: 1.2.3 → 1.3.0
-  git @ version -b                 # Increment fix: 1.2.3 → 1.2.4
-  git @ version -Mmb               # Combined: major, minor, fix: 1.2.3 → 2.1.1
-  git @ version -m -b              # Multiple flags: minor, fix: 1.2.3 → 1.3.1
-  git @ version -t                 # Show version tag (e.g., "v1.2.3")
-  git @ version --set              # Set version interactively
-  git @ version --reset            # Reset to 0.0.0 (with confirmation)
-
-STORAGE:
-  Major version: git config at.major
-  Minor version: git config at.minor
-  Fix version: git config at.fix
-
-SEMANTIC VERSIONING:
-  MAJOR: Breaking changes, incompatible API changes
-  MINOR: New features, backward compatible
-  FIX: Bug fixes, backward compatible
-
-SECURITY:
-  All version operations are logged to .git/gitat-logs/version-changes.log for audit purposes.
-`)
-	return nil
+func versionCommand() *Command {
+	return &Command{
+		Name:    "version",
+		Summary: "Semantic versioning management",
+		Usage:   []string{"[options]"},
+		Description: `Manage semantic versioning for your project.
+Uses MAJOR.MINOR.FIX format (e.g., 1.2.3).
+Without options, shows the current version.`,
+		Flags: []Flag{
+			{Short: "M", Long: "major", Help: "Increment major version (resets minor and fix to 0)"},
+			{Short: "m", Long: "minor", Help: "Increment minor version (resets fix to 0)"},
+			{Short: "b", Long: "bump", Help: "Increment fix version"},
+			{Short: "t", Long: "tag", Help: "Show version tag (e.g., \"v1.2.3\")"},
+			{Long: "set", Help: "Set version interactively (opens form)"},
+			{Long: "reset", Help: "Reset version to 0.0.0 (requires confirmation)"},
+		},
+		Examples: []Example{
+			{"git @ version", "Show current version (e.g., \"1.2.3\")"},
+			{"git @ version -M", "Increment major: 1.2.3 → 2.0.0"},
+			{"git @ version -m", "Increment minor: 1.2.3 → 1.3.0"},
+			{"git @ version -b", "Increment fix: 1.2.3 → 1.2.4"},
+			{"git @ version -Mmb", "Combined: major, minor, fix: 1.2.3 → 2.1.1"},
+			{"git @ version -m -b", "Multiple flags: minor, fix: 1.2.3 → 1.3.1"},
+			{"git @ version -t", "Show version tag (e.g., \"v1.2.3\")"},
+			{"git @ version --set", "Set version interactively"},
+			{"git @ version --reset", "Reset to 0.0.0 (with confirmation)"},
+		},
+		Sections: []Section{
+			{"STORAGE", `Major version: git config at.major
+Minor version: git config at.minor
+Fix version: git config at.fix`},
+			{"SEMANTIC VERSIONING", `MAJOR: Breaking changes, incompatible API changes
+MINOR: New features, backward compatible
+FIX: Bug fixes, backward compatible`},
+			{"SECURITY", `All version operations are logged to .git/gitat-logs/version-changes.log for audit purposes.`},
+		},
+		Run: (*Manager).Version,
+	}
 }
 
 // Release handles the release command
@@ -3208,7 +3450,7 @@ func (m *Manager) Release(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showReleaseUsage()
+			return m.help("release")
 		}
 	}
 
@@ -3217,27 +3459,25 @@ func (m *Manager) Release(args []string) error {
 	return nil
 }
 
-func (m *Manager) showReleaseUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ release [options]
-
-DESCRIPTION:
-  Create releases with proper tagging and version management.
-  Automatically creates version tags and release notes.
-
-OPTIONS:
-  -h, --help           Show this help message
-
-EXAMPLES:
-  git @ release                    # Create release with current version
-  git @ release --help             # Show this help
-
-FEATURES:
-  - Automatic version tagging
-  - Release note generation
-  - Changelog creation
-  - Semantic versioning support
-`)
-	return nil
+func releaseCommand() *Command {
+	return &Command{
+		Name:    "release",
+		Summary: "Create releases with proper tagging",
+		Usage:   []string{"[options]"},
+		Description: `Create releases with proper tagging and version management.
+Automatically creates version tags and release notes.`,
+		Examples: []Example{
+			{"git @ release", "Create release with current version"},
+			{"git @ release --help", "Show this help"},
+		},
+		Sections: []Section{
+			{"FEATURES", `- Automatic version tagging
+- Release note generation
+- Changelog creation
+- Semantic versioning support`},
+		},
+		Run: (*Manager).Release,
+	}
 }
 
 // Master handles the master command
@@ -3245,14 +3485,14 @@ func (m *Manager) Master(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showMasterUsage()
+			return m.help("master")
 		}
 	}
 
 	// Get current branch
 	currentBranch, err := m.git.GetCurrentBranch()
 	if err != nil {
-		return fmt.Errorf("error: Not on a branch (detached HEAD state)")
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
 	}
 
 	// Get trunk branch
@@ -3276,23 +3516,33 @@ func (m *Manager) Master(args []string) error {
 	if hasUncommitted || hasStaged {
 		fmt.Println("Warning: You have uncommitted changes")
 		fmt.Println("Stashing changes before switching to trunk branch...")
+	}
 
-		_, err = m.git.Run("stash", "push", "-m", fmt.Sprintf("Auto-stash before switching to %s", trunkBranch))
-		if err != nil {
-			return fmt.Errorf("failed to stash changes: %w", err)
-		}
+	// Changes are restored when switching back with git @ switch
+	stashed, err := m.autostash(currentBranch)
+	if err != nil {
+		return err
 	}
 
 	// Switch to trunk branch
 	fmt.Printf("Switching to %s branch...\n", trunkBranch)
 	_, err = m.git.Run("checkout", trunkBranch)
 	if err != nil {
+		if stashed {
+			m.restoreAutostash(currentBranch)
+		}
 		return fmt.Errorf("failed to switch to %s branch: %w", trunkBranch, err)
 	}
 
+	m.recordBranchSwitch("master", currentBranch, trunkBranch)
+
+	if err := m.restoreAutostash(trunkBranch); err != nil {
+		return err
+	}
+
 	// Pull latest changes
 	fmt.Println("Pulling latest changes...")
-	_, err = m.git.Run("pull", "origin", trunkBranch)
+	err = m.git.Stream("pull", "origin", trunkBranch)
 	if err != nil {
 		fmt.Printf("Warning: Failed to pull latest changes: %v\n", err)
 	}
@@ -3301,34 +3551,32 @@ func (m *Manager) Master(args []string) error {
 	return nil
 }
 
-func (m *Manager) showMasterUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ master [options]
-
-DESCRIPTION:
-  Switch to the trunk branch (master/main) with automatic stash management.
-  Safely switches to the configured trunk branch, stashing any uncommitted changes.
-
-OPTIONS:
-  -h, --help           Show this help message
-
-EXAMPLES:
-  git @ master                    # Switch to trunk branch
-  git @ master --help             # Show this help
-
-FEATURES:
-  - Automatic stash of uncommitted changes
-  - Pulls latest changes from remote
-  - Uses configured trunk branch (at.trunk)
-  - Safe branch switching
-  - Status feedback
-
-WORKFLOW:
-  1. Stashes uncommitted changes (if any)
-  2. Switches to trunk branch
-  3. Pulls latest changes from remote
-  4. Provides status feedback
-`)
-	return nil
+func masterCommand() *Command {
+	return &Command{
+		Name:    "master",
+		Aliases: []string{"root"},
+		Summary: "Switch to trunk branches",
+		Usage:   []string{"[options]"},
+		Description: `Switch to the trunk branch (master/main) with automatic stash management.
+Safely switches to the configured trunk branch, stashing any uncommitted changes.`,
+		Examples: []Example{
+			{"git @ master", "Switch to trunk branch"},
+			{"git @ master --help", "Show this help"},
+		},
+		Sections: []Section{
+			{"FEATURES", `- Automatic stash of uncommitted changes, restored by 'git @ switch'
+  when returning to the branch
+- Pulls latest changes from remote
+- Uses configured trunk branch (at.trunk)
+- Safe branch switching
+- Status feedback`},
+			{"WORKFLOW", `1. Stashes uncommitted changes (if any)
+2. Switches to trunk branch
+3. Pulls latest changes from remote
+4. Provides status feedback`},
+		},
+		Run: (*Manager).Master,
+	}
 }
 
 // Changes handles the changes command
@@ -3336,8 +3584,16 @@ func (m *Manager) Changes(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showChangesUsage()
+			return m.help("changes")
+		}
+	}
+
+	if output.JSONEnabled() {
+		changes, err := m.workingTreeChanges()
+		if err != nil {
+			return fmt.Errorf("failed to get changes: %w", err)
 		}
+		return output.JSON(changes)
 	}
 
 	// Show uncommitted changes
@@ -3355,23 +3611,21 @@ func (m *Manager) Changes(args []string) error {
 	return nil
 }
 
-func (m *Manager) showChangesUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ changes
-
-DESCRIPTION:
-  Show uncommitted changes in the working directory.
-  Lists files that have been modified but not yet committed.
-
-EXAMPLES:
-  git @ changes                    # Show modified files
-
-OUTPUT:
-  Lists file names that have been changed since last commit.
-
-SECURITY:
-  All change operations are validated and logged.
-`)
-	return nil
+func changesCommand() *Command {
+	return &Command{
+		Name:    "changes",
+		Summary: "View uncommitted changes",
+		Description: `Show uncommitted changes in the working directory.
+Lists files that have been modified but not yet committed.`,
+		Examples: []Example{
+			{"git @ changes", "Show modified files"},
+		},
+		Sections: []Section{
+			{"OUTPUT", `Lists file names that have been changed since last commit.`},
+			{"SECURITY", `All change operations are validated and logged.`},
+		},
+		Run: (*Manager).Changes,
+	}
 }
 
 // Logs handles the logs command
@@ -3379,8 +3633,16 @@ func (m *Manager) Logs(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showLogsUsage()
+			return m.help("logs")
+		}
+	}
+
+	if output.JSONEnabled() {
+		commits, err := m.recentCommits("HEAD", 10)
+		if err != nil {
+			return fmt.Errorf("failed to get logs: %w", err)
 		}
+		return output.JSON(map[string][]CommitInfo{"commits": commits})
 	}
 
 	// Show recent commit history
@@ -3393,113 +3655,136 @@ func (m *Manager) Logs(args []string) error {
 	return nil
 }
 
-func (m *Manager) showLogsUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ logs
-
-DESCRIPTION:
-  Show recent commit history in a compact format.
-  Displays the last 10 commits with abbreviated commit hashes.
-
-EXAMPLES:
-  git @ logs                    # Show recent commits
-
-OUTPUT:
-  Shows commit hash, author, date, and message for recent commits.
-
-SECURITY:
-  All log operations are validated and logged.
-`)
-	return nil
+func logsCommand() *Command {
+	return &Command{
+		Name:    "logs",
+		Summary: "View commit history",
+		Description: `Show recent commit history in a compact format.
+Displays the last 10 commits with abbreviated commit hashes.`,
+		Examples: []Example{
+			{"git @ logs", "Show recent commits"},
+		},
+		Sections: []Section{
+			{"OUTPUT", `Shows commit hash, author, date, and message for recent commits.`},
+			{"SECURITY", `All log operations are validated and logged.`},
+		},
+		Run: (*Manager).Logs,
+	}
 }
 
 // WIP handles the wip command
 func (m *Manager) WIP(args []string) error {
-	if len(args) == 0 {
-		return m.showWIP()
+	command := wipCommand()
+	if len(args) > 0 {
+		// Snapshot subcommands
+		if sub := command.Subcommand(args[0]); sub != nil {
+			return sub.Run(m, args[1:])
+		}
 	}
 
-	if len(args) >= 1 {
-		// Handle help first
-		if args[0] == "-h" || args[0] == "--help" || args[0] == "help" || args[0] == "h" {
-			return m.showWIPUsage()
-		}
+	flags, err := command.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("wip")
+	}
 
-		// Parse flags for combined operations
-		var operations []string
-
-		// Parse all arguments
-		for _, arg := range args {
-			// Handle combined flags like -sc
-			if strings.HasPrefix(arg, "-") && len(arg) > 1 {
-				// Parse each character in the flag
-				for i := 1; i < len(arg); i++ {
-					switch arg[i] {
-					case 's':
-						operations = append(operations, "set")
-					case 'c':
-						operations = append(operations, "checkout")
-					case 'r':
-						operations = append(operations, "restore")
-					}
-				}
-			} else {
-				// Handle individual flags
-				switch arg {
-				case "-s", "--set", "s", "set", ".":
-					operations = append(operations, "set")
-				case "-c", "--checkout", "c", "checkout":
-					operations = append(operations, "checkout")
-				case "-r", "--restore", "r", "restore":
-					operations = append(operations, "restore")
-				}
-			}
-		}
+	// "." is short for --set
+	message := flags.Args()
+	operation := flags.First("set", "checkout", "restore")
+	if operation == "" && len(message) > 0 && message[0] == "." {
+		operation, message = "set", message[1:]
+	}
 
-		// Execute operations (only the first one, as these are mutually exclusive)
-		if len(operations) > 0 {
-			switch operations[0] {
-			case "set":
-				return m.setWIP()
-			case "checkout":
-				return m.checkoutWIP()
-			case "restore":
-				return m.restoreWIP()
-			}
-		}
+	// Only the first operation runs, as they are mutually exclusive
+	switch operation {
+	case "set":
+		return m.snapshotWIP(strings.Join(message, " "))
+	case "checkout":
+		return m.checkoutWIP()
+	case "restore":
+		return m.restoreWIP()
 	}
 
-	return m.showWIPUsage()
+	if len(message) > 0 {
+		return m.help("wip")
+	}
+	return m.showWIP()
 }
 
 func (m *Manager) showWIP() error {
 	wipBranch, err := m.git.GetConfig("at.wip")
+	if output.JSONEnabled() {
+		var snapshots int
+		if wipBranch != "" {
+			list, _ := m.wipSnapshots(wipBranch)
+			snapshots = len(list)
+		}
+		return output.JSON(map[string]interface{}{"wip_branch": wipBranch, "snapshots": snapshots})
+	}
 	if err != nil || wipBranch == "" {
 		fmt.Println("No WIP branch configured")
 		return nil
 	}
-	fmt.Println(wipBranch)
+
+	snapshots, _ := m.wipSnapshots(wipBranch)
+	if len(snapshots) == 0 {
+		fmt.Println(wipBranch)
+		return nil
+	}
+	fmt.Printf("%s (%d snapshots)\n", wipBranch, len(snapshots))
 	return nil
 }
 
+// wipBranchOrPick returns the WIP branch. When none is configured, it asks
+// for one with the picker and configures it.
+func (m *Manager) wipBranchOrPick() (string, error) {
+	if wipBranch, err := m.git.GetConfig("at.wip"); err == nil && wipBranch != "" {
+		return wipBranch, nil
+	}
+
+	choice, ok, err := m.pickBranch("WIP branch", "", nil)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("error: No WIP branch configured")
+	}
+	if err := m.git.SetConfig("at.wip", choice.Local); err != nil {
+		return "", fmt.Errorf("failed to set WIP branch: %w", err)
+	}
+	return choice.Local, nil
+}
+
 func (m *Manager) checkoutWIP() error {
-	wipBranch, err := m.git.GetConfig("at.wip")
-	if err != nil || wipBranch == "" {
-		return fmt.Errorf("error: No WIP branch configured")
+	wipBranch, err := m.wipBranchOrPick()
+	if err != nil {
+		return err
 	}
 
+	previousBranch, _ := m.git.GetCurrentBranch()
 	_, err = m.git.Run("checkout", wipBranch)
 	if err != nil {
 		return fmt.Errorf("failed to checkout WIP branch: %w", err)
 	}
+	m.recordBranchSwitch("wip", previousBranch, wipBranch)
 
 	fmt.Printf("Switched to WIP branch: %s\n", wipBranch)
 	return nil
 }
 
 func (m *Manager) restoreWIP() error {
-	wipBranch, err := m.git.GetConfig("at.wip")
-	if err != nil || wipBranch == "" {
-		return fmt.Errorf("error: No WIP branch configured")
+	wipBranch, err := m.wipBranchOrPick()
+	if err != nil {
+		return err
+	}
+
+	currentBranch, _ := m.git.GetCurrentBranch()
+	if currentBranch != wipBranch {
+		if err := m.checkoutWIP(); err != nil {
+			return err
+		}
 	}
 
 	// Set working branch to WIP branch
@@ -3508,64 +3793,134 @@ func (m *Manager) restoreWIP() error {
 		return fmt.Errorf("failed to set working branch: %w", err)
 	}
 
-	// Create work branch
-	err = m.newWorkingBranch()
+	// Bring back the latest snapshot, if any
+	snapshots, err := m.wipSnapshots(wipBranch)
 	if err != nil {
-		return fmt.Errorf("failed to create work branch: %w", err)
+		return err
+	}
+	if len(snapshots) > 0 {
+		if err := m.applyWIPSnapshot(wipBranch, "", false); err != nil {
+			return err
+		}
 	}
 
 	fmt.Printf("Restored WIP branch: %s\n", wipBranch)
 	return nil
 }
 
-func (m *Manager) showWIPUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ wip [options]
-
-DESCRIPTION:
-  Manage Work-In-Progress branch state.
-  Tracks which branch you were working on for quick context switching.
-
-OPTIONS:
-  (no options)           Show current WIP branch
-  -s, --set              Set current branch as WIP
-  -c, --checkout         Checkout WIP branch
-  -r, --restore          Restore WIP to working branch
-  -h, --help             Show this help
-
-EXAMPLES:
-  git @ wip                    # Show current WIP branch
-  git @ wip -s                 # Set current branch as WIP
-  git @ wip -c                 # Checkout WIP branch
-  git @ wip -r                 # Restore WIP to working branch
-  git @ wip -sc                # Combined flags (first operation takes precedence)
-
-WORKFLOW:
-  Use WIP to quickly switch between different features you're working on.
-  Set WIP when you need to context switch to another task.
-
-STORAGE:
-  Saved in git config: at.wip
-
-SECURITY:
-  All WIP operations are validated and logged.
-`)
-	return nil
+func wipCommand() *Command {
+	return &Command{
+		Name:    "wip",
+		Summary: "Work in progress management",
+		Usage:   []string{"[options] [<message>]", "list [--all]", "show [<n>]", "apply [<n>] [--index]", "drop [<n>|--all]", "push [<remote>] [--all]", "fetch [<remote>]"},
+		Description: `Manage Work-In-Progress state. Tracks which branch you were working on and
+saves snapshots of its uncommitted work for quick context switching.
+
+A snapshot records the index, working tree and untracked files as a
+stash-shaped commit under refs/gitat/wip/<branch>/<n>, without touching
+HEAD or your files. Snapshots are per branch, numbered and pushable.
+
+Without options, shows the current WIP branch.`,
+		Flags: []Flag{
+			{Short: "s", Long: "set", Help: "Set current branch as WIP and snapshot its changes, with an optional message"},
+			{Short: "c", Long: "checkout", Help: "Checkout WIP branch (picked from a list when none is set)"},
+			{Short: "r", Long: "restore", Help: "Checkout WIP branch and apply its latest snapshot (picked from a list when none is set)"},
+		},
+		Subcommands: []*Command{
+			{
+				Name: "list", Aliases: []string{"ls"}, Hint: "[--all]",
+				Summary: "List snapshots of the current branch (or all branches)",
+				Flags:   []Flag{{Short: "a", Long: "all", Help: "List the snapshots of every branch"}},
+				Run:     (*Manager).listWIP,
+			},
+			{
+				Name: "show", Hint: "[<n>]",
+				Summary: "Show the changes of a snapshot (default: latest)",
+				Run:     (*Manager).showWIPSnapshot,
+			},
+			{
+				Name: "apply", Hint: "[<n>]",
+				Summary: "Apply a snapshot (--index also restores staged changes)",
+				Flags:   []Flag{{Long: "index", Help: "Also restore staged changes"}},
+				Run:     (*Manager).applyWIP,
+			},
+			{
+				Name: "drop", Hint: "[<n>|--all]",
+				Summary: "Delete a snapshot (default: latest) or all of them",
+				Flags:   []Flag{{Short: "a", Long: "all", Help: "Delete every snapshot of the branch"}},
+				Run:     (*Manager).dropWIP,
+			},
+			{
+				Name: "push", Hint: "[<remote>]",
+				Summary: "Push snapshots of the current branch (--all: every branch)",
+				Args:    []Arg{{Name: "<remote>", Source: SourceRemotes}},
+				Flags:   []Flag{{Short: "a", Long: "all", Help: "Push the snapshots of every branch"}},
+				Run:     (*Manager).pushWIP,
+			},
+			{
+				Name: "fetch", Hint: "[<remote>]",
+				Summary: "Fetch all snapshots from a remote",
+				Args:    []Arg{{Name: "<remote>", Source: SourceRemotes}},
+				Run:     (*Manager).fetchWIP,
+			},
+		},
+		Examples: []Example{
+			{"git @ wip", "Show current WIP branch"},
+			{"git @ wip -s", "Set current branch as WIP and snapshot it"},
+			{"git @ wip -s \"before rebase\"", "Snapshot with a message"},
+			{"git @ wip list", "List snapshots of this branch"},
+			{"git @ wip apply 2", "Apply snapshot 2"},
+			{"git @ wip drop --all", "Delete all snapshots of this branch"},
+			{"git @ wip -c", "Checkout WIP branch"},
+			{"git @ wip -r", "Restore WIP branch and its latest snapshot"},
+			{"git @ wip -sc", "Combined flags (first operation takes precedence)"},
+		},
+		Sections: []Section{
+			{"WORKFLOW", `Use WIP to quickly switch between different features you're working on.
+Set WIP when you need to context switch to another task.`},
+			{"STORAGE", `WIP branch saved in git config: at.wip
+Snapshots saved as refs:        refs/gitat/wip/<branch>/<n>`},
+			{"SECURITY", `All WIP operations are validated and logged.`},
+		},
+		Run: (*Manager).WIP,
+	}
 }
 
 // Label handles the _label command
 func (m *Manager) Label(args []string) error {
-	if len(args) == 1 {
-		switch args[0] {
-		case "-h", "--help", "help", "h":
-			return m.showLabelUsage()
+	cmd := command("_label")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("_label")
+	}
+	if err := cmd.extraArgs(flags, 1); err != nil {
+		return err
+	}
+
+	// Only the first operation runs, as they are mutually exclusive
+	switch flags.First("reset", "template") {
+	case "reset":
+		return m.resetLabel()
+	case "template":
+		template := m.labelTemplate()
+		if output.JSONEnabled() {
+			return output.JSON(map[string]string{"template": template})
 		}
+		fmt.Println(template)
+		return nil
 	}
 
-	if len(args) == 0 {
+	if len(flags.Args()) == 0 {
 		label, err := m.showLabel()
 		if err != nil {
 			return err
 		}
+		if output.JSONEnabled() {
+			return output.JSON(map[string]string{"label": label, "template": m.labelTemplate()})
+		}
 		if label == "" {
 			fmt.Println("[Update]")
 		} else {
@@ -3574,15 +3929,24 @@ func (m *Manager) Label(args []string) error {
 		return nil
 	}
 
-	if len(args) == 1 {
-		return m.setLabel(args[0])
-	}
+	return m.setLabel(flags.Args()[0])
+}
 
-	return m.showLabelUsage()
+// labelTemplate returns the configured label template or the default
+func (m *Manager) labelTemplate() string {
+	template, _ := m.git.GetConfig("at.label")
+	if template == "" {
+		return defaultLabelTemplate
+	}
+	return template
 }
 
-func (m *Manager) setLabel(label string) error {
-	_, err := m.git.Run("config", "--replace-all", "at.label", label)
+func (m *Manager) setLabel(template string) error {
+	if err := label.Validate(template, labelPlaceholders); err != nil {
+		return errs.Wrap(errs.Usage, err, "Invalid label template")
+	}
+
+	_, err := m.git.Run("config", "--replace-all", "at.label", template)
 	if err != nil {
 		return fmt.Errorf("failed to set label: %w", err)
 	}
@@ -3596,70 +3960,101 @@ func (m *Manager) setLabel(label string) error {
 	return nil
 }
 
-func (m *Manager) showLabelUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ _label [<custom-label>]
-
-DESCRIPTION:
-  Manage commit labels for GitAT workflow.
-  Labels are used in commit messages and help track project context.
-
-EXAMPLES:
-  git @ _label                    # Show formatted label [product.feature.issue]
-  git @ _label "Custom label"     # Set custom label
-
-DEFAULT FORMAT:
-  [product.feature.issue]
-  Example: [gitAT.user-auth.PROJ-123]
-
-STORAGE:
-  Saved in git config: at.label
+func (m *Manager) resetLabel() error {
+	if _, err := m.git.Run("config", "--unset-all", "at.label"); err != nil {
+		return fmt.Errorf("error: No custom label template is set")
+	}
 
-SECURITY:
-  All label operations are validated and logged.
-`)
+	newLabel, _ := m.showLabel()
+	fmt.Printf("Label reset to the default: %s\n", newLabel)
 	return nil
 }
 
+func labelCommand() *Command {
+	return &Command{
+		Name:    "_label",
+		Summary: "Generate commit labels",
+		Usage:   []string{"[<template>]", "--template | --reset"},
+		Description: `Manage commit labels for GitAT workflow.
+Labels are used in commit messages and help track project context.
+The label is rendered from a template by 'git @ save', 'git @ pr' and
+'git @ _label'.`,
+		Flags: []Flag{
+			{Short: "t", Long: "template", Help: "Show the label template"},
+			{Short: "r", Long: "reset", Help: "Go back to the default template"},
+		},
+		Examples: []Example{
+			{"git @ _label", "Show the rendered label"},
+			{"git @ _label \"{?[{issue}] }{type|upper}\"", "e.g. [PROJ-123] FEATURE"},
+			{"git @ _label \"{?({product})}{?[{issue}]}\"", "Sections drop when empty"},
+			{"git @ _label \"Custom label\"", "Fixed label"},
+		},
+		Sections: []Section{
+			{"PLACEHOLDERS", `{product} {feature} {issue}   Context of the current branch ({task} = {issue})
+{context}                     Non-empty product.feature.issue values
+{type} {branch}               Work type and name of the current branch
+{version} {major} {minor} {fix}
+{user} {date}                 git user.name, today (YYYY-MM-DD)
+{name|upper} {name|lower}     Change the case of a value
+{?...}                        Optional section, dropped when a value in it is empty
+{{ }}                         Literal braces`},
+			{"DEFAULT FORMAT", `{?[{context}]}
+Example: [gitAT.user-auth.PROJ-123]
+Unset parts are left out, e.g. [PROJ-123]. The issue is inferred from
+the current branch (see git @ issue).`},
+			{"RELATED TEMPLATES", `git config at.id '{product}:{version}'     # git @ _id (default {product}:{major}{minor}{fix})
+git config at.pr.title '{?[{issue}] }{subject}'  # Default PR title, {subject} is the last commit`},
+			{"STORAGE", `Saved in git config: at.label`},
+			{"SECURITY", `All label operations are validated and logged.`},
+		},
+		Run: (*Manager).Label,
+	}
+}
+
 // ID handles the _id command
 func (m *Manager) ID(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showIDUsage()
+			return m.help("_id")
 		}
 	}
 
-	product, _ := m.git.GetConfig("at.product")
-	major, _ := m.git.GetConfig("at.major")
-	minor, _ := m.git.GetConfig("at.minor")
-	fix, _ := m.git.GetConfig("at.fix")
-
-	id := fmt.Sprintf("%s:%s%s%s", product, major, minor, fix)
+	id, err := m.renderTemplate("at.id", defaultIDTemplate, nil)
+	if err != nil {
+		return errs.Wrap(errs.Usage, err, "Cannot render the id")
+	}
+	if output.JSONEnabled() {
+		template, _ := m.git.GetConfig("at.id")
+		if template == "" {
+			template = defaultIDTemplate
+		}
+		return output.JSON(map[string]string{"id": id, "template": template})
+	}
 	fmt.Println(id)
 	return nil
 }
 
-func (m *Manager) showIDUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ _id
-
-DESCRIPTION:
-  Generate a unique identifier for the current product state.
-  Creates an ID based on product name and version.
-
-FORMAT:
-  product:major.minor.fix
-  Example: gitAT:1.2.3
-
-EXAMPLES:
-  git @ _id                    # Show project ID
-
-OUTPUT:
-  Unique identifier combining product name and version.
-
-SECURITY:
-  All ID operations are validated and logged.
-`)
-	return nil
+func idCommand() *Command {
+	return &Command{
+		Name:    "_id",
+		Summary: "Generate unique project identifiers",
+		Description: `Generate a unique identifier for the current product state.
+Creates an ID based on product name and version.`,
+		Examples: []Example{
+			{"git @ _id", "Show project ID"},
+		},
+		Sections: []Section{
+			{"FORMAT", `{product}:{major}{minor}{fix}
+Example: gitAT:123
+
+Set another template with: git config at.id '{product}:{version}'
+(placeholders as in git @ _label --help)`},
+			{"OUTPUT", `Unique identifier combining product name and version.`},
+			{"SECURITY", `All ID operations are validated and logged.`},
+		},
+		Run: (*Manager).ID,
+	}
 }
 
 // Path handles the _path command
@@ -3667,36 +4062,37 @@ func (m *Manager) Path(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showPathUsage()
+			return m.help("_path")
 		}
 	}
 
-	output, err := m.git.Run("rev-parse", "--show-toplevel")
+	path, err := m.git.Run("rev-parse", "--show-toplevel")
 	if err != nil {
 		return fmt.Errorf("failed to get repository path: %w", err)
 	}
 
-	fmt.Print(output)
+	if output.JSONEnabled() {
+		return output.JSON(map[string]string{"path": strings.TrimSpace(path)})
+	}
+	fmt.Print(path)
 	return nil
 }
 
-func (m *Manager) showPathUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ _path
-
-DESCRIPTION:
-  Get the git repository root path.
-  Returns the absolute path to the root of the current git repository.
-
-EXAMPLES:
-  git @ _path                    # Show repository root path
-
-OUTPUT:
-  Absolute path to the git repository root directory.
-
-SECURITY:
-  All path operations are validated and logged.
-`)
-	return nil
+func pathCommand() *Command {
+	return &Command{
+		Name:    "_path",
+		Summary: "Get repository path",
+		Description: `Get the git repository root path.
+Returns the absolute path to the root of the current git repository.`,
+		Examples: []Example{
+			{"git @ _path", "Show repository root path"},
+		},
+		Sections: []Section{
+			{"OUTPUT", `Absolute path to the git repository root directory.`},
+			{"SECURITY", `All path operations are validated and logged.`},
+		},
+		Run: (*Manager).Path,
+	}
 }
 
 // Trunk handles the _trunk command
@@ -3704,7 +4100,7 @@ func (m *Manager) Trunk(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showTrunkUsage()
+			return m.help("_trunk")
 		}
 	}
 
@@ -3716,19 +4112,19 @@ func (m *Manager) Trunk(args []string) error {
 		return m.setTrunk(args[0])
 	}
 
-	return m.showTrunkUsage()
+	return m.help("_trunk")
 }
 
 func (m *Manager) showTrunk() error {
 	current, err := m.git.GetConfig("at.trunk")
 	if err != nil || current == "" {
 		// Auto-detect trunk branch from remote HEAD
-		output, err := m.git.Run("branch", "-rl", "*/HEAD")
+		heads, err := m.git.Run("branch", "-rl", "*/HEAD")
 		if err != nil {
 			current = "develop"
 		} else {
 			// Parse the output to get the branch name
-			lines := strings.Split(strings.TrimSpace(output), "\n")
+			lines := strings.Split(strings.TrimSpace(heads), "\n")
 			if len(lines) > 0 {
 				parts := strings.Split(strings.TrimSpace(lines[0]), "/")
 				if len(parts) > 0 {
@@ -3745,7 +4141,10 @@ func (m *Manager) showTrunk() error {
 		if err != nil {
 			return fmt.Errorf("failed to set trunk branch: %w", err)
 		}
-		fmt.Printf("Auto-detected trunk branch: %s\n", current)
+		output.Info("Auto-detected trunk branch: %s", current)
+	}
+	if output.JSONEnabled() {
+		return output.JSON(map[string]string{"trunk": current})
 	}
 	fmt.Println(current)
 	return nil
@@ -3758,44 +4157,45 @@ func (m *Manager) setTrunk(branchName string) error {
 		return fmt.Errorf("failed to set trunk branch: %w", err)
 	}
 
+	if output.JSONEnabled() {
+		return output.JSON(map[string]string{"trunk": branchName, "previous": from})
+	}
 	fmt.Printf("Base branch updated to: %s from %s\n", branchName, from)
 	return nil
 }
 
-func (m *Manager) showTrunkUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ _trunk [<branch-name>]
-
-DESCRIPTION:
-  Manage the base/trunk branch (usually develop or master).
-  The trunk branch is used as the base for feature branches.
-
-EXAMPLES:
-  git @ _trunk                    # Show current trunk branch
-  git @ _trunk develop            # Set trunk to "develop"
-  git @ _trunk master             # Set trunk to "master"
-
-AUTO-DETECTION:
-  If no trunk is set, automatically detects from remote HEAD.
-
-STORAGE:
-  Saved in git config: at.trunk
-
-SECURITY:
-  All trunk operations are validated and logged.
-`)
-	return nil
+func trunkCommand() *Command {
+	return &Command{
+		Name:    "_trunk",
+		Summary: "Manage trunk branch configuration",
+		Usage:   []string{"[<branch-name>]"},
+		Args:    []Arg{{Name: "<branch-name>", Source: SourceBranches}},
+		Description: `Manage the base/trunk branch (usually develop or master).
+The trunk branch is used as the base for feature branches.`,
+		Examples: []Example{
+			{"git @ _trunk", "Show current trunk branch"},
+			{"git @ _trunk develop", "Set trunk to \"develop\""},
+			{"git @ _trunk master", "Set trunk to \"master\""},
+		},
+		Sections: []Section{
+			{"AUTO-DETECTION", `If no trunk is set, automatically detects from remote HEAD.`},
+			{"STORAGE", `Saved in git config: at.trunk`},
+			{"SECURITY", `All trunk operations are validated and logged.`},
+		},
+		Run: (*Manager).Trunk,
+	}
 }
 
 // Ignore handles the ignore command
 func (m *Manager) Ignore(args []string) error {
 	if len(args) == 0 {
-		return m.showIgnoreUsage()
+		return m.help("ignore")
 	}
 
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showIgnoreUsage()
+			return m.help("ignore")
 		}
 	}
 
@@ -3803,7 +4203,7 @@ func (m *Manager) Ignore(args []string) error {
 		return m.addToGitignore(args[0])
 	}
 
-	return m.showIgnoreUsage()
+	return m.help("ignore")
 }
 
 func (m *Manager) addToGitignore(pattern string) error {
@@ -3830,46 +4230,38 @@ func (m *Manager) addToGitignore(pattern string) error {
 	}
 
 	// Add pattern to .gitignore
-	file, err := os.OpenFile(gitignorePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open .gitignore: %w", err)
-	}
-	defer file.Close()
-
-	_, err = file.WriteString(pattern + "\n")
-	if err != nil {
-		return fmt.Errorf("failed to write to .gitignore: %w", err)
+	if err := m.appendFile(gitignorePath, []byte(pattern+"\n")); err != nil {
+		return err
 	}
 
 	fmt.Printf("String %s appended to %s\n", pattern, gitignorePath)
 	return nil
 }
 
-func (m *Manager) showIgnoreUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ ignore <pattern>
-
-DESCRIPTION:
-  Add patterns to .gitignore file.
-  Checks if pattern already exists before adding.
-
-ARGUMENTS:
-  <pattern>    Pattern to add to .gitignore
-
-EXAMPLES:
-  git @ ignore "*.log"              # Ignore all log files
-  git @ ignore "node_modules/"      # Ignore node_modules directory
-  git @ ignore "build/"             # Ignore build directory
-  git @ ignore "*.tmp"              # Ignore temporary files
-
-PROCESS:
-  1. Checks if pattern already exists in .gitignore
-  2. Adds pattern if not present
-  3. Reports success or existing pattern
-
-SECURITY:
-  All ignore operations are validated and logged.
-`)
-	return nil
+func ignoreCommand() *Command {
+	return &Command{
+		Name:    "ignore",
+		Summary: "Add patterns to .gitignore",
+		Usage:   []string{"<pattern>"},
+		Description: `Add patterns to .gitignore file.
+Checks if pattern already exists before adding.`,
+		Args: []Arg{
+			{Name: "<pattern>", Help: "Pattern to add to .gitignore"},
+		},
+		Examples: []Example{
+			{"git @ ignore \"*.log\"", "Ignore all log files"},
+			{"git @ ignore \"node_modules/\"", "Ignore node_modules directory"},
+			{"git @ ignore \"build/\"", "Ignore build directory"},
+			{"git @ ignore \"*.tmp\"", "Ignore temporary files"},
+		},
+		Sections: []Section{
+			{"PROCESS", `1. Checks if pattern already exists in .gitignore
+2. Adds pattern if not present
+3. Reports success or existing pattern`},
+			{"SECURITY", `All ignore operations are validated and logged.`},
+		},
+		Run: (*Manager).Ignore,
+	}
 }
 
 // InitLocal handles the initlocal command
@@ -3877,19 +4269,19 @@ func (m *Manager) InitLocal(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showInitLocalUsage()
+			return m.help("initlocal")
 		}
 	}
 
 	if len(args) == 0 {
-		return m.showInitLocalUsage()
+		return m.help("initlocal")
 	}
 
 	if len(args) == 1 {
 		return m.initLocal(args[0])
 	}
 
-	return m.showInitLocalUsage()
+	return m.help("initlocal")
 }
 
 func (m *Manager) initLocal(originURL string) error {
@@ -3938,7 +4330,7 @@ func (m *Manager) initLocal(originURL string) error {
 	}
 
 	// Push to master
-	_, err = m.git.Run("push", "--set-upstream", "origin", "master")
+	err = m.git.Stream("push", "--set-upstream", "origin", "master")
 	if err != nil {
 		return fmt.Errorf("failed to push to master: %w", err)
 	}
@@ -3949,7 +4341,7 @@ func (m *Manager) initLocal(originURL string) error {
 		return fmt.Errorf("failed to create staging branch: %w", err)
 	}
 
-	_, err = m.git.Run("push", "--set-upstream", "origin", "staging")
+	err = m.git.Stream("push", "--set-upstream", "origin", "staging")
 	if err != nil {
 		return fmt.Errorf("failed to push staging branch: %w", err)
 	}
@@ -3960,7 +4352,7 @@ func (m *Manager) initLocal(originURL string) error {
 		return fmt.Errorf("failed to create develop branch: %w", err)
 	}
 
-	_, err = m.git.Run("push", "--set-upstream", "origin", "develop")
+	err = m.git.Stream("push", "--set-upstream", "origin", "develop")
 	if err != nil {
 		return fmt.Errorf("failed to push develop branch: %w", err)
 	}
@@ -3976,42 +4368,37 @@ func (m *Manager) initLocal(originURL string) error {
 	return nil
 }
 
-func (m *Manager) showInitLocalUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ initlocal <origin-url> <project-name>
-
-DESCRIPTION:
-  Initialize a new local repository with remote setup and proper branch structure.
-  Creates master → staging → develop branch hierarchy.
-
-ARGUMENTS:
-  <origin-url>      Remote repository URL (e.g., git@gitlab.com:user)
-  <project-name>    Project name for the repository
-
-EXAMPLES:
-  git @ initlocal git@gitlab.com:user my-project
-  git @ initlocal git@github.com:org api-service
-
-PROCESS:
-  1. Initializes git repository
-  2. Sets project name
-  3. Creates remote origin
-  4. Sets up branch structure: master → staging → develop
-  5. Pushes all branches to remote
-
-BRANCH STRUCTURE:
-  master   → Production-ready code
-  staging  → Pre-production testing
-  develop  → Development and feature integration
-
-NEXT STEPS:
-  After initialization, visit your repository settings and set the default
-  branch to 'develop':
-  https://gitlab.com/user/project-name/settings/repository
-
-SECURITY:
-  All initialization operations are validated and logged.
-`)
-	return nil
+func initLocalCommand() *Command {
+	return &Command{
+		Name:    "initlocal",
+		Summary: "Initialize local repository with branch structure",
+		Usage:   []string{"<origin-url> <project-name>"},
+		Description: `Initialize a new local repository with remote setup and proper branch structure.
+Creates master → staging → develop branch hierarchy.`,
+		Args: []Arg{
+			{Name: "<origin-url>", Help: "Remote repository URL (e.g., git@gitlab.com:user)"},
+			{Name: "<project-name>", Help: "Project name for the repository"},
+		},
+		Examples: []Example{
+			{"git @ initlocal git@gitlab.com:user my-project", ""},
+			{"git @ initlocal git@github.com:org api-service", ""},
+		},
+		Sections: []Section{
+			{"PROCESS", `1. Initializes git repository
+2. Sets project name
+3. Creates remote origin
+4. Sets up branch structure: master → staging → develop
+5. Pushes all branches to remote`},
+			{"BRANCH STRUCTURE", `master   → Production-ready code
+staging  → Pre-production testing
+develop  → Development and feature integration`},
+			{"NEXT STEPS", `After initialization, visit your repository settings and set the default
+branch to 'develop':
+https://gitlab.com/user/project-name/settings/repository`},
+			{"SECURITY", `All initialization operations are validated and logged.`},
+		},
+		Run: (*Manager).InitLocal,
+	}
 }
 
 // InitRemote handles the initremote command
@@ -4019,19 +4406,19 @@ func (m *Manager) InitRemote(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showInitRemoteUsage()
+			return m.help("initremote")
 		}
 	}
 
 	if len(args) == 0 {
-		return m.showInitRemoteUsage()
+		return m.help("initremote")
 	}
 
 	if len(args) == 1 {
 		return m.initRemote(args[0])
 	}
 
-	return m.showInitRemoteUsage()
+	return m.help("initremote")
 }
 
 func (m *Manager) initRemote(repoURL string) error {
@@ -4060,7 +4447,7 @@ func (m *Manager) initRemote(repoURL string) error {
 	}
 
 	// Push to master
-	_, err = m.git.Run("push", "--set-upstream", "origin", "master")
+	err = m.git.Stream("push", "--set-upstream", "origin", "master")
 	if err != nil {
 		return fmt.Errorf("failed to push to master: %w", err)
 	}
@@ -4073,7 +4460,7 @@ func (m *Manager) initRemote(repoURL string) error {
 
 	// Create CHANGELOG file
 	changelogContent := fmt.Sprintf("[%s]\r- CHANGELOG CREATED\r- INITIAL COMMIT\r\r", time.Now().Format("2006-01-02"))
-	err = os.WriteFile("CHANGELOG", []byte(changelogContent), 0644)
+	err = m.writeFile("CHANGELOG", []byte(changelogContent))
 	if err != nil {
 		return fmt.Errorf("failed to create CHANGELOG: %w", err)
 	}
@@ -4090,7 +4477,7 @@ func (m *Manager) initRemote(repoURL string) error {
 	}
 
 	// Push develop branch
-	_, err = m.git.Run("push", "--set-upstream", "origin", "develop")
+	err = m.git.Stream("push", "--set-upstream", "origin", "develop")
 	if err != nil {
 		return fmt.Errorf("failed to push develop branch: %w", err)
 	}
@@ -4098,33 +4485,32 @@ func (m *Manager) initRemote(repoURL string) error {
 	return nil
 }
 
-func (m *Manager) showInitRemoteUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ initremote <repository-url>
-
-DESCRIPTION:
-  Initialize a remote repository with basic structure.
-  Creates initial commit and sets up develop branch with CHANGELOG.
-
-ARGUMENTS:
-  <repository-url>    Remote repository URL (e.g., git@github.com:user/repo.git)
-
-PROCESS:
-  1. Initializes git repository
-  2. Adds remote origin
-  3. Creates initial commit
-  4. Pushes to master branch
-  5. Creates develop branch
-  6. Creates CHANGELOG file
-  7. Pushes develop branch
-
-EXAMPLES:
-  git @ initremote git@github.com:user/my-repo.git
-  git @ initremote git@gitlab.com:org/project.git
-
-SECURITY:
-  All initialization operations are validated and logged.
-`)
-	return nil
+func initRemoteCommand() *Command {
+	return &Command{
+		Name:    "initremote",
+		Summary: "Initialize remote repository with basic structure",
+		Usage:   []string{"<repository-url>"},
+		Description: `Initialize a remote repository with basic structure.
+Creates initial commit and sets up develop branch with CHANGELOG.`,
+		Args: []Arg{
+			{Name: "<repository-url>", Help: "Remote repository URL (e.g., git@github.com:user/repo.git)"},
+		},
+		Examples: []Example{
+			{"git @ initremote git@github.com:user/my-repo.git", ""},
+			{"git @ initremote git@gitlab.com:org/project.git", ""},
+		},
+		Sections: []Section{
+			{"PROCESS", `1. Initializes git repository
+2. Adds remote origin
+3. Creates initial commit
+4. Pushes to master branch
+5. Creates develop branch
+6. Creates CHANGELOG file
+7. Pushes develop branch`},
+			{"SECURITY", `All initialization operations are validated and logged.`},
+		},
+		Run: (*Manager).InitRemote,
+	}
 }
 
 // Security handles the _security command
@@ -4132,7 +4518,7 @@ func (m *Manager) Security(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showSecurityUsage()
+			return m.help("_security")
 		}
 	}
 
@@ -4156,25 +4542,23 @@ All operations are validated and logged for security monitoring.
 	return nil
 }
 
-func (m *Manager) showSecurityUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ _security
-
-DESCRIPTION:
-  Security utilities for GitAT.
-  Implements defensive coding practices and security checks.
-
-FEATURES:
-  - Input validation and sanitization
-  - Path traversal protection
-  - Command injection prevention
-  - Permission checking
-  - Secure configuration management
-  - Error handling and logging
-
-SECURITY:
-  All security operations are validated and logged.
-`)
-	return nil
+func securityCommand() *Command {
+	return &Command{
+		Name:    "_security",
+		Summary: "Security utilities and status",
+		Description: `Security utilities for GitAT.
+Implements defensive coding practices and security checks.`,
+		Sections: []Section{
+			{"FEATURES", `- Input validation and sanitization
+- Path traversal protection
+- Command injection prevention
+- Permission checking
+- Secure configuration management
+- Error handling and logging`},
+			{"SECURITY", `All security operations are validated and logged.`},
+		},
+		Run: (*Manager).Security,
+	}
 }
 
 // Go handles the _go command
@@ -4182,7 +4566,7 @@ func (m *Manager) Go(args []string) error {
 	if len(args) == 1 {
 		switch args[0] {
 		case "-h", "--help", "help", "h":
-			return m.showGoUsage()
+			return m.help("_go")
 		}
 	}
 
@@ -4236,31 +4620,25 @@ func (m *Manager) initializeGitAT() error {
 	return nil
 }
 
-func (m *Manager) showGoUsage() error {
-	fmt.Fprintf(os.Stdout, `Usage: git @ _go
-
-DESCRIPTION:
-  Initialize GitAT settings for a new repository.
-  Sets up all main configurations for general use of the tool.
-
-PROCESS:
-  1. Sets base branch based on remote HEAD
-  2. Resets version to 0.0.0
-  3. Sets current working branch
-  4. Sets current WIP branch
-  5. Marks repository as initialized
-
-EXAMPLES:
-  git @ _go                    # Initialize GitAT for current repository
-
-USE CASE:
-  Run this command when setting up GitAT for the first time in a repository.
-
-STORAGE:
-  Sets git config: at.initialised = true
-
-SECURITY:
-  All initialization operations are validated and logged.
-`)
-	return nil
+func goCommand() *Command {
+	return &Command{
+		Name:    "_go",
+		Summary: "Initialize GitAT for current repository",
+		Description: `Initialize GitAT settings for a new repository.
+Sets up all main configurations for general use of the tool.`,
+		Examples: []Example{
+			{"git @ _go", "Initialize GitAT for current repository"},
+		},
+		Sections: []Section{
+			{"PROCESS", `1. Sets base branch based on remote HEAD
+2. Resets version to 0.0.0
+3. Sets current working branch
+4. Sets current WIP branch
+5. Marks repository as initialized`},
+			{"USE CASE", `Run this command when setting up GitAT for the first time in a repository.`},
+			{"STORAGE", `Sets git config: at.initialised = true`},
+			{"SECURITY", `All initialization operations are validated and logged.`},
+		},
+		Run: (*Manager).Go,
+	}
 }
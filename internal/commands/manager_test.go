@@ -1,12 +1,24 @@
 package commands
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/potsed/gitAT/internal/config"
+	"github.com/potsed/gitAT/internal/errs"
 	"github.com/potsed/gitAT/internal/git"
+	"github.com/potsed/gitAT/internal/git/gittest"
+	"github.com/potsed/gitAT/internal/picker"
+	"github.com/potsed/gitAT/pkg/output"
 )
 
 // createTestManager creates a test manager with a temporary repository
@@ -161,10 +173,10 @@ func TestVersion(t *testing.T) {
 		t.Errorf("Version tag command failed: %v", err)
 	}
 
-	// Test version reset
+	// Unknown flags are usage errors
 	err = manager.Version([]string{"-r"})
-	if err != nil {
-		t.Errorf("Version reset command failed: %v", err)
+	if !errs.Is(err, errs.Usage) {
+		t.Errorf("expected a usage error for -r, got %v", err)
 	}
 }
 
@@ -297,6 +309,1848 @@ func TestErrorHandling(t *testing.T) {
 	}
 }
 
+// TestApplyPRDefaults tests merging of PR flags with configured defaults
+func TestApplyPRDefaults(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	manager.git.Run("config", "--add", "at.pr.reviewer", "alice")
+	manager.git.Run("config", "--add", "at.pr.label", "needs-review")
+	manager.git.Run("config", "at.pr.feature.draft", "true")
+	manager.git.Run("config", "at.pr.milestone", "v1.0")
+
+	// Hotfix branches get the built-in urgent label
+	opts := prOptions{reviewers: []string{"bob"}, milestone: "v2.0"}
+	manager.applyPRDefaults(&opts, "hotfix-fix-login")
+
+	if got := strings.Join(opts.reviewers, ","); got != "bob,alice" {
+		t.Errorf("Expected reviewers bob,alice, got %s", got)
+	}
+	if got := strings.Join(opts.labels, ","); got != "urgent,needs-review" {
+		t.Errorf("Expected labels urgent,needs-review, got %s", got)
+	}
+	if opts.milestone != "v2.0" {
+		t.Errorf("Expected milestone flag to win, got %s", opts.milestone)
+	}
+	if opts.draft {
+		t.Error("Hotfix PR should not be a draft")
+	}
+
+	// Feature branches are drafts by configuration unless --ready is given
+	opts = prOptions{}
+	manager.applyPRDefaults(&opts, "feature-add-auth")
+	if !opts.draft {
+		t.Error("Expected feature PR to be a draft")
+	}
+	if opts.milestone != "v1.0" {
+		t.Errorf("Expected configured milestone v1.0, got %s", opts.milestone)
+	}
+
+	opts = prOptions{ready: true}
+	manager.applyPRDefaults(&opts, "feature-add-auth")
+	if opts.draft {
+		t.Error("--ready should override the draft setting")
+	}
+
+	// A configured type label replaces the built-in default
+	manager.git.Run("config", "--add", "at.pr.hotfix.label", "hotfix")
+	opts = prOptions{}
+	manager.applyPRDefaults(&opts, "hotfix-fix-login")
+	if got := strings.Join(opts.labels, ","); got != "hotfix,needs-review" {
+		t.Errorf("Expected labels hotfix,needs-review, got %s", got)
+	}
+}
+
+// TestParsePRArgsValidation tests PR flag validation
+func TestParsePRArgsValidation(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	if err := manager.parsePRArgs([]string{"--draft", "--ready"}); err == nil {
+		t.Error("Expected error for --draft with --ready")
+	}
+
+	if err := manager.parsePRArgs([]string{"--reviewer"}); err == nil {
+		t.Error("Expected error for --reviewer without a value")
+	}
+}
+
+func TestMergeAutoDescription(t *testing.T) {
+	block := wrapAutoDescription("new summary")
+
+	// A description with markers keeps the user text around them
+	existing := "Intro by the author\n\n" + wrapAutoDescription("old summary") + "\n\nTesting notes"
+	want := "Intro by the author\n\n" + block + "\n\nTesting notes"
+	if got := mergeAutoDescription(existing, "new summary"); got != want {
+		t.Errorf("Expected user sections to be kept, got:\n%s", got)
+	}
+
+	// Empty and unmarked generated descriptions are replaced
+	if got := mergeAutoDescription("", "new summary"); got != block {
+		t.Errorf("Expected generated block for empty description, got:\n%s", got)
+	}
+	legacy := "# Summary\n\n" + autoDescriptionFooter + " based on the changes in this PR.*\n"
+	if got := mergeAutoDescription(legacy, "new summary"); got != block {
+		t.Errorf("Expected unmarked generated description to be replaced, got:\n%s", got)
+	}
+
+	// A hand-written description gets the generated block appended
+	if got := mergeAutoDescription("Hand written\n", "new summary"); got != "Hand written\n\n"+block {
+		t.Errorf("Expected generated block to be appended, got:\n%s", got)
+	}
+}
+
+func TestCommitType(t *testing.T) {
+	cases := map[string]string{
+		"feat(api): add login":    "feature",
+		"fix!: crash on start":    "bugfix",
+		"docs: update README":     "docs",
+		"[HOTFIX] patch session":  "hotfix",
+		"[FEATURE] PROD.AUTH add": "feature",
+		"Update dependencies":     "other",
+		"wip: something":          "other",
+	}
+	for subject, want := range cases {
+		if got := commitType(subject); got != want {
+			t.Errorf("commitType(%q) = %q, want %q", subject, got, want)
+		}
+	}
+}
+
+func TestGenerateAutoDescription(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	base, _ := manager.git.GetCurrentBranch()
+	dir := manager.config.RepoPath
+
+	content := strings.Repeat("line\n", 20)
+	os.WriteFile(filepath.Join(dir, "old.txt"), []byte(content), 0644)
+	manager.git.Run("add", ".")
+	manager.git.Run("commit", "-m", "Add old file")
+
+	manager.git.Run("checkout", "-b", "feature-desc")
+	manager.git.Run("mv", "old.txt", "new.txt")
+	manager.git.Run("commit", "-m", "refactor: rename file")
+	os.WriteFile(filepath.Join(dir, "b.go"), []byte("package b\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nvar x = 1\n"), 0644)
+	manager.git.Run("add", ".")
+	manager.git.Run("commit", "-m", "[FEATURE] add files")
+
+	description := manager.generateAutoDescription(base, "feature-desc", false)
+
+	for _, want := range []string{
+		"| **Renamed** | 1 |",
+		"`old.txt` → `new.txt`",
+		"| added | `a.go` | +3 | -0 |",
+		"This PR includes **2 commits**.",
+		"### Features",
+		"### Refactoring",
+	} {
+		if !strings.Contains(description, want) {
+			t.Errorf("Expected description to contain %q, got:\n%s", want, description)
+		}
+	}
+
+	if strings.Index(description, "`a.go`") > strings.Index(description, "`b.go`") {
+		t.Error("Expected files to be sorted by path")
+	}
+	if strings.Contains(description, "📋") {
+		t.Error("Expected no emojis when disabled")
+	}
+	if again := manager.generateAutoDescription(base, "feature-desc", false); again != description {
+		t.Error("Expected the description to be deterministic")
+	}
+}
+
+// commitFile writes a file and commits it in the test repository
+func commitFile(t *testing.T, manager *Manager, name, content, message string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(manager.config.RepoPath, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	if _, err := manager.git.Run("add", name); err != nil {
+		t.Fatalf("Failed to add %s: %v", name, err)
+	}
+	if _, err := manager.git.Run("commit", "-m", message); err != nil {
+		t.Fatalf("Failed to commit %s: %v", name, err)
+	}
+}
+
+func TestStack(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	trunk, _ := manager.git.GetCurrentBranch()
+	manager.git.SetConfig("at.trunk", trunk)
+
+	if err := manager.createWorkBranchFromName("feature-a", trunk); err != nil {
+		t.Fatalf("Failed to create feature-a: %v", err)
+	}
+	commitFile(t, manager, "a.txt", "a", "Add a")
+
+	if err := manager.createWorkBranchFromName("feature-b", "feature-a"); err != nil {
+		t.Fatalf("Failed to create feature-b: %v", err)
+	}
+	commitFile(t, manager, "b.txt", "b", "Add b")
+
+	if parent := manager.getStackParent("feature-b"); parent != "feature-a" {
+		t.Errorf("Expected feature-a as parent, got %q", parent)
+	}
+	if children := manager.getStackChildren("feature-a"); len(children) != 1 || children[0] != "feature-b" {
+		t.Errorf("Expected feature-b as child, got %v", children)
+	}
+	if base := manager.stackBaseBranch("feature-b"); base != "feature-a" {
+		t.Errorf("Expected PR to target feature-a, got %q", base)
+	}
+	if parent, _ := manager.detectParentBranch(); parent != "feature-a" {
+		t.Errorf("Expected detected parent feature-a, got %q", parent)
+	}
+	if err := manager.Stack([]string{}); err != nil {
+		t.Errorf("Stack failed: %v", err)
+	}
+
+	// Rewrite the parent: restack moves feature-b onto it
+	manager.git.Run("checkout", "feature-a")
+	commitFile(t, manager, "a2.txt", "a2", "Add a2")
+	if err := manager.Restack([]string{}); err != nil {
+		t.Fatalf("Restack failed: %v", err)
+	}
+	if _, err := manager.git.Run("merge-base", "--is-ancestor", "feature-a", "feature-b"); err != nil {
+		t.Error("Expected feature-b to be rebased onto feature-a")
+	}
+
+	// Merge and delete the parent: feature-b moves onto the trunk
+	manager.git.Run("checkout", trunk)
+	manager.git.Run("merge", "--squash", "feature-a")
+	manager.git.Run("commit", "-m", "Squash feature-a")
+	manager.git.Run("branch", "-D", "feature-a")
+
+	manager.git.Run("checkout", "feature-b")
+	if err := manager.Restack([]string{}); err != nil {
+		t.Fatalf("Restack failed: %v", err)
+	}
+	if parent := manager.getStackParent("feature-b"); parent != trunk {
+		t.Errorf("Expected feature-b to be reparented to %s, got %q", trunk, parent)
+	}
+	count, _ := manager.git.Run("rev-list", "--count", trunk+"..feature-b")
+	if strings.TrimSpace(count) != "1" {
+		t.Errorf("Expected only feature-b's commit on top of %s, got %s", trunk, count)
+	}
+}
+
+func TestStackParentMergedRewritten(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.trunk", "master").
+		Branch("feature-a").
+		Commit("a1.txt", "a1", "Add a1").
+		Commit("a2.txt", "a2", "Add a2")
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+	if err := manager.setStackParent("feature-a", "master"); err != nil {
+		t.Fatalf("Failed to record the parent: %v", err)
+	}
+	repo.Checkout("master").Commit("trunk.txt", "trunk", "Trunk work")
+
+	if manager.isStackParentMerged("feature-a") {
+		t.Error("Expected feature-a not to be merged yet")
+	}
+
+	// Rebase merge: the commits are replayed on the trunk
+	repo.Run("cherry-pick", "master..feature-a")
+	if !manager.isStackParentMerged("feature-a") {
+		t.Error("Expected a rebase-merged feature-a to be merged")
+	}
+
+	// Only part of the branch on the trunk is not a merge
+	repo.Run("reset", "--hard", "HEAD~1")
+	if manager.isStackParentMerged("feature-a") {
+		t.Error("Expected a partly applied feature-a not to be merged")
+	}
+
+	// Squash merge: one commit with all the changes
+	repo.Run("reset", "--hard", "HEAD~1")
+	repo.Run("merge", "--squash", "feature-a")
+	repo.Run("commit", "-m", "Squash feature-a")
+	objects := repo.Run("count-objects")
+	if !manager.isStackParentMerged("feature-a") {
+		t.Error("Expected a squash-merged feature-a to be merged")
+	}
+	if after := repo.Run("count-objects"); after != objects {
+		t.Errorf("Expected the check to write no objects, %s became %s", objects, after)
+	}
+}
+
+func TestSyncState(t *testing.T) {
+	state := syncState{Original: "main", Strategy: syncMerge, Branch: "feature-a", Target: "main", Pending: []string{"feature-b", "feature-c"}}
+
+	parsed, err := parseSyncState(state.format())
+	if err != nil {
+		t.Fatalf("Failed to parse sync state: %v", err)
+	}
+	if parsed.Original != "main" || parsed.Strategy != syncMerge || parsed.Branch != "feature-a" || len(parsed.Pending) != 2 {
+		t.Errorf("Unexpected sync state: %+v", parsed)
+	}
+
+	if _, err := parseSyncState("garbage"); err == nil {
+		t.Error("Expected error for invalid sync state")
+	}
+}
+
+func TestSync(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	trunk, _ := manager.git.GetCurrentBranch()
+	manager.git.SetConfig("at.trunk", trunk)
+
+	// Clone the repository as origin and add an upstream commit to trunk
+	upstreamDir, err := os.MkdirTemp("", "gitat-upstream-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(upstreamDir)
+
+	if _, err := manager.git.Run("clone", manager.config.RepoPath, upstreamDir); err != nil {
+		t.Fatalf("Failed to clone: %v", err)
+	}
+	upstream := &Manager{config: &config.Config{RepoPath: upstreamDir}, git: git.NewRepository(upstreamDir)}
+	commitFile(t, upstream, "upstream.txt", "upstream", "Upstream change")
+	manager.git.Run("remote", "add", "origin", upstreamDir)
+
+	manager.git.Run("checkout", "-b", "feature-x")
+	commitFile(t, manager, "x.txt", "x", "Add x")
+
+	if err := manager.Sync([]string{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	if _, err := manager.git.Run("merge-base", "--is-ancestor", "origin/"+trunk, trunk); err != nil {
+		t.Error("Expected trunk to be fast-forwarded")
+	}
+	if _, err := manager.git.Run("merge-base", "--is-ancestor", trunk, "feature-x"); err != nil {
+		t.Error("Expected feature-x to be rebased onto trunk")
+	}
+	if current, _ := manager.git.GetCurrentBranch(); current != "feature-x" {
+		t.Errorf("Expected to stay on feature-x, got %s", current)
+	}
+
+	// A branch that was never stacked gets no parent and is left alone
+	// once it contains the trunk
+	if parent := manager.getStackParent("feature-x"); parent != "" {
+		t.Errorf("Expected no parent recorded for feature-x, got %q", parent)
+	}
+	synced, _ := manager.getHeadSHA("feature-x")
+	if err := manager.Sync([]string{}); err != nil {
+		t.Fatalf("Second sync failed: %v", err)
+	}
+	if head, _ := manager.getHeadSHA("feature-x"); head != synced {
+		t.Error("Expected an up to date feature-x not to be rebased again")
+	}
+
+	// A conflicting branch stops the sync, abort restores it
+	commitFile(t, upstream, "test.txt", "upstream edit", "Edit test")
+	manager.git.Run("checkout", "-b", "feature-y", trunk)
+	commitFile(t, manager, "test.txt", "local edit", "Edit test locally")
+	before, _ := manager.getHeadSHA("feature-y")
+
+	if err := manager.Sync([]string{"--all"}); !errs.Is(err, errs.Conflict) || errs.ExitCode(err) != 6 {
+		t.Fatalf("Expected sync to stop on conflicts, got %v", err)
+	}
+	if _, err := manager.loadSyncState(); err != nil {
+		t.Errorf("Expected sync state to be saved: %v", err)
+	}
+	if err := manager.Sync([]string{}); !errs.Is(err, errs.Conflict) {
+		t.Errorf("Expected sync to refuse while another sync is in progress, got %v", err)
+	}
+
+	if err := manager.Sync([]string{"--abort"}); err != nil {
+		t.Fatalf("Abort failed: %v", err)
+	}
+	if after, _ := manager.getHeadSHA("feature-y"); after != before {
+		t.Error("Expected feature-y to be unchanged after abort")
+	}
+	if _, err := manager.loadSyncState(); err == nil {
+		t.Error("Expected sync state to be removed after abort")
+	}
+	if err := manager.Sync([]string{"--abort"}); !errs.Is(err, errs.NothingToDo) {
+		t.Errorf("Expected nothing to abort, got %v", err)
+	}
+}
+
+func TestSyncSkipsWorktreeBranches(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	trunk, _ := manager.git.GetCurrentBranch()
+	manager.git.SetConfig("at.trunk", trunk)
+
+	worktreeDir, err := os.MkdirTemp("", "gitat-worktrees-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(worktreeDir)
+
+	manager.git.Run("branch", "feature-elsewhere")
+	manager.git.Run("branch", "feature-behind")
+	commitFile(t, manager, "trunk.txt", "trunk", "Trunk change")
+	if _, err := manager.git.Run("worktree", "add", filepath.Join(worktreeDir, "wt"), "feature-elsewhere"); err != nil {
+		t.Fatalf("Failed to add worktree: %v", err)
+	}
+	manager.git.Run("checkout", "-b", "feature-current")
+
+	if err := manager.Sync([]string{"--all"}); err != nil {
+		t.Fatalf("Sync --all failed: %v", err)
+	}
+	if _, err := manager.git.Run("merge-base", "--is-ancestor", trunk, "feature-behind"); err != nil {
+		t.Error("Expected feature-behind to be synced")
+	}
+	if _, err := manager.git.Run("merge-base", "--is-ancestor", trunk, "feature-elsewhere"); err == nil {
+		t.Error("Expected the branch of the other worktree to be skipped")
+	}
+	if current, _ := manager.git.GetCurrentBranch(); current != "feature-current" {
+		t.Errorf("Expected to stay on feature-current, got %s", current)
+	}
+}
+
+func TestParseWorktrees(t *testing.T) {
+	out := "worktree /repo\nHEAD 1111111111\nbranch refs/heads/main\n\n" +
+		"worktree /wt/feature-a\nHEAD 2222222222\nbranch refs/heads/feature-a\nlocked\n\n" +
+		"worktree /wt/tmp\nHEAD 3333333333\ndetached\nprunable gitdir file points to non-existent location\n"
+
+	worktrees := parseWorktrees(out)
+	if len(worktrees) != 3 {
+		t.Fatalf("Expected 3 worktrees, got %d", len(worktrees))
+	}
+	if worktrees[0].Path != "/repo" || worktrees[0].Branch != "main" {
+		t.Errorf("Unexpected main worktree: %+v", worktrees[0])
+	}
+	if !worktrees[1].Locked || worktrees[1].Branch != "feature-a" {
+		t.Errorf("Unexpected locked worktree: %+v", worktrees[1])
+	}
+	if !worktrees[2].Detached || !worktrees[2].Prunable {
+		t.Errorf("Unexpected detached worktree: %+v", worktrees[2])
+	}
+}
+
+func TestWorktreeWork(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	trunk, _ := manager.git.GetCurrentBranch()
+	manager.git.SetConfig("at.trunk", trunk)
+
+	worktreeDir, err := os.MkdirTemp("", "gitat-worktrees-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(worktreeDir)
+	manager.git.SetConfig("at.worktree.dir", worktreeDir)
+
+	// Uncommitted changes stay in the current checkout
+	testFile := filepath.Join(manager.config.RepoPath, "test.txt")
+	os.WriteFile(testFile, []byte("in progress"), 0644)
+
+	if err := manager.createWorkBranch([]string{"feature", "login", "-w"}); err != nil {
+		t.Fatalf("Failed to create worktree branch: %v", err)
+	}
+
+	if current, _ := manager.git.GetCurrentBranch(); current != trunk {
+		t.Errorf("Expected to stay on %s, got %s", trunk, current)
+	}
+	if content, _ := os.ReadFile(testFile); string(content) != "in progress" {
+		t.Error("Expected uncommitted changes to be untouched")
+	}
+
+	wt := manager.findWorktree("feature-login")
+	if wt == nil {
+		t.Fatal("Expected a worktree for feature-login")
+	}
+	if want, _ := filepath.EvalSymlinks(filepath.Join(worktreeDir, "feature-login")); wt.Path != want && wt.Path != filepath.Join(worktreeDir, "feature-login") {
+		t.Errorf("Unexpected worktree path %s", wt.Path)
+	}
+
+	// The branch is merged (no commits of its own), so sweep removes it and its worktree
+	manager.git.SetConfig("at.branch", trunk)
+	if err := manager.Sweep([]string{"--yes"}); err != nil {
+		t.Fatalf("Sweep failed: %v", err)
+	}
+	if manager.branchExists("feature-login") {
+		t.Error("Expected feature-login to be swept")
+	}
+	if manager.findWorktree("feature-login") != nil {
+		t.Error("Expected the worktree of feature-login to be removed")
+	}
+
+	// A worktree whose directory was deleted is pruned
+	if err := manager.createWorkBranch([]string{"feature", "lost", "-w"}); err != nil {
+		t.Fatalf("Failed to create worktree branch: %v", err)
+	}
+	os.RemoveAll(filepath.Join(worktreeDir, "feature-lost"))
+	if err := manager.Worktree([]string{"prune"}); err != nil {
+		t.Fatalf("Worktree prune failed: %v", err)
+	}
+	if manager.findWorktree("feature-lost") != nil {
+		t.Error("Expected the deleted worktree to be pruned")
+	}
+}
+
+func TestWIPSnapshot(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	branch, _ := manager.git.GetCurrentBranch()
+	dir := manager.config.RepoPath
+	head, _ := manager.getHeadSHA("HEAD")
+
+	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("modified"), 0644)
+	os.WriteFile(filepath.Join(dir, "staged.txt"), []byte("staged"), 0644)
+	manager.git.Run("add", "staged.txt")
+	os.WriteFile(filepath.Join(dir, "untracked.txt"), []byte("untracked"), 0644)
+	// Names git quotes or splits, and a glob that must not match others
+	os.WriteFile(filepath.Join(dir, "two\nlines.txt"), []byte("newline"), 0644)
+	os.WriteFile(filepath.Join(dir, `"quoted" *.txt`), []byte("quoted"), 0644)
+
+	if err := manager.WIP([]string{"-s", "before switch"}); err != nil {
+		t.Fatalf("WIP snapshot failed: %v", err)
+	}
+
+	// HEAD and the working tree are untouched
+	if after, _ := manager.getHeadSHA("HEAD"); after != head {
+		t.Error("Expected HEAD to be unchanged")
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "test.txt")); string(content) != "modified" {
+		t.Error("Expected working tree to be unchanged")
+	}
+
+	snapshots, err := manager.wipSnapshots(branch)
+	if err != nil || len(snapshots) != 1 {
+		t.Fatalf("Expected 1 snapshot, got %d (%v)", len(snapshots), err)
+	}
+	if snapshots[0].Ref != "refs/gitat/wip/"+branch+"/1" || !strings.Contains(snapshots[0].Message, "before switch") {
+		t.Errorf("Unexpected snapshot: %+v", snapshots[0])
+	}
+	if err := manager.WIP([]string{"list"}); err != nil {
+		t.Errorf("WIP list failed: %v", err)
+	}
+	if err := manager.WIP([]string{"show"}); err != nil {
+		t.Errorf("WIP show failed: %v", err)
+	}
+
+	// Discard everything, then bring it back from the snapshot
+	manager.git.Run("reset", "--hard")
+	manager.git.Run("clean", "-fd")
+	if err := manager.WIP([]string{"apply", "1", "--index"}); err != nil {
+		t.Fatalf("WIP apply failed: %v", err)
+	}
+	restored := map[string]string{"test.txt": "modified", "staged.txt": "staged", "untracked.txt": "untracked",
+		"two\nlines.txt": "newline", `"quoted" *.txt`: "quoted"}
+	for name, want := range restored {
+		if content, _ := os.ReadFile(filepath.Join(dir, name)); string(content) != want {
+			t.Errorf("Expected %s to be restored, got %q", name, content)
+		}
+	}
+	if staged, _ := manager.git.Run("diff", "--cached", "--name-only"); staged != "staged.txt" {
+		t.Errorf("Expected staged.txt to be staged again, got %q", staged)
+	}
+
+	if err := manager.WIP([]string{"drop"}); err != nil {
+		t.Fatalf("WIP drop failed: %v", err)
+	}
+	if snapshots, _ := manager.wipSnapshots(branch); len(snapshots) != 0 {
+		t.Errorf("Expected no snapshots after drop, got %d", len(snapshots))
+	}
+}
+
+func TestParseStashList(t *testing.T) {
+	out := "stash@{0}\x00On main: gitat-autostash:feature-a\x002 minutes ago\n" +
+		"stash@{1}\x00On main: Auto-stash before switching to main\x001 day ago\n" +
+		"stash@{2}\x00WIP on main: 1234567 Initial commit\x002 days ago"
+
+	entries := parseStashList(out)
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 stashes, got %d", len(entries))
+	}
+	if entries[0].Branch != "feature-a" || entries[0].Ref != "stash@{0}" {
+		t.Errorf("Unexpected autostash: %+v", entries[0])
+	}
+	if !entries[1].Legacy || !entries[1].isGitAT() {
+		t.Errorf("Expected legacy gitAT stash: %+v", entries[1])
+	}
+	if entries[2].isGitAT() {
+		t.Errorf("Expected user stash to be ignored: %+v", entries[2])
+	}
+}
+
+func TestSwitchAutostash(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	trunk, _ := manager.git.GetCurrentBranch()
+	manager.git.Run("branch", "feature-a")
+	dir := manager.config.RepoPath
+
+	// Changes on trunk are stashed when leaving and restored when returning
+	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("trunk work"), 0644)
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("untracked"), 0644)
+
+	if err := manager.Switch([]string{"feature-a"}); err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+	if manager.isDirty() {
+		t.Error("Expected a clean tree on feature-a")
+	}
+
+	os.WriteFile(filepath.Join(dir, "test.txt"), []byte("feature work"), 0644)
+	if err := manager.Switch([]string{trunk}); err != nil {
+		t.Fatalf("Switch back failed: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "test.txt")); string(content) != "trunk work" {
+		t.Errorf("Expected trunk changes to be restored, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); err != nil {
+		t.Error("Expected untracked file to be restored")
+	}
+
+	// A plain checkout back leaves the stash of trunk to be restored
+	manager.git.Run("branch", "feature-b")
+	if err := manager.Switch([]string{"feature-b"}); err != nil {
+		t.Fatalf("Switch to feature-b failed: %v", err)
+	}
+	manager.git.Run("checkout", trunk)
+
+	// Deleting feature-a orphans its stash
+	manager.git.Run("branch", "-D", "feature-a")
+	if err := manager.Switch([]string{"--stashes"}); err != nil {
+		t.Errorf("Switch --stashes failed: %v", err)
+	}
+	if err := manager.Switch([]string{"-y", "--clean"}); err != nil {
+		t.Fatalf("Switch -y --clean failed: %v", err)
+	}
+	stashes, _ := manager.getStashes()
+	if len(stashes) != 1 || stashes[0].Branch != trunk {
+		t.Errorf("Expected only the stash of the current branch to be kept, got %+v", stashes)
+	}
+}
+
+func TestWorkFromIssue(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/issue/PROJ-123" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"key":"PROJ-123","fields":{"summary":"Login fails when the SSO session has expired overnight",
+			"issuetype":{"name":"Bug"},"status":{"name":"To Do"},"labels":[]}}`))
+	}))
+	defer server.Close()
+
+	manager.git.SetConfig("at.tracker", "jira")
+	manager.git.SetConfig("at.tracker.url", server.URL)
+
+	if err := manager.Work([]string{"--issue", "PROJ-123"}); err != nil {
+		t.Fatalf("Work --issue failed: %v", err)
+	}
+
+	branch, _ := manager.git.GetCurrentBranch()
+	if branch != "bugfix-PROJ-123-login-fails-when-the-sso-session-has" {
+		t.Errorf("Unexpected branch name: %s", branch)
+	}
+	if issue, _ := manager.git.GetConfig("branch." + branch + ".at-issue"); issue != "PROJ-123" {
+		t.Errorf("Expected the branch issue PROJ-123, got %q", issue)
+	}
+	if task, _ := manager.git.GetConfig("at.task"); task != "" {
+		t.Errorf("Expected at.task to stay unset, got %q", task)
+	}
+
+	// An explicit work type wins over the derived one
+	manager.git.Run("checkout", "-")
+	if err := manager.Work([]string{"chore", "--issue", "PROJ-123"}); err != nil {
+		t.Fatalf("Work chore --issue failed: %v", err)
+	}
+	if branch, _ := manager.git.GetCurrentBranch(); !strings.HasPrefix(branch, "chore-PROJ-123-") {
+		t.Errorf("Expected chore branch, got %s", branch)
+	}
+
+	// A sibling branch without an issue does not get the previous one
+	manager.git.Run("checkout", "-")
+	if err := manager.Work([]string{"feature", "other"}); err != nil {
+		t.Fatalf("Work feature failed: %v", err)
+	}
+	ctx := manager.currentContext()
+	if ctx.Issue != "" || ctx.label() != "" {
+		t.Errorf("Expected no issue or label on the sibling branch, got %+v", ctx)
+	}
+
+	// A branch named explicitly still records the issue
+	if err := manager.Work([]string{"--name", "feature-named", "--issue", "PROJ-123"}); err != nil {
+		t.Fatalf("Work --name --issue failed: %v", err)
+	}
+	if issue := manager.branchIssue("feature-named"); issue != "PROJ-123" {
+		t.Errorf("Expected feature-named to record PROJ-123, got %q", issue)
+	}
+
+	if err := manager.Work([]string{"--issue", "PROJ-999"}); !errs.Is(err, errs.NotFound) {
+		t.Errorf("Expected a not found error for a missing issue, got %v", err)
+	}
+	manager.git.Run("config", "--unset", "at.tracker")
+	if err := manager.Work([]string{"--issue", "PROJ-123"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("Expected a usage error without a tracker, got %v", err)
+	}
+}
+
+func TestBranchIssue(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	if issue := manager.branchIssue("feature-PROJ-42-login"); issue != "PROJ-42" {
+		t.Errorf("Expected PROJ-42 from the branch name, got %q", issue)
+	}
+	if issue := manager.branchIssue("feature-login"); issue != "" {
+		t.Errorf("Expected no issue, got %q", issue)
+	}
+
+	// Recorded metadata wins over the branch name
+	manager.setBranchIssue("feature-PROJ-42-login", "OPS-7")
+	if issue := manager.branchIssue("feature-PROJ-42-login"); issue != "OPS-7" {
+		t.Errorf("Expected OPS-7 from branch metadata, got %q", issue)
+	}
+
+	// Capture groups select the key
+	manager.git.SetConfig("at.issue.pattern", `-(\d+)-`)
+	if issue := manager.branchIssue("bugfix-118-crash"); issue != "118" {
+		t.Errorf("Expected 118 with a custom pattern, got %q", issue)
+	}
+}
+
+func TestSaveIssueTrailer(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	if err := manager.createWorkBranchFromName("feature-PROJ-7-search", "master"); err != nil {
+		t.Fatalf("Failed to create branch: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(manager.config.RepoPath, "search.txt"), []byte("search"), 0644)
+	if err := manager.saveWork([]string{"Add search"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	message, _ := manager.git.Run("log", "-1", "--format=%B")
+	if !strings.Contains(message, "[FEATURE] [PROJ-7] Add search") {
+		t.Errorf("Expected the issue in the label, got %q", message)
+	}
+	if !strings.Contains(message, "Refs: PROJ-7") {
+		t.Errorf("Expected Refs trailer, got %q", message)
+	}
+
+	os.WriteFile(filepath.Join(manager.config.RepoPath, "search.txt"), []byte("search v2"), 0644)
+	if err := manager.saveWork([]string{"--closes", "Finish search"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	message, _ = manager.git.Run("log", "-1", "--format=%B")
+	if !strings.Contains(message, "Closes: PROJ-7") || strings.Contains(message, "--closes") {
+		t.Errorf("Expected Closes trailer, got %q", message)
+	}
+
+	manager.git.SetConfig("at.issue.url", "https://jira.example.com/browse/{issue}")
+	description := manager.generateAutoDescription("master", "feature-PROJ-7-search", false)
+	if !strings.Contains(description, "- Closes [PROJ-7](https://jira.example.com/browse/PROJ-7)") {
+		t.Errorf("Expected issue link in description:\n%s", description)
+	}
+}
+
+func TestBranchContext(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	// On trunk the values are repository defaults
+	manager.Product([]string{"shop"})
+	manager.Feature([]string{"catalog"})
+	if product, _ := manager.git.GetConfig("at.product"); product != "shop" {
+		t.Errorf("Expected repository product, got %q", product)
+	}
+
+	if err := manager.createWorkBranchFromName("feature-a", "master"); err != nil {
+		t.Fatalf("Failed to create feature-a: %v", err)
+	}
+	manager.Feature([]string{"checkout"})
+	manager.Issue([]string{"SHOP-1"})
+
+	if feature, _ := manager.git.GetConfig("at.feature"); feature != "catalog" {
+		t.Errorf("Expected repository feature to stay catalog, got %q", feature)
+	}
+	if label, _ := manager.showLabel(); label != "[shop.checkout.SHOP-1]" {
+		t.Errorf("Unexpected label on feature-a: %s", label)
+	}
+
+	// Stacked branches inherit the feature but not the issue
+	if err := manager.createWorkBranchFromName("feature-b", "feature-a"); err != nil {
+		t.Fatalf("Failed to create feature-b: %v", err)
+	}
+	if ctx := manager.getBranchContext("feature-b"); ctx.Feature != "checkout" || ctx.Issue != "" {
+		t.Errorf("Unexpected inherited context: %+v", ctx)
+	}
+
+	// Other branches fall back to the repository defaults
+	manager.git.Run("checkout", "master")
+	if label, _ := manager.showLabel(); label != "[shop.catalog]" {
+		t.Errorf("Unexpected label on master: %s", label)
+	}
+
+	// --default sets the repository value from a work branch
+	manager.git.Run("checkout", "feature-a")
+	manager.Feature([]string{"--default", "search"})
+	if feature, _ := manager.git.GetConfig("at.feature"); feature != "search" {
+		t.Errorf("Expected repository feature search, got %q", feature)
+	}
+	if ctx := manager.getBranchContext("feature-a"); ctx.Feature != "checkout" {
+		t.Errorf("Expected branch feature to win, got %q", ctx.Feature)
+	}
+}
+
+func TestLabelTemplate(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	if err := manager.createWorkBranchFromName("feature-SHOP-9-cart", "master"); err != nil {
+		t.Fatalf("Failed to create branch: %v", err)
+	}
+	manager.Product([]string{"shop"})
+
+	if label, _ := manager.showLabel(); label != "[shop.SHOP-9]" {
+		t.Errorf("Unexpected default label: %s", label)
+	}
+
+	if err := manager.Label([]string{"{?({feature}) }{type|upper}/{issue}"}); err != nil {
+		t.Fatalf("Failed to set label: %v", err)
+	}
+	if label, _ := manager.showLabel(); label != "FEATURE/SHOP-9" {
+		t.Errorf("Unexpected custom label: %s", label)
+	}
+
+	os.WriteFile(filepath.Join(manager.config.RepoPath, "cart.txt"), []byte("cart"), 0644)
+	if err := manager.saveWork([]string{"Add cart"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if subject, _ := manager.git.Run("log", "-1", "--format=%s"); subject != "[FEATURE] FEATURE/SHOP-9 Add cart" {
+		t.Errorf("Unexpected commit subject: %s", subject)
+	}
+
+	if err := manager.Label([]string{"{unknown}"}); err == nil {
+		t.Error("Expected an error for an unknown placeholder")
+	}
+
+	manager.git.SetConfig("at.pr.title", "{?[{issue}] }{subject}")
+	if title, err := manager.getDefaultPRTitle(); err != nil || title != "[SHOP-9] [FEATURE] FEATURE/SHOP-9 Add cart" {
+		t.Errorf("Unexpected PR title %q (%v)", title, err)
+	}
+	manager.git.SetConfig("at.pr.title", "{subjct}")
+	var templateErr *templateError
+	if _, err := manager.getDefaultPRTitle(); !errors.As(err, &templateErr) || templateErr.Key != "at.pr.title" {
+		t.Errorf("Expected a template error for at.pr.title, got %v", err)
+	}
+
+	manager.git.SetConfig("at.major", "1")
+	manager.git.SetConfig("at.id", "{product}-v{version}")
+	if id, _ := manager.renderTemplate("at.id", defaultIDTemplate, nil); id != "shop-v1.0.0" {
+		t.Errorf("Unexpected id: %s", id)
+	}
+}
+
+func TestSummarizeTime(t *testing.T) {
+	base := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	events := []timeEvent{
+		{Time: at(0), Event: timeStart, Branch: "feature-A-1", Issue: "A-1", Feature: "cart"},
+		{Time: at(20), Event: timeSave, Branch: "feature-A-1", Issue: "A-1", Feature: "cart"},
+		{Time: at(45), Event: timeStop, Branch: "feature-A-1", Issue: "A-1", Feature: "cart"},
+		{Time: at(45), Event: timeStart, Branch: "bugfix-B-2", Issue: "B-2", Feature: "cart"},
+		// Lunch: the 3h gap only counts as the idle limit
+		{Time: at(225), Event: timeSave, Branch: "bugfix-B-2", Issue: "B-2", Feature: "cart"},
+		{Time: at(240), Event: timeStop, Branch: "bugfix-B-2", Issue: "B-2", Feature: "cart"},
+	}
+
+	totals := summarizeTime(events, "issue", time.Time{}, at(300), 30*time.Minute)
+	if len(totals) != 2 {
+		t.Fatalf("Expected 2 issues, got %+v", totals)
+	}
+	if totals[0].Key != "A-1" || totals[0].Duration != 45*time.Minute {
+		t.Errorf("Unexpected A-1 total: %+v", totals[0])
+	}
+	if totals[1].Key != "B-2" || totals[1].Duration != 45*time.Minute {
+		t.Errorf("Unexpected B-2 total: %+v", totals[1])
+	}
+
+	byFeature := summarizeTime(events, "feature", time.Time{}, at(300), 30*time.Minute)
+	if len(byFeature) != 1 || byFeature[0].Duration != 90*time.Minute || len(byFeature[0].Branches) != 2 {
+		t.Errorf("Unexpected feature totals: %+v", byFeature)
+	}
+
+	// Time before --since is not counted
+	recent := summarizeTime(events, "issue", at(30), at(300), 30*time.Minute)
+	if recent[0].Key != "B-2" || recent[1].Duration != 15*time.Minute {
+		t.Errorf("Unexpected totals since 09:30: %+v", recent)
+	}
+}
+
+func TestTimeJournal(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	if err := manager.createWorkBranchFromName("feature-TT-1-report", "master"); err != nil {
+		t.Fatalf("Failed to create branch: %v", err)
+	}
+	os.WriteFile(filepath.Join(manager.config.RepoPath, "report.txt"), []byte("report"), 0644)
+	if err := manager.saveWork([]string{"Add report"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := manager.Switch([]string{"master"}); err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+
+	events, err := manager.loadTimeEvents()
+	if err != nil {
+		t.Fatalf("Failed to load time journal: %v", err)
+	}
+	var got []string
+	for _, event := range events {
+		got = append(got, fmt.Sprintf("%s %s %s %s", event.Command, event.Event, event.Branch, event.Issue))
+	}
+	want := []string{
+		"work stop master ",
+		"work start feature-TT-1-report TT-1",
+		"save save feature-TT-1-report TT-1",
+		"switch stop feature-TT-1-report TT-1",
+		"switch start master ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected journal:\n%s", strings.Join(got, "\n"))
+	}
+
+	if err := manager.Time([]string{"--csv", "--by", "branch"}); err != nil {
+		t.Errorf("Time report failed: %v", err)
+	}
+	if err := manager.Time([]string{"--since", "yesterday"}); err == nil {
+		t.Error("Expected an error for an invalid --since")
+	}
+}
+
+func TestSaveWithFakeGit(t *testing.T) {
+	fake := gittest.NewFake("feature-PROJ-7-login").
+		Set("at.branch", "feature-PROJ-7-login").
+		Set("at.time", "false").
+		On("rev-parse --show-toplevel", "/repo")
+	manager := NewManagerWithClients(&config.Config{RepoPath: "/repo"}, fake, nil)
+
+	if err := manager.Save([]string{"Add", "login", "form"}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	if !fake.Called("add .") {
+		t.Error("expected save to stage the changes")
+	}
+	commits := fake.CallsTo("commit -m")
+	if len(commits) != 1 {
+		t.Fatalf("expected one commit, got %v", fake.Calls)
+	}
+	commit := commits[0]
+	if message := commit[2]; !strings.HasPrefix(message, "[FEATURE] ") || !strings.HasSuffix(message, "Add login form") {
+		t.Errorf("unexpected commit message %q", message)
+	}
+	if !strings.Contains(strings.Join(commit, " "), "--trailer Refs: PROJ-7") {
+		t.Errorf("expected an issue trailer, got %v", commit)
+	}
+}
+
+func TestSaveWithFakeGitFailure(t *testing.T) {
+	fake := gittest.NewFake("feature-login").
+		Set("at.branch", "feature-login").
+		Set("at.time", "false").
+		Fail("commit")
+	manager := NewManagerWithClients(&config.Config{RepoPath: "/repo"}, fake, nil)
+
+	err := manager.Save([]string{"Add", "login"})
+	if err == nil || !strings.Contains(err.Error(), "failed to commit changes") {
+		t.Errorf("expected commit failure, got %v", err)
+	}
+}
+
+func TestSquashWithFixture(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Branch("feature-squash").
+		Commit("a.txt", "a", "Add a").
+		Commit("b.txt", "b", "Add b").
+		Commit("a.txt", "a2", "Update a")
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+
+	if err := manager.Squash([]string{"master"}); err != nil {
+		t.Fatalf("squash failed: %v", err)
+	}
+
+	if head := repo.Head(); head != "feature-squash" {
+		t.Errorf("expected to stay on feature-squash, got %s", head)
+	}
+	subjects := repo.Subjects("master..HEAD")
+	if len(subjects) != 1 || subjects[0] != "Add a" {
+		t.Fatalf("expected one squashed commit, got %v", subjects)
+	}
+	body := repo.Run("log", "-1", "--format=%b")
+	if !strings.Contains(body, "* Add b") || !strings.Contains(body, "* Update a") {
+		t.Errorf("expected the squashed subjects in the body, got %q", body)
+	}
+	if content, _ := os.ReadFile(filepath.Join(repo.Dir, "a.txt")); string(content) != "a2" {
+		t.Errorf("expected the squashed tree to keep the last change, got %q", content)
+	}
+}
+
+func TestSquashForPRWithFixture(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.trunk", "master").
+		Branch("feature-pr-squash").
+		Commit("a.txt", "a", "Add a").
+		Commit("b.txt", "b", "Add b")
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+
+	if err := manager.Squash([]string{"--pr"}); err != nil {
+		t.Fatalf("squash --pr failed: %v", err)
+	}
+
+	if subjects := repo.Subjects("master..HEAD"); len(subjects) != 1 {
+		t.Errorf("expected one squashed commit, got %v", subjects)
+	}
+	if branches := repo.Run("branch", "--list", "*-squash-*"); branches != "" {
+		t.Errorf("expected the temporary branch to be removed, got %q", branches)
+	}
+}
+
+func TestPRWithFixture(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.trunk", "master").
+		Origin("https://github.com/acme/widgets.git").
+		Branch("feature-widgets").
+		Commit("widget.go", "package widget\n", "Add widget")
+
+	var calls [][]string
+	cli := func(name string, args ...string) (string, error) {
+		calls = append(calls, append([]string{name}, args...))
+		switch {
+		case len(args) > 1 && args[0] == "pr" && args[1] == "list":
+			return "[]", nil
+		case len(args) > 1 && args[0] == "pr" && args[1] == "create":
+			return "https://github.com/acme/widgets/pull/42", nil
+		}
+		return "", nil
+	}
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, cli)
+
+	if err := manager.PullRequest([]string{"-t", "Add widgets"}); err != nil {
+		t.Fatalf("pr failed: %v", err)
+	}
+
+	var create []string
+	for _, call := range calls {
+		if len(call) > 2 && call[1] == "pr" && call[2] == "create" {
+			create = call
+		}
+	}
+	if create == nil {
+		t.Fatalf("expected gh pr create, got %v", calls)
+	}
+	args := strings.Join(create, " ")
+	for _, want := range []string{"--title Add widgets", "--base master", "--head feature-widgets"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("expected %q in %q", want, args)
+		}
+	}
+}
+
+func TestPRFindFailure(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.trunk", "master").
+		Origin("https://github.com/acme/widgets.git").
+		Branch("feature-widgets").
+		Commit("widget.go", "package widget\n", "Add widget")
+
+	var created bool
+	missing := false
+	cli := func(name string, args ...string) (string, error) {
+		switch {
+		case missing && len(args) > 0 && args[0] == "--version":
+			return "", fmt.Errorf("executable file not found")
+		case len(args) > 1 && args[0] == "pr" && args[1] == "list":
+			return "", fmt.Errorf("gh command failed: HTTP 502")
+		case len(args) > 1 && args[0] == "pr" && args[1] == "create":
+			created = true
+		}
+		return "", nil
+	}
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, cli)
+
+	// A failed lookup must not open a second PR
+	if err := manager.PullRequest([]string{"-t", "Add widgets"}); err == nil || !strings.Contains(err.Error(), "HTTP 502") {
+		t.Errorf("expected the lookup failure, got %v", err)
+	}
+	if created {
+		t.Error("expected no PR to be created after a failed lookup")
+	}
+
+	// Without gh the PR is created in the web interface
+	missing = true
+	if err := manager.PullRequest([]string{"-t", "Add widgets"}); err != nil {
+		t.Errorf("expected the web fallback without gh, got %v", err)
+	}
+}
+
+// captureJSON runs fn in JSON mode and decodes what it prints
+func captureJSON(t *testing.T, fn func() error) map[string]interface{} {
+	t.Helper()
+
+	output.SetJSON(true)
+	defer output.SetJSON(false)
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	runErr := fn()
+	os.Stdout = stdout
+	writer.Close()
+
+	data, _ := io.ReadAll(reader)
+	if runErr != nil {
+		t.Fatalf("command failed: %v", runErr)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("expected a JSON document, got %q: %v", data, err)
+	}
+	return doc
+}
+
+func TestInfoJSON(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.trunk", "master").
+		Branch("feature-PROJ-7-report").
+		Commit("a.txt", "a", "Add a").
+		Write("a.txt", "changed").
+		Write("new.txt", "new")
+	repo.Run("add", "new.txt")
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+
+	doc := captureJSON(t, func() error { return manager.Info(nil) })
+
+	if doc["branch"] != "feature-PROJ-7-report" || doc["type"] != "feature" || doc["issue"] != "PROJ-7" {
+		t.Errorf("unexpected branch fields: %v", doc)
+	}
+	trunk, _ := doc["trunk_status"].(map[string]interface{})
+	if trunk["ref"] != "master" || trunk["ahead"] != 1.0 || trunk["behind"] != 0.0 {
+		t.Errorf("expected one commit ahead of master, got %v", doc["trunk_status"])
+	}
+	if doc["upstream"] != nil {
+		t.Errorf("expected no upstream, got %v", doc["upstream"])
+	}
+	changes, _ := doc["changes"].(map[string]interface{})
+	if fmt.Sprint(changes["staged"]) != "[new.txt]" || fmt.Sprint(changes["unstaged"]) != "[a.txt]" {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+	if commits, _ := doc["commits"].([]interface{}); len(commits) != 2 {
+		t.Errorf("expected 2 commits, got %v", doc["commits"])
+	}
+}
+
+func TestQueryCommandsJSON(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.product", "widgets").
+		Config("at.major", "1").
+		Config("at.minor", "2").
+		Config("at.fix", "3").
+		Config("at.trunk", "master").
+		Branch("feature-json")
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+	top := repo.Run("rev-parse", "--show-toplevel")
+
+	tests := []struct {
+		name string
+		run  func() error
+		key  string
+		want string
+	}{
+		{"version", func() error { return manager.Version(nil) }, "version", "1.2.3"},
+		{"version tag", func() error { return manager.Version([]string{"--tag"}) }, "tag", "v1.2.3"},
+		{"id", func() error { return manager.ID(nil) }, "id", "widgets:123"},
+		{"label", func() error { return manager.Label(nil) }, "label", "[widgets]"},
+		{"product", func() error { return manager.Product(nil) }, "product", "widgets"},
+		{"current branch", func() error { return manager.Branch([]string{"-c"}) }, "current_branch", "feature-json"},
+		{"trunk", func() error { return manager.Trunk(nil) }, "trunk", "master"},
+		{"path", func() error { return manager.Path(nil) }, "path", top},
+		{"stack parent", func() error { return manager.Stack([]string{"parent"}) }, "branch", "feature-json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := captureJSON(t, tt.run)
+			if doc[tt.key] != tt.want {
+				t.Errorf("expected %s %q, got %v", tt.key, tt.want, doc)
+			}
+		})
+	}
+
+	doc := captureJSON(t, func() error { return manager.Branch([]string{"--feature"}) })
+	branches, _ := doc["branches"].([]interface{})
+	if len(branches) != 1 {
+		t.Fatalf("expected one feature branch, got %v", doc)
+	}
+	if entry := branches[0].(map[string]interface{}); entry["name"] != "feature-json" || entry["current"] != true {
+		t.Errorf("unexpected branch entry: %v", entry)
+	}
+}
+
+func TestListCommandsJSON(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.trunk", "master").
+		Branch("feature-base").
+		Commit("base.txt", "base", "Add base")
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+	if err := manager.createWorkBranch([]string{"feature", "top"}); err != nil {
+		t.Fatalf("Failed to create a stacked branch: %v", err)
+	}
+	repo.Commit("top.txt", "top", "Add top").Write("top.txt", "changed")
+	if err := manager.WIP([]string{"-s", "halfway"}); err != nil {
+		t.Fatalf("Failed to snapshot: %v", err)
+	}
+
+	doc := captureJSON(t, func() error { return manager.Stack(nil) })
+	stack, _ := doc["stack"].(map[string]interface{})
+	children, _ := stack["children"].([]interface{})
+	if doc["current_branch"] != "feature-top" || stack["branch"] != "feature-base" || len(children) != 1 {
+		t.Fatalf("expected feature-top stacked on feature-base, got %v", doc)
+	}
+	if child := children[0].(map[string]interface{}); child["branch"] != "feature-top" || child["ahead"] != 1.0 || child["current"] != true {
+		t.Errorf("unexpected stack entry: %v", child)
+	}
+
+	doc = captureJSON(t, func() error { return manager.WIP([]string{"list"}) })
+	snapshots, _ := doc["snapshots"].([]interface{})
+	if len(snapshots) != 1 || snapshots[0].(map[string]interface{})["branch"] != "feature-top" {
+		t.Errorf("expected one snapshot of feature-top, got %v", doc)
+	}
+
+	doc = captureJSON(t, func() error { return manager.Worktree([]string{"list"}) })
+	worktrees, _ := doc["worktrees"].([]interface{})
+	if len(worktrees) != 1 || worktrees[0].(map[string]interface{})["current"] != true {
+		t.Errorf("expected the current worktree, got %v", doc)
+	}
+
+	doc = captureJSON(t, func() error { return manager.Switch([]string{"--stashes"}) })
+	if stashes, ok := doc["stashes"].([]interface{}); !ok || len(stashes) != 0 {
+		t.Errorf("expected no gitAT stashes, got %v", doc)
+	}
+
+	doc = captureJSON(t, func() error { return manager.Time([]string{"--all"}) })
+	if totals, ok := doc["totals"].([]interface{}); !ok || doc["by"] != "issue" || doc["since"] != nil {
+		t.Errorf("expected a report over the whole journal, got %v (%v)", doc, totals)
+	}
+
+	output.SetJSON(true)
+	defer output.SetJSON(false)
+	if err := manager.Time([]string{"--csv"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("expected --csv to be refused with --json, got %v", err)
+	}
+}
+
+func TestPRStatusJSON(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Origin("https://github.com/acme/widgets.git").
+		Branch("feature-widgets")
+	cli := func(name string, args ...string) (string, error) {
+		return "[]", nil
+	}
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, cli)
+
+	doc := captureJSON(t, func() error { return manager.PullRequest([]string{"status"}) })
+	if doc["branch"] != "feature-widgets" || doc["pr"] != nil {
+		t.Errorf("expected no PR for the branch, got %v", doc)
+	}
+}
+
+func TestSquashDryRun(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.trunk", "master").
+		Branch("feature-dry-run").
+		Commit("a.txt", "a", "Add a").
+		Commit("b.txt", "b", "Add b")
+	head := repo.Run("rev-parse", "HEAD")
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+	plan := git.NewPlanner()
+	manager.SetDryRun(plan)
+
+	if err := manager.Squash([]string{"--pr"}); err != nil {
+		t.Fatalf("squash --pr failed: %v", err)
+	}
+
+	if now := repo.Run("rev-parse", "HEAD"); now != head {
+		t.Errorf("expected HEAD to stay at %s, got %s", head, now)
+	}
+	if branches := repo.Run("branch", "--list", "*-squash-*"); branches != "" {
+		t.Errorf("expected no temporary branch, got %q", branches)
+	}
+
+	steps := strings.Join(plan.Steps(), "\n")
+	for _, want := range []string{"git checkout -b feature-dry-run-squash-", "git cherry-pick --no-commit", "git reset --hard feature-dry-run-squash-"} {
+		if !strings.Contains(steps, want) {
+			t.Errorf("expected %q in the plan:\n%s", want, steps)
+		}
+	}
+}
+
+func TestSweepDryRun(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.trunk", "master").
+		Branch("feature-merged").
+		Checkout("master")
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+
+	if err := manager.Sweep([]string{"-n"}); err != nil {
+		t.Fatalf("sweep -n failed: %v", err)
+	}
+
+	if branches := repo.Run("branch", "--list", "feature-merged"); branches == "" {
+		t.Error("expected the branch to be kept")
+	}
+	if plan := manager.Plan(); plan == nil || !strings.Contains(strings.Join(plan.Steps(), "\n"), "git branch -d feature-merged") {
+		t.Errorf("expected the deletion to be planned, got %v", plan)
+	}
+}
+
+// TestParseFlags tests the flag parsing shared by the commands
+func TestParseFlags(t *testing.T) {
+	command := &Command{Name: "demo", Flags: []Flag{
+		{Short: "s", Long: "save"},
+		{Short: "p", Long: "pr"},
+		{Short: "b", Long: "base", Value: "branch"},
+		{Short: "r", Long: "reviewer", Value: "users"},
+	}}
+
+	flags, err := command.parse([]string{"-sp", "target", "--base=main", "-r", "alice", "-rbob", "--", "-x"})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if !flags.Has("save") || !flags.Has("pr") || flags.Has("help") {
+		t.Error("expected combined -sp to set save and pr")
+	}
+	if flags.String("base") != "main" {
+		t.Errorf("expected base main, got %q", flags.String("base"))
+	}
+	if got := strings.Join(flags.Strings("reviewer"), ","); got != "alice,bob" {
+		t.Errorf("expected reviewers alice,bob, got %q", got)
+	}
+	if got := strings.Join(flags.Args(), " "); got != "target -x" {
+		t.Errorf("expected positional arguments 'target -x', got %q", got)
+	}
+	if first := flags.First("pr", "save"); first != "save" {
+		t.Errorf("expected save to be given first, got %q", first)
+	}
+
+	for _, args := range [][]string{{"-x"}, {"--nope"}, {"--base"}, {"-b", "--pr"}, {"--save=yes"}} {
+		if _, err := command.parse(args); !errs.Is(err, errs.Usage) {
+			t.Errorf("expected a usage error for %q, got %v", args, err)
+		}
+	}
+}
+
+// TestCommandHelp tests the help generated from the registry
+func TestCommandHelp(t *testing.T) {
+	for _, command := range Commands() {
+		shorts := make(map[string]bool)
+		for _, flag := range command.AllFlags() {
+			if flag.Short != "" && shorts[flag.Short] {
+				t.Errorf("%s declares -%s twice", command.Name, flag.Short)
+			}
+			shorts[flag.Short] = true
+		}
+	}
+
+	help := FindCommand(Commands(), "pr").Subcommand("status").Help()
+	if !strings.HasPrefix(help, "Usage: git @ pr status\n") {
+		t.Errorf("unexpected help of pr status:\n%s", help)
+	}
+
+	help = FindCommand(Commands(), "squash").Help()
+	for _, want := range []string{
+		"Usage: git @ squash [options] [<target-branch>]",
+		"  -a, --auto <on|off|status>",
+		"  -h, --help ",
+		"  git @ squash -sp ",
+		"\nPR SQUASHING:\n",
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("expected the squash help to contain %q:\n%s", want, help)
+		}
+	}
+}
+
+// TestFlagParsingCommands tests the commands using the shared parser
+func TestFlagParsingCommands(t *testing.T) {
+	fake := gittest.NewFake("feature-x")
+	fake.Branches = []string{"feature-x", "feature-y", "bugfix-z"}
+	manager := NewManagerWithClients(&config.Config{}, fake, nil)
+
+	if err := manager.Branch([]string{"--feature"}); err != nil {
+		t.Fatalf("branch --feature failed: %v", err)
+	}
+	if err := manager.Branch([]string{"-cs"}); err != nil {
+		t.Fatalf("branch -cs failed: %v", err)
+	}
+	if len(fake.Config["at.branch"]) > 0 {
+		t.Error("expected only the first operation of -cs to run")
+	}
+	if err := manager.Branch([]string{"."}); err != nil {
+		t.Fatalf("branch . failed: %v", err)
+	}
+	if got := fake.Config["at.branch"]; len(got) != 1 || got[0] != "feature-x" {
+		t.Error("expected . to set the working branch to the current branch")
+	}
+
+	for _, args := range [][]string{{"--nope"}, {"-x"}} {
+		if err := manager.Branch(args); !errs.Is(err, errs.Usage) {
+			t.Errorf("expected a usage error for branch %q, got %v", args, err)
+		}
+		if err := manager.WIP(args); !errs.Is(err, errs.Usage) {
+			t.Errorf("expected a usage error for wip %q, got %v", args, err)
+		}
+	}
+	if err := manager.Squash([]string{"--auto"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("expected --auto without a value to be a usage error, got %v", err)
+	}
+	if err := manager.PullRequest([]string{"title", "extra"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("expected a second positional argument to be a usage error, got %v", err)
+	}
+	if err := manager.PullRequest([]string{"--draft", "--ready"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("expected --draft with --ready to be a usage error, got %v", err)
+	}
+}
+
+func TestExpandAlias(t *testing.T) {
+	tests := []struct {
+		expansion string
+		args      []string
+		want      string
+	}{
+		{"squash -s; pr --draft -o $1", []string{"Add login"}, "squash -s|pr --draft -o Add login"},
+		{"squash -s; pr --draft -o $1", nil, "squash -s|pr --draft -o"},
+		{"pr -t \"$1\"", nil, "pr -t "},
+		{"sync; squash", []string{"main"}, "sync|squash main"},
+		{"pr -r $@", []string{"alice", "bob"}, "pr -r alice bob"},
+		{"wip -s \"wip: $@\"", []string{"a", "b"}, "wip -s wip: a b"},
+		{"save 'a; b $1' ; ; info", []string{"x"}, "save a; b $1|info x"},
+		{"label feat-$2$1", []string{"x", "y"}, "label feat-yx"},
+		{`save it\'s`, nil, "save it's"},
+	}
+
+	for _, tt := range tests {
+		lines, err := ExpandAlias(tt.expansion, tt.args)
+		if err != nil {
+			t.Errorf("ExpandAlias(%q) failed: %v", tt.expansion, err)
+			continue
+		}
+		var got []string
+		for _, line := range lines {
+			got = append(got, strings.Join(line, " "))
+		}
+		if strings.Join(got, "|") != tt.want {
+			t.Errorf("ExpandAlias(%q, %q) = %q, want %q", tt.expansion, tt.args, strings.Join(got, "|"), tt.want)
+		}
+	}
+
+	for _, expansion := range []string{"save 'oops", " ; "} {
+		if _, err := ExpandAlias(expansion, nil); !errs.Is(err, errs.Usage) {
+			t.Errorf("expected a usage error for %q, got %v", expansion, err)
+		}
+	}
+}
+
+func TestAlias(t *testing.T) {
+	fake := gittest.NewFake("feature-x")
+	manager := NewManagerWithClients(&config.Config{RepoPath: "/repo"}, fake, nil)
+
+	if err := manager.Alias([]string{"ship", "squash -s; pr --draft -o $1"}); err != nil {
+		t.Fatalf("defining an alias failed: %v", err)
+	}
+	if got := fake.Config["at.alias.ship"]; len(got) != 1 || got[0] != "squash -s; pr --draft -o $1" {
+		t.Errorf("expected at.alias.ship to be set, got %q", got)
+	}
+	if err := manager.Alias([]string{"--", "sq", "squash", "-s"}); err != nil {
+		t.Fatalf("defining an alias from words failed: %v", err)
+	}
+	if expansion, _ := manager.FindAlias("sq"); expansion != "squash -s" {
+		t.Errorf("expected the words to be joined, got %q", expansion)
+	}
+
+	aliases := manager.Aliases()
+	if len(aliases) != 2 || aliases[0].Name != "ship" || aliases[1].Name != "sq" {
+		t.Errorf("expected ship and sq, got %+v", aliases)
+	}
+
+	for _, args := range [][]string{
+		{"squash", "pr"},
+		{"1st", "info"},
+		{"bad", "'unterminated"},
+		{"bad", "$1 info"},
+	} {
+		if err := manager.Alias(args); !errs.Is(err, errs.Usage) {
+			t.Errorf("expected %v to be a usage error, got %v", args, err)
+		}
+	}
+
+	if err := manager.Alias([]string{"--unset", "sq"}); err != nil {
+		t.Fatalf("removing an alias failed: %v", err)
+	}
+	if _, ok := manager.FindAlias("sq"); ok {
+		t.Error("expected sq to be removed")
+	}
+	if err := manager.Alias([]string{"sq"}); !errs.Is(err, errs.NotFound) {
+		t.Errorf("expected a removed alias not to be found, got %v", err)
+	}
+}
+
+func TestBranchesAndDeleteBranch(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.trunk", "master").
+		Branch("feature-PROJ-7-login").
+		Branch("hotfix-crash").
+		Branch("release-1").
+		Config("at.branch", "release-1").
+		Checkout("feature-PROJ-7-login")
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+
+	branches, err := manager.Branches()
+	if err != nil {
+		t.Fatalf("listing branches failed: %v", err)
+	}
+	types := map[string]string{}
+	for _, branch := range branches {
+		types[branch.Name] = branch.Type
+		if branch.Current != (branch.Name == "feature-PROJ-7-login") {
+			t.Errorf("expected only feature-PROJ-7-login to be current, got %+v", branch)
+		}
+	}
+	if len(branches) != 4 || types["feature-PROJ-7-login"] != "feature" || types["hotfix-crash"] != "hotfix" || types["master"] != "" {
+		t.Errorf("expected all local branches with their types, got %+v", branches)
+	}
+
+	for _, name := range []string{"feature-PROJ-7-login", "master", "release-1"} {
+		if err := manager.DeleteBranch(name, true); !errs.Is(err, errs.PolicyViolation) {
+			t.Errorf("expected deleting %s to be refused, got %v", name, err)
+		}
+	}
+	if err := manager.DeleteBranch("hotfix-crash", false); err != nil {
+		t.Fatalf("deleting hotfix-crash failed: %v", err)
+	}
+	if branches := repo.Run("branch", "--list", "hotfix-crash"); branches != "" {
+		t.Errorf("expected hotfix-crash to be gone, got %q", branches)
+	}
+}
+
+func TestSetInteractive(t *testing.T) {
+	repo := gittest.NewRepo(t)
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+	manager.SetInteractive(false)
+
+	for _, args := range [][]string{{"--reset"}, {"--set"}} {
+		if err := manager.Version(args); !errs.Is(err, errs.Usage) || !strings.Contains(err.Error(), "run it in a terminal") {
+			t.Errorf("expected version %v to need a terminal, got %v", args, err)
+		}
+	}
+}
+
+func TestPickBranch(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Origin("https://example.com/repo.git").
+		Branch("feature-login").
+		Commit("a.txt", "a", "Add a").
+		Commit("b.txt", "b", "Add b").
+		Checkout("master").
+		Commit("c.txt", "c", "Add c")
+	repo.Run("update-ref", "refs/remotes/origin/master", "master")
+	repo.Run("update-ref", "refs/remotes/origin/hotfix-remote", "master~1")
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+
+	// Without a terminal, nothing is asked
+	if err := manager.Switch(nil); err != nil || repo.Head() != "master" {
+		t.Fatalf("expected switch to show its help, got %v on %s", err, repo.Head())
+	}
+
+	choices, err := manager.branchChoices()
+	if err != nil {
+		t.Fatalf("listing the choices failed: %v", err)
+	}
+	if len(choices) != 2 {
+		t.Fatalf("expected feature-login and origin/hotfix-remote, got %+v", choices)
+	}
+	if c := choices[0]; c.Name != "feature-login" || c.Type != "feature" || c.Ahead != 2 || c.Behind != 1 || c.Subject != "Add b" || c.Remote {
+		t.Errorf("unexpected local choice %+v", c)
+	}
+	if c := choices[1]; c.Name != "origin/hotfix-remote" || c.Local != "hotfix-remote" || c.Type != "hotfix" || c.Ahead != 0 || c.Behind != 1 || !c.Remote {
+		t.Errorf("unexpected remote choice %+v", c)
+	}
+
+	var title, answer string
+	var asked []picker.Item
+	manager.picker = func(t string, items []picker.Item, selected int) (int, error) {
+		title, asked = t, items
+		for i, item := range items {
+			if item.Name == answer {
+				return i, nil
+			}
+		}
+		return -1, errs.New(errs.Cancelled, "Cancelled")
+	}
+
+	answer = "feature-login"
+	if err := manager.Switch(nil); err != nil || repo.Head() != "feature-login" || title != "Switch to" {
+		t.Fatalf("expected switch to pick feature-login, got %v on %s", err, repo.Head())
+	}
+	if detail := asked[0].Detail; !strings.HasPrefix(detail, "feature") || !strings.Contains(detail, "↑2 ↓1") || !strings.HasSuffix(detail, "Add b") {
+		t.Errorf("expected the type, counts and subject of feature-login, got %q", detail)
+	}
+
+	// A remote branch is checked out as a tracking branch
+	answer = "origin/hotfix-remote"
+	if err := manager.Switch(nil); err != nil || repo.Head() != "hotfix-remote" {
+		t.Fatalf("expected switch to check out hotfix-remote, got %v on %s", err, repo.Head())
+	}
+
+	answer = "nope"
+	if err := manager.Switch(nil); !errs.Is(err, errs.Cancelled) {
+		t.Errorf("expected a cancelled pick to cancel, got %v", err)
+	}
+
+	// The working branch is picked from the local branches
+	answer = "feature-login"
+	if err := manager.Branch([]string{"--pick"}); err != nil {
+		t.Fatalf("branch --pick failed: %v", err)
+	}
+	if working := repo.Run("config", "at.branch"); working != "feature-login" {
+		t.Errorf("expected feature-login as working branch, got %q", working)
+	}
+	for _, item := range asked {
+		if strings.Contains(item.Name, "/") {
+			t.Errorf("expected only local branches, got %s", item.Name)
+		}
+	}
+
+	// wip -c asks for the WIP branch when none is set
+	if err := manager.WIP([]string{"-c"}); err != nil || repo.Head() != "feature-login" {
+		t.Fatalf("expected wip -c to pick feature-login, got %v on %s", err, repo.Head())
+	}
+	if wip := repo.Run("config", "at.wip"); wip != "feature-login" {
+		t.Errorf("expected feature-login as WIP branch, got %q", wip)
+	}
+
+	answer = "master"
+	if err := manager.Squash(nil); err != nil || title != "Squash onto" {
+		t.Fatalf("expected squash to pick master, got %v", err)
+	}
+	if subjects := repo.Subjects("master..HEAD"); len(subjects) != 1 {
+		t.Errorf("expected one squashed commit, got %v", subjects)
+	}
+
+	// -b without a branch picks the base, or needs one without a terminal
+	if flags, err := prCommand().parse([]string{"-b", "--draft"}); err != nil || !flags.Has("base") || flags.String("base") != "" {
+		t.Errorf("expected -b to take no value, got %v", err)
+	}
+	manager.SetInteractive(false)
+	if err := manager.PullRequest([]string{"-b"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("expected -b to need a value without a terminal, got %v", err)
+	}
+	if err := manager.Switch(nil); err != nil || repo.Head() != "feature-login" {
+		t.Errorf("expected switch not to ask when not interactive, got %v on %s", err, repo.Head())
+	}
+}
+
+func TestCommandsRejectUnknownOptions(t *testing.T) {
+	manager := createTestManager(t)
+	defer cleanupTest(t, manager)
+
+	// Unknown options are usage errors instead of branch names or messages
+	calls := map[string]func([]string) error{
+		"work":            manager.Work,
+		"hotfix":          manager.Hotfix,
+		"save":            manager.Save,
+		"sync":            manager.Sync,
+		"sweep":           manager.Sweep,
+		"switch":          manager.Switch,
+		"time":            manager.Time,
+		"_label":          manager.Label,
+		"product":         manager.Product,
+		"feature":         manager.Feature,
+		"issue":           manager.Issue,
+		"plugins list":    manager.Plugins,
+		"wip apply":       manager.applyWIP,
+		"wip push":        manager.pushWIP,
+		"worktree remove": manager.removeWorktree,
+	}
+	for name, call := range calls {
+		if err := call([]string{"feature", "--bogus"}); !errs.Is(err, errs.Usage) || !strings.Contains(err.Error(), "--bogus") {
+			t.Errorf("%s: expected a usage error for --bogus, got %v", name, err)
+		}
+	}
+
+	if err := manager.Product([]string{"-x"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("Expected product -x to be rejected, got %v", err)
+	}
+	if product, _ := manager.git.GetConfig("at.product"); product != "" {
+		t.Errorf("Expected no product to be stored, got %q", product)
+	}
+	if err := manager.Sync([]string{"main"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("Expected sync to reject arguments, got %v", err)
+	}
+	if err := manager.Switch([]string{"-y"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("Expected --yes without --clean to be rejected, got %v", err)
+	}
+	if err := manager.Time([]string{"--all", "-b", "branch"}); err != nil {
+		t.Errorf("Expected time --all -b branch to work, got %v", err)
+	}
+	if err := manager.Time([]string{"--by", "team"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("Expected an invalid --by to be rejected, got %v", err)
+	}
+}
+
+// writePlugin writes an executable plugin script to dir
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "git-@-"+name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPlugins(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.trunk", "master").
+		Config("at.branch", "feature-x").
+		Branch("feature-x")
+	repo.Run("config", "--add", "at.reviewers", "alice")
+	repo.Run("config", "--add", "at.reviewers", "bob")
+	manager := NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+
+	bin := t.TempDir()
+	envFile := filepath.Join(t.TempDir(), "env")
+	writePlugin(t, bin, "report", `env | grep ^GITAT_ > "`+envFile+`"; echo "$@" >> "`+envFile+`"; exit ${EXIT:-0}`)
+	writePlugin(t, bin, "shadowed", "exit 0")
+	shadow := writePlugin(t, t.TempDir(), "shadowed", "exit 1")
+	if err := os.WriteFile(filepath.Join(bin, "git-@-noexec"), []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	local := writePlugin(t, filepath.Join(repo.Dir, ".gitat", "plugins"), "local", "exit 0")
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+filepath.Dir(shadow)+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	t.Run("list", func(t *testing.T) {
+		found := make(map[string]Plugin)
+		for _, plugin := range manager.InstalledPlugins() {
+			found[plugin.Name] = plugin
+		}
+		if _, ok := found["noexec"]; ok {
+			t.Error("expected a file that is not executable to be skipped")
+		}
+		if found["shadowed"].Shadows != shadow {
+			t.Errorf("expected the first plugin on PATH to hide %s, got %+v", shadow, found["shadowed"])
+		}
+		if p := found["local"]; p.Path != local || p.Source != "repository" || p.Trusted {
+			t.Errorf("expected an untrusted repository plugin, got %+v", p)
+		}
+		if p := found["report"]; p.Source != "path" || !p.Trusted {
+			t.Errorf("expected a trusted PATH plugin, got %+v", p)
+		}
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		plugin, ok := manager.FindPlugin("report")
+		if !ok {
+			t.Fatal("plugin report not found")
+		}
+		if err := manager.RunPlugin(plugin, []string{"one", "two"}); err != nil {
+			t.Fatalf("plugin failed: %v", err)
+		}
+
+		data, err := os.ReadFile(envFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env := make(map[string]string)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		for _, line := range lines[:len(lines)-1] {
+			key, value, _ := strings.Cut(line, "=")
+			env[key] = value
+		}
+		if args := lines[len(lines)-1]; args != "one two" {
+			t.Errorf("expected the arguments to be passed, got %q", args)
+		}
+
+		for key, want := range map[string]string{
+			"GITAT_REPO_PATH":      repo.Dir,
+			"GITAT_TRUNK":          "master",
+			"GITAT_BRANCH":         "feature-x",
+			"GITAT_CURRENT_BRANCH": "feature-x",
+			"GITAT_OUTPUT":         "text",
+			"GITAT_DRY_RUN":        "0",
+			"GITAT_PLUGIN":         "report",
+		} {
+			if env[key] != want {
+				t.Errorf("expected %s=%s, got %q", key, want, env[key])
+			}
+		}
+
+		var config map[string]interface{}
+		if err := json.Unmarshal([]byte(env["GITAT_CONFIG"]), &config); err != nil {
+			t.Fatalf("GITAT_CONFIG is not JSON: %v", err)
+		}
+		if config["at.trunk"] != "master" {
+			t.Errorf("expected at.trunk in GITAT_CONFIG, got %v", config)
+		}
+		if reviewers, _ := config["at.reviewers"].([]interface{}); len(reviewers) != 2 {
+			t.Errorf("expected at.reviewers to be a list, got %v", config["at.reviewers"])
+		}
+	})
+
+	t.Run("exit status", func(t *testing.T) {
+		t.Setenv("EXIT", "3")
+		plugin, _ := manager.FindPlugin("report")
+		err := manager.RunPlugin(plugin, nil)
+		if code := errs.ExitCode(err); code != 3 {
+			t.Errorf("expected exit status 3, got %d (%v)", code, err)
+		}
+	})
+
+	t.Run("untrusted repository", func(t *testing.T) {
+		plugin, _ := manager.FindPlugin("local")
+		if err := manager.RunPlugin(plugin, nil); !errs.Is(err, errs.PolicyViolation) {
+			t.Errorf("expected a policy violation, got %v", err)
+		}
+
+		repo.Config("at.plugins.trusted", "true")
+		plugin, _ = manager.FindPlugin("local")
+		if err := manager.RunPlugin(plugin, nil); err != nil {
+			t.Errorf("expected the trusted plugin to run, got %v", err)
+		}
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		if _, ok := manager.FindPlugin("../report"); ok {
+			t.Error("expected a path not to name a plugin")
+		}
+	})
+}
+
 // cleanupTest cleans up the test environment
 func cleanupTest(t *testing.T, manager *Manager) {
 	if manager.config.RepoPath != "" {
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/picker"
+)
+
+// branchChoice is a branch offered by the branch picker
+type branchChoice struct {
+	Name    string // as git knows it: feature-x, or origin/feature-x
+	Local   string // the name without the remote, for checkout and config
+	Remote  bool   // only on a remote
+	Type    string
+	Age     string // of the last commit, e.g. "2 days ago"
+	Subject string // of the last commit
+	Ahead   int    // commits of the branch missing on HEAD
+	Behind  int    // commits of HEAD missing on the branch
+}
+
+// branchChoices returns the local branches and the remote branches without
+// a local one, most recently committed first. The current branch is left
+// out.
+func (m *Manager) branchChoices() ([]branchChoice, error) {
+	out, err := m.git.Run("for-each-ref", "--sort=-committerdate",
+		"--format=%(refname)%00%(committerdate:relative)%00%(contents:subject)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	currentBranch, _ := m.git.GetCurrentBranch()
+
+	var locals, remotes []branchChoice
+	local := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		choice := branchChoice{Age: fields[1], Subject: fields[2]}
+		switch ref := fields[0]; {
+		case strings.HasPrefix(ref, "refs/heads/"):
+			choice.Name = strings.TrimPrefix(ref, "refs/heads/")
+			choice.Local = choice.Name
+			local[choice.Name] = true
+			if choice.Name != currentBranch {
+				locals = append(locals, choice)
+			}
+		case strings.HasPrefix(ref, "refs/remotes/") && !strings.HasSuffix(ref, "/HEAD"):
+			choice.Name = strings.TrimPrefix(ref, "refs/remotes/")
+			_, choice.Local, _ = strings.Cut(choice.Name, "/")
+			choice.Remote = true
+			remotes = append(remotes, choice)
+		}
+	}
+
+	choices := locals
+	for _, choice := range remotes {
+		if !local[choice.Local] && choice.Local != currentBranch {
+			choices = append(choices, choice)
+		}
+	}
+	for i := range choices {
+		choice := &choices[i]
+		choice.Type = m.getWorkType(choice.Local)
+		// "<branch only> <HEAD only>"
+		if counts, err := m.git.Run("rev-list", "--left-right", "--count", choice.Name+"...HEAD"); err == nil {
+			fmt.Sscanf(counts, "%d %d", &choice.Ahead, &choice.Behind)
+		}
+	}
+	return choices, nil
+}
+
+// canPickBranch reports whether an omitted branch can be asked for: stdin
+// is a terminal and prompts are allowed, see SetInteractive
+func (m *Manager) canPickBranch() bool {
+	return m.picker != nil && !m.batch
+}
+
+// pickBranch asks for one of the branches kept by keep (nil keeps all) with
+// the picker, starting on preselect. It returns false when the picker is not
+// available or there is no branch to pick, leaving the command to its
+// default.
+func (m *Manager) pickBranch(title, preselect string, keep func(branchChoice) bool) (branchChoice, bool, error) {
+	if !m.canPickBranch() {
+		return branchChoice{}, false, nil
+	}
+	all, err := m.branchChoices()
+	if err != nil {
+		return branchChoice{}, false, err
+	}
+	var choices []branchChoice
+	for _, choice := range all {
+		if keep == nil || keep(choice) {
+			choices = append(choices, choice)
+		}
+	}
+	if len(choices) == 0 {
+		return branchChoice{}, false, nil
+	}
+
+	items := make([]picker.Item, len(choices))
+	selected := 0
+	for i, choice := range choices {
+		items[i] = picker.Item{Name: choice.Name, Detail: choice.detail()}
+		if choice.Name == preselect {
+			selected = i
+		}
+	}
+	i, err := m.picker(title, items, selected)
+	if err != nil {
+		return branchChoice{}, true, err
+	}
+	return choices[i], true, nil
+}
+
+// detail returns the columns shown next to the name in the picker
+func (c branchChoice) detail() string {
+	workType := c.Type
+	if workType == "" {
+		workType = "-"
+	}
+	return fmt.Sprintf("%-8s %-15s %-9s %s", workType, c.Age, fmt.Sprintf("↑%d ↓%d", c.Ahead, c.Behind), c.Subject)
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/potsed/gitAT/internal/git"
+)
+
+// SetVerbose makes the manager write every git command it runs, with its
+// duration, to w. A nil w turns it off.
+func (m *Manager) SetVerbose(w io.Writer) {
+	if repo, ok := m.git.(*git.Repository); ok {
+		m.git = repo.WithTrace(w)
+	}
+}
+
+// SetDryRun makes the manager record its mutating git commands, platform
+// CLI calls and file writes in plan instead of running them. A nil plan
+// turns it off.
+func (m *Manager) SetDryRun(plan *git.Planner) {
+	m.plan = plan
+	if repo, ok := m.git.(*git.Repository); ok {
+		m.git = repo.WithPlanner(plan)
+	}
+}
+
+// Plan returns the plan of a dry run, or nil when commands run for real
+func (m *Manager) Plan() *git.Planner {
+	return m.plan
+}
+
+// dryRun reports whether changes are planned instead of made
+func (m *Manager) dryRun() bool {
+	return m.plan != nil
+}
+
+// cliMutates reports whether a platform CLI call (gh, glab) changes
+// something on the platform
+func cliMutates(args []string) bool {
+	if len(args) < 2 {
+		return false
+	}
+	switch args[0] {
+	case "pr", "mr", "repo", "issue", "release":
+		switch args[1] {
+		case "create", "edit", "update", "ready", "merge", "close", "reopen", "delete", "comment":
+			return true
+		}
+	}
+	return false
+}
+
+// writeFile writes a file, or plans the write in a dry run
+func (m *Manager) writeFile(path string, data []byte) error {
+	if m.dryRun() {
+		m.plan.Add("write %s", path)
+		return nil
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// appendFile appends data to a file, creating the file and its directory
+// when needed, or plans the write in a dry run
+func (m *Manager) appendFile(path string, data []byte) error {
+	if m.dryRun() {
+		m.plan.Add("append to %s", path)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", path, err)
+	}
+	return nil
+}
+
+// removeFile removes a file if it exists, or plans the removal in a dry run
+func (m *Manager) removeFile(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if m.dryRun() {
+		m.plan.Add("remove %s", path)
+		return nil
+	}
+	return os.Remove(path)
+}
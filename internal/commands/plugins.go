@@ -0,0 +1,319 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// Plugins are executables named git-@-<name>. git @ <name> runs the plugin
+// when <name> is not a built-in command, passing the rest of the arguments
+// and the GITAT_* environment of pluginEnv.
+//
+// Plugins are looked up in .gitat/plugins of the repository, then on PATH.
+// A repository plugin comes with the clone, so it only runs once the
+// repository is trusted with at.plugins.trusted, which is set locally and
+// never cloned.
+
+const (
+	pluginPrefix = "git-@-"
+	pluginDir    = ".gitat/plugins"
+)
+
+// pluginName matches the names plugins can be run by
+var pluginName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Plugin is an installed plugin
+type Plugin struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Source  string `json:"source"` // "repository" or "path"
+	Trusted bool   `json:"trusted"`
+	Shadows string `json:"shadows,omitempty"` // path of a plugin hidden by this one
+}
+
+// InstalledPlugins returns the plugins by name. Repository plugins come
+// before PATH, and earlier PATH entries hide later ones, as when running.
+func (m *Manager) InstalledPlugins() []Plugin {
+	var plugins []Plugin
+	index := make(map[string]int)
+	for _, dir := range m.pluginDirs() {
+		entries, err := os.ReadDir(dir.path)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := strings.TrimPrefix(entry.Name(), pluginPrefix)
+			if name == entry.Name() || !pluginName.MatchString(name) {
+				continue
+			}
+			path := filepath.Join(dir.path, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			if i, ok := index[name]; ok {
+				if plugins[i].Shadows == "" {
+					plugins[i].Shadows = path
+				}
+				continue
+			}
+			index[name] = len(plugins)
+			plugins = append(plugins, Plugin{Name: name, Path: path, Source: dir.source, Trusted: dir.trusted})
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// FindPlugin returns the plugin run by git @ <name>
+func (m *Manager) FindPlugin(name string) (Plugin, bool) {
+	if !pluginName.MatchString(name) {
+		return Plugin{}, false
+	}
+	for _, dir := range m.pluginDirs() {
+		path := filepath.Join(dir.path, pluginPrefix+name)
+		if isExecutable(path) {
+			return Plugin{Name: name, Path: path, Source: dir.source, Trusted: dir.trusted}, true
+		}
+	}
+	return Plugin{}, false
+}
+
+// pluginLocation is a directory searched for plugins
+type pluginLocation struct {
+	path    string
+	source  string
+	trusted bool
+}
+
+// pluginDirs returns the directories searched for plugins, in order
+func (m *Manager) pluginDirs() []pluginLocation {
+	var dirs []pluginLocation
+	if m.config.RepoPath != "" {
+		trusted, _ := m.git.GetConfig("at.plugins.trusted")
+		dirs = append(dirs, pluginLocation{
+			path:    filepath.Join(m.config.RepoPath, pluginDir),
+			source:  "repository",
+			trusted: trusted == "true",
+		})
+	}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			dir = "."
+		}
+		dirs = append(dirs, pluginLocation{path: dir, source: "path", trusted: true})
+	}
+	return dirs
+}
+
+// isExecutable reports whether path is an executable file
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
+
+// RunPlugin runs a plugin with args, connected to the terminal. A plugin
+// failing with exit status N makes git @ exit with N.
+func (m *Manager) RunPlugin(plugin Plugin, args []string) error {
+	if !plugin.Trusted {
+		return errs.New(errs.PolicyViolation,
+			"Plugin %s comes from the repository and is not trusted. Review %s, then allow repository plugins with: git config at.plugins.trusted true",
+			plugin.Name, plugin.Path)
+	}
+
+	ctx := m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// A dry run is up to the plugin, see GITAT_DRY_RUN
+	if m.dryRun() {
+		m.plan.Add("ran plugin %s with GITAT_DRY_RUN=1", filepath.Base(plugin.Path))
+	}
+
+	cmd := exec.CommandContext(ctx, plugin.Path, args...)
+	cmd.Env = append(os.Environ(), m.pluginEnv(plugin)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return errs.Wrap(errs.Cancelled, ctx.Err(), "Plugin %s cancelled", plugin.Name)
+	case errors.As(err, &exitErr):
+		return &errs.Exit{Name: filepath.Base(plugin.Path), Code: exitErr.ExitCode()}
+	default:
+		return fmt.Errorf("failed to run plugin %s: %w", plugin.Name, err)
+	}
+}
+
+// pluginEnv returns the environment passed to plugins. The variables are
+// documented in the help of git @ plugins.
+func (m *Manager) pluginEnv(plugin Plugin) []string {
+	outputMode := "text"
+	if output.JSONEnabled() {
+		outputMode = "json"
+	}
+	flag := func(on bool) string {
+		if on {
+			return "1"
+		}
+		return "0"
+	}
+
+	env := []string{
+		"GITAT_PLUGIN=" + plugin.Name,
+		"GITAT_REPO_PATH=" + m.config.RepoPath,
+		"GITAT_OUTPUT=" + outputMode,
+		"GITAT_DRY_RUN=" + flag(m.dryRun()),
+		"GITAT_VERBOSE=" + flag(m.config.Verbose),
+	}
+	if m.config.RepoPath == "" {
+		return append(env, "GITAT_TRUNK=", "GITAT_BRANCH=", "GITAT_CURRENT_BRANCH=", "GITAT_CONFIG={}")
+	}
+
+	branch, _ := m.git.GetConfig("at.branch")
+	current, _ := m.git.GetCurrentBranch()
+	config, _ := json.Marshal(m.Config())
+	return append(env,
+		"GITAT_TRUNK="+m.trunkBranch(),
+		"GITAT_BRANCH="+branch,
+		"GITAT_CURRENT_BRANCH="+current,
+		"GITAT_CONFIG="+string(config),
+	)
+}
+
+// Config returns the at.* configuration by key. Keys set several times
+// (git config --add) map to the list of their values.
+func (m *Manager) Config() map[string]interface{} {
+	config := make(map[string]interface{})
+	out, err := m.git.Run("config", "--get-regexp", "^at.")
+	if err != nil || out == "" {
+		return config
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch existing := config[key].(type) {
+		case nil:
+			config[key] = value
+		case string:
+			config[key] = []string{existing, value}
+		case []string:
+			config[key] = append(existing, value)
+		}
+	}
+	return config
+}
+
+// Plugins handles the plugins command
+func (m *Manager) Plugins(args []string) error {
+	path := []string{"plugins"}
+	if len(args) > 0 {
+		if sub := command("plugins").Subcommand(args[0]); sub != nil {
+			path, args = append(path, sub.Name), args[1:]
+		}
+	}
+	cmd := command(path...)
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help(path...)
+	}
+	if len(path) == 1 && len(flags.Args()) > 0 {
+		return errs.New(errs.Usage, "Unknown plugins command '%s' (see 'git @ plugins --help')", flags.Args()[0])
+	}
+	if err := cmd.extraArgs(flags, 0); err != nil {
+		return err
+	}
+
+	plugins := m.InstalledPlugins()
+	if output.JSONEnabled() {
+		if plugins == nil {
+			plugins = []Plugin{}
+		}
+		return output.JSON(map[string]interface{}{"plugins": plugins})
+	}
+
+	if len(plugins) == 0 {
+		output.Info("No plugins installed. Put a git-@-<name> executable on PATH or in %s", pluginDir)
+		return nil
+	}
+
+	output.Title("🔌 Plugins")
+	var rows [][]string
+	for _, plugin := range plugins {
+		source := plugin.Source
+		if !plugin.Trusted {
+			source += " (untrusted)"
+		}
+		rows = append(rows, []string{plugin.Name, source, plugin.Path})
+	}
+	output.Table([]string{"Name", "Source", "Path"}, rows)
+
+	for _, plugin := range plugins {
+		if !plugin.Trusted {
+			output.Info("Repository plugins run after: git config at.plugins.trusted true")
+			break
+		}
+	}
+	return nil
+}
+
+func pluginsCommand() *Command {
+	return &Command{
+		Name:    "plugins",
+		Hint:    "[list]",
+		Summary: "List the installed git-@-<name> plugins",
+		Description: `Plugins add commands to git @ without forking it. 'git @ <name>' runs the
+executable git-@-<name> when <name> is not a built-in command, with the
+remaining arguments. Plugins are looked up in .gitat/plugins of the
+repository, then on PATH; the first one found wins.
+
+Repository plugins come with the clone, so they only run after you have
+reviewed them and trusted the repository:
+  git config at.plugins.trusted true`,
+		Subcommands: []*Command{
+			{Name: "list", Aliases: []string{"ls"}, Summary: "List the plugins, where they come from and whether they run (default)"},
+		},
+		Examples: []Example{
+			{"git @ plugins", "List the installed plugins"},
+			{"git @ plugins list --json", "List them as JSON"},
+			{"git @ deploy staging", "Run git-@-deploy with the argument staging"},
+		},
+		Sections: []Section{
+			{"ENVIRONMENT", `Plugins run with these variables set:
+  GITAT_PLUGIN           Name of the plugin, as in git @ <name>
+  GITAT_REPO_PATH        Root of the repository, empty outside one
+  GITAT_TRUNK            Trunk branch (at.trunk)
+  GITAT_BRANCH           Working branch (at.branch)
+  GITAT_CURRENT_BRANCH   Checked out branch, empty on a detached HEAD
+  GITAT_CONFIG           at.* configuration as a JSON object; keys set
+                         several times map to an array of values
+  GITAT_OUTPUT           text, or json with --json
+  GITAT_DRY_RUN          1 with --dry-run: plan changes, do not make them
+  GITAT_VERBOSE          1 with --verbose`},
+			{"EXIT STATUS", `git @ exits with the exit status of the plugin.`},
+			{"WRITING A PLUGIN", `#!/bin/sh
+# .gitat/plugins/git-@-hello
+echo "Hello from $GITAT_CURRENT_BRANCH (trunk: $GITAT_TRUNK)"`},
+		},
+		NoRepo: true,
+		Run:    (*Manager).Plugins,
+	}
+}
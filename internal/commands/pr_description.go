@@ -0,0 +1,372 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fileChange is a file changed by a PR, as reported by git diff --numstat
+type fileChange struct {
+	Path      string
+	OldPath   string // set for renames
+	Status    string // A, M, D or R
+	Additions int
+	Deletions int
+	Binary    bool
+}
+
+// prCommit is a commit included in a PR
+type prCommit struct {
+	Hash    string
+	Subject string
+}
+
+// issueLink is an issue referenced by a PR
+type issueLink struct {
+	Key     string
+	URL     string
+	Keyword string // Closes or Refs
+}
+
+// prDescription holds everything rendered in a generated PR description
+type prDescription struct {
+	Head    string
+	Base    string
+	Files   []fileChange
+	Commits []prCommit
+	Issues  []issueLink
+	Emoji   bool
+}
+
+// commitGroup is a section of the commit list
+type commitGroup struct {
+	key   string
+	title string
+	emoji string
+}
+
+// commitGroups lists the commit sections in display order
+var commitGroups = []commitGroup{
+	{"feature", "Features", "✨"},
+	{"bugfix", "Bug Fixes", "🐛"},
+	{"hotfix", "Hotfixes", "🚑"},
+	{"perf", "Performance", "⚡"},
+	{"refactor", "Refactoring", "♻️"},
+	{"docs", "Documentation", "📚"},
+	{"style", "Style", "💄"},
+	{"test", "Tests", "✅"},
+	{"build", "Build", "📦"},
+	{"ci", "CI", "👷"},
+	{"chore", "Chores", "🔧"},
+	{"release", "Releases", "🔖"},
+	{"revert", "Reverts", "⏪"},
+	{"other", "Other Changes", "📌"},
+}
+
+// commitTypeAliases maps Conventional Commit types to gitAT work types
+var commitTypeAliases = map[string]string{
+	"feat":     "feature",
+	"fix":      "bugfix",
+	"bug":      "bugfix",
+	"docs":     "docs",
+	"doc":      "docs",
+	"tests":    "test",
+	"chores":   "chore",
+	"refactor": "refactor",
+}
+
+var (
+	conventionalCommitPattern = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?!?:\s`)
+	gitatCommitPattern        = regexp.MustCompile(`^\[([A-Z]+)\]\s`)
+)
+
+// commitType returns the commit group key of a commit subject, based on its
+// Conventional Commit type or gitAT [TYPE] prefix
+func commitType(subject string) string {
+	var kind string
+	if match := conventionalCommitPattern.FindStringSubmatch(subject); match != nil {
+		kind = strings.ToLower(match[1])
+	} else if match := gitatCommitPattern.FindStringSubmatch(subject); match != nil {
+		kind = strings.ToLower(match[1])
+	}
+
+	if alias, ok := commitTypeAliases[kind]; ok {
+		kind = alias
+	}
+	for _, group := range commitGroups {
+		if group.key == kind {
+			return kind
+		}
+	}
+	return "other"
+}
+
+// generateAutoDescription builds a PR description from the changes between
+// baseBranch and currentBranch
+func (m *Manager) generateAutoDescription(baseBranch, currentBranch string, emoji bool) string {
+	revRange := fmt.Sprintf("%s...%s", baseBranch, currentBranch)
+
+	numstat, err := m.git.Run("diff", "-M", "--numstat", "-z", revRange)
+	if err != nil {
+		return "No files changed compared to " + baseBranch
+	}
+	nameStatus, _ := m.git.Run("diff", "-M", "--name-status", "-z", revRange)
+
+	log, _ := m.git.Run("log", "--reverse", "--format=%h %s", fmt.Sprintf("%s..%s", baseBranch, currentBranch))
+
+	desc := prDescription{
+		Head:    currentBranch,
+		Base:    baseBranch,
+		Files:   parseNumstat(numstat, parseNameStatus(nameStatus)),
+		Commits: parseCommitLog(log),
+		Emoji:   emoji,
+	}
+	desc.Issues = m.prIssues(currentBranch, desc.Commits)
+	return desc.render()
+}
+
+// useDescriptionEmoji reports whether generated descriptions use emojis
+func (m *Manager) useDescriptionEmoji(opts prOptions) bool {
+	if opts.noEmoji {
+		return false
+	}
+	setting, _ := m.git.GetConfig("at.pr.emoji")
+	return setting != "false"
+}
+
+// parseNameStatus parses git diff --name-status -z into a path → status map
+func parseNameStatus(output string) map[string]string {
+	statuses := make(map[string]string)
+	fields := strings.Split(output, "\x00")
+
+	for i := 0; i < len(fields); i++ {
+		status := fields[i]
+		if status == "" {
+			continue
+		}
+
+		// Renames and copies list the old and new paths
+		if status[0] == 'R' || status[0] == 'C' {
+			if i+2 < len(fields) {
+				statuses[fields[i+2]] = status[:1]
+			}
+			i += 2
+			continue
+		}
+
+		if i+1 < len(fields) {
+			statuses[fields[i+1]] = status[:1]
+		}
+		i++
+	}
+
+	return statuses
+}
+
+// parseNumstat parses git diff --numstat -z, sorted by path
+func parseNumstat(output string, statuses map[string]string) []fileChange {
+	var files []fileChange
+	fields := strings.Split(output, "\x00")
+
+	for i := 0; i < len(fields); i++ {
+		parts := strings.SplitN(fields[i], "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		change := fileChange{Path: parts[2], Status: "M"}
+
+		// Binary files report - for both counts
+		if parts[0] == "-" && parts[1] == "-" {
+			change.Binary = true
+		} else {
+			change.Additions, _ = strconv.Atoi(parts[0])
+			change.Deletions, _ = strconv.Atoi(parts[1])
+		}
+
+		// Renames have an empty path followed by the old and new paths
+		if change.Path == "" && i+2 < len(fields) {
+			change.OldPath = fields[i+1]
+			change.Path = fields[i+2]
+			change.Status = "R"
+			i += 2
+		} else if status, ok := statuses[change.Path]; ok {
+			change.Status = status
+		}
+
+		files = append(files, change)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+// parseCommitLog parses git log --format="%h %s"
+func parseCommitLog(output string) []prCommit {
+	var commits []prCommit
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+		hash, subject, _ := strings.Cut(line, " ")
+		commits = append(commits, prCommit{Hash: hash, Subject: subject})
+	}
+	return commits
+}
+
+// icon returns the emoji followed by a space, or "" when emojis are disabled
+func (d prDescription) icon(emoji string) string {
+	if !d.Emoji {
+		return ""
+	}
+	return emoji + " "
+}
+
+// statusLabel returns the display label of a file status
+func (d prDescription) statusLabel(status string) string {
+	switch status {
+	case "A":
+		return d.icon("➕") + "added"
+	case "D":
+		return d.icon("🗑️") + "deleted"
+	case "R":
+		return d.icon("🔀") + "renamed"
+	default:
+		return d.icon("✏️") + "modified"
+	}
+}
+
+// markdown returns the issue as a Markdown link, or as #n for numeric keys
+// that GitHub and GitLab link themselves
+func (i issueLink) markdown() string {
+	if i.URL != "" {
+		return fmt.Sprintf("[%s](%s)", i.Key, i.URL)
+	}
+	if _, err := strconv.Atoi(i.Key); err == nil {
+		return "#" + i.Key
+	}
+	return i.Key
+}
+
+// render returns the description as Markdown. The output only depends on
+// the description content, so re-running pr yields the same text.
+func (d prDescription) render() string {
+	if len(d.Files) == 0 {
+		return "No files changed compared to " + d.Base
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %sPull Request Summary\n\n", d.icon("📋"))
+	fmt.Fprintf(&b, "This PR contains changes from branch `%s` targeting `%s`.\n\n", d.Head, d.Base)
+
+	// Issues, with the keyword platforms use to close them on merge
+	if len(d.Issues) > 0 {
+		fmt.Fprintf(&b, "## %sRelated Issues\n\n", d.icon("🎫"))
+		for _, issue := range d.Issues {
+			fmt.Fprintf(&b, "- %s %s\n", issue.Keyword, issue.markdown())
+		}
+		b.WriteString("\n")
+	}
+
+	// Overview
+	counts := make(map[string]int)
+	additions, deletions := 0, 0
+	for _, file := range d.Files {
+		counts[file.Status]++
+		additions += file.Additions
+		deletions += file.Deletions
+	}
+
+	fmt.Fprintf(&b, "## %sChanges Overview\n\n", d.icon("📊"))
+	b.WriteString("| Metric | Count |\n")
+	b.WriteString("|--------|-------|\n")
+	fmt.Fprintf(&b, "| **Total Files** | %d |\n", len(d.Files))
+	for _, status := range []struct{ code, name string }{{"A", "Added"}, {"M", "Modified"}, {"R", "Renamed"}, {"D", "Deleted"}} {
+		if counts[status.code] > 0 {
+			fmt.Fprintf(&b, "| **%s** | %d |\n", status.name, counts[status.code])
+		}
+	}
+	fmt.Fprintf(&b, "| **Lines** | +%d / -%d |\n\n", additions, deletions)
+
+	// Directories
+	dirSet := make(map[string]bool)
+	for _, file := range d.Files {
+		dir := "root"
+		if idx := strings.LastIndex(file.Path, "/"); idx >= 0 {
+			dir = file.Path[:idx]
+		}
+		dirSet[dir] = true
+	}
+	if len(dirSet) > 1 {
+		dirs := make([]string, 0, len(dirSet))
+		for dir := range dirSet {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+
+		fmt.Fprintf(&b, "### %sDirectories Affected\n\n", d.icon("📂"))
+		for _, dir := range dirs {
+			fmt.Fprintf(&b, "- `%s`\n", dir)
+		}
+		b.WriteString("\n")
+	}
+
+	// Files
+	fmt.Fprintf(&b, "## %sChanged Files\n\n", d.icon("📝"))
+	fmt.Fprintf(&b, "<details>\n<summary>%s%d changed files</summary>\n\n", d.icon("📋"), len(d.Files))
+	b.WriteString("| Status | File | Additions | Deletions |\n")
+	b.WriteString("|--------|------|----------:|----------:|\n")
+	for _, file := range d.Files {
+		path := fmt.Sprintf("`%s`", file.Path)
+		if file.OldPath != "" {
+			path = fmt.Sprintf("`%s` → `%s`", file.OldPath, file.Path)
+		}
+
+		if file.Binary {
+			fmt.Fprintf(&b, "| %s | %s | binary | binary |\n", d.statusLabel(file.Status), path)
+		} else {
+			fmt.Fprintf(&b, "| %s | %s | +%d | -%d |\n", d.statusLabel(file.Status), path, file.Additions, file.Deletions)
+		}
+	}
+	b.WriteString("\n</details>\n\n")
+
+	// Commits, grouped by type in chronological order
+	if len(d.Commits) > 0 {
+		grouped := make(map[string][]prCommit)
+		for _, commit := range d.Commits {
+			kind := commitType(commit.Subject)
+			grouped[kind] = append(grouped[kind], commit)
+		}
+
+		noun := "commits"
+		if len(d.Commits) == 1 {
+			noun = "commit"
+		}
+
+		fmt.Fprintf(&b, "## %sCommits\n\n", d.icon("🔄"))
+		fmt.Fprintf(&b, "This PR includes **%d %s**.\n\n", len(d.Commits), noun)
+		for _, group := range commitGroups {
+			commits := grouped[group.key]
+			if len(commits) == 0 {
+				continue
+			}
+
+			fmt.Fprintf(&b, "### %s%s\n\n", d.icon(group.emoji), group.title)
+			for _, commit := range commits {
+				fmt.Fprintf(&b, "- `%s` %s\n", commit.Hash, commit.Subject)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("---\n\n")
+	b.WriteString(autoDescriptionFooter + " based on the changes in this PR.*\n")
+
+	return b.String()
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/internal/provider"
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// prBackend returns the provider backend for the origin remote
+func (m *Manager) prBackend() (provider.Provider, error) {
+	platform := m.detectPlatform()
+
+	backend := provider.New(platform, m.runCLI)
+	if backend == nil {
+		return nil, fmt.Errorf("error: PR status is not supported for %s repositories\nSupported platforms: github (gh), gitlab (glab)", platform)
+	}
+
+	return backend, nil
+}
+
+// prStatusReport is the JSON document of a branch and its PR, which is null
+// when the branch has none
+type prStatusReport struct {
+	Branch string                `json:"branch"`
+	PR     *provider.PullRequest `json:"pr"`
+}
+
+// newPRStatusReport returns the report of branch, listing no checks as an
+// empty array rather than null
+func newPRStatusReport(branch string, pr *provider.PullRequest) prStatusReport {
+	if pr != nil && pr.Checks == nil {
+		pr.Checks = []provider.Check{}
+	}
+	return prStatusReport{Branch: branch, PR: pr}
+}
+
+// prStatus shows the open PR of the current branch
+func (m *Manager) prStatus(args []string) error {
+	if len(args) == 1 && (args[0] == "-h" || args[0] == "--help") {
+		return m.help("pr", "status")
+	}
+
+	currentBranch, err := m.git.GetCurrentBranch()
+	if err != nil {
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+
+	backend, err := m.prBackend()
+	if err != nil {
+		return err
+	}
+
+	pr, err := backend.Find(currentBranch)
+	if err != nil {
+		return err
+	}
+
+	if output.JSONEnabled() {
+		return output.JSON(newPRStatusReport(currentBranch, pr))
+	}
+
+	if pr == nil {
+		fmt.Printf("No open PR for branch %s\n", currentBranch)
+		fmt.Println("Create one with: git @ pr")
+		return nil
+	}
+
+	output.Title(fmt.Sprintf("🔀 PR #%d: %s", pr.Number, pr.Title))
+	fmt.Printf("URL:       %s\n", pr.URL)
+	fmt.Printf("Branch:    %s → %s\n", pr.Head, pr.Base)
+	fmt.Printf("State:     %s\n", m.prStateLabel(pr))
+	fmt.Printf("Reviews:   %s\n", pr.ReviewSummary())
+	fmt.Printf("Checks:    %s\n", pr.CheckSummary())
+	fmt.Printf("Mergeable: %s\n", pr.Mergeable)
+
+	if len(pr.Checks) > 0 {
+		fmt.Println()
+		var rows [][]string
+		for _, check := range pr.Checks {
+			rows = append(rows, []string{check.Name, m.checkStateLabel(check.State)})
+		}
+		output.Table([]string{"Check", "Result"}, rows)
+	}
+
+	return nil
+}
+
+// prList shows the open PRs of all local work branches
+func (m *Manager) prList() error {
+	branches, err := m.git.GetBranches()
+	if err != nil {
+		return fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	backend, err := m.prBackend()
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	reports := []prStatusReport{}
+	for _, branch := range branches {
+		if m.getWorkType(branch) == "" {
+			continue
+		}
+
+		pr, err := backend.Find(branch)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, newPRStatusReport(branch, pr))
+
+		if pr == nil {
+			rows = append(rows, []string{branch, "-", "-", "-", "-", "-"})
+			continue
+		}
+
+		rows = append(rows, []string{
+			branch,
+			fmt.Sprintf("#%d", pr.Number),
+			m.prStateLabel(pr),
+			pr.ReviewSummary(),
+			pr.CheckSummary(),
+			pr.Mergeable,
+		})
+	}
+
+	if output.JSONEnabled() {
+		return output.JSON(map[string][]prStatusReport{"branches": reports})
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No work branches found")
+		return nil
+	}
+
+	output.Table([]string{"Branch", "PR", "State", "Reviews", "Checks", "Mergeable"}, rows)
+	return nil
+}
+
+// prStateLabel returns the display state of a PR
+func (m *Manager) prStateLabel(pr *provider.PullRequest) string {
+	if pr.Draft {
+		return "draft"
+	}
+	return strings.ToLower(pr.State)
+}
+
+// checkStateLabel returns the display label of a check state
+func (m *Manager) checkStateLabel(state string) string {
+	switch state {
+	case provider.CheckSuccess:
+		return "✅ " + state
+	case provider.CheckFailure:
+		return "❌ " + state
+	case provider.CheckPending:
+		return "⏳ " + state
+	default:
+		return "⏭️  " + state
+	}
+}
+
+func prStatusCommand() *Command {
+	return &Command{
+		Name:    "status",
+		Summary: "Show the PR, reviews and checks of the current branch",
+		Description: `Show the open Pull Request (PR) or Merge Request (MR) of the current branch,
+or of every local work branch, using the platform CLI (gh or glab).`,
+		Examples: []Example{
+			{"git @ pr status", "Show the PR of the current branch"},
+			{"git @ pr list", "Show PRs of all work branches"},
+		},
+		Sections: []Section{
+			{"OUTPUT", `- PR number, title and URL
+- Draft state
+- Review approvals and requested changes
+- CI check results
+- Mergeability`},
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/provider"
+)
+
+// Markers delimiting the generated part of a PR description. Text outside
+// the markers is written by the user and kept when the PR is updated.
+const (
+	autoDescriptionStart = "<!-- gitat:auto-description:start -->"
+	autoDescriptionEnd   = "<!-- gitat:auto-description:end -->"
+)
+
+// autoDescriptionFooter ends descriptions generated before the markers existed
+const autoDescriptionFooter = "*This description was automatically generated"
+
+// wrapAutoDescription surrounds a generated description with the markers
+func wrapAutoDescription(description string) string {
+	return autoDescriptionStart + "\n" + strings.TrimSpace(description) + "\n" + autoDescriptionEnd
+}
+
+// mergeAutoDescription replaces the generated block of an existing PR
+// description with generated, keeping everything the user wrote around it
+func mergeAutoDescription(existing, generated string) string {
+	block := wrapAutoDescription(generated)
+
+	start := strings.Index(existing, autoDescriptionStart)
+	end := strings.Index(existing, autoDescriptionEnd)
+	if start >= 0 && end > start {
+		return existing[:start] + block + existing[end+len(autoDescriptionEnd):]
+	}
+
+	// Nothing written by the user, or an unmarked description we generated
+	if strings.TrimSpace(existing) == "" || strings.Contains(existing, autoDescriptionFooter) {
+		return block
+	}
+
+	return strings.TrimRight(existing, "\n") + "\n\n" + block
+}
+
+// confirmPRUpdate asks whether the existing PR of a branch should be updated
+func (m *Manager) confirmPRUpdate(pr *provider.PullRequest) bool {
+	fmt.Printf("An open PR already exists for %s: #%d %s\n", pr.Head, pr.Number, pr.URL)
+	fmt.Print("Update it? (Y/n): ")
+
+	var confirmation string
+	fmt.Scanln(&confirmation)
+	return confirmation == "" || strings.HasPrefix(strings.ToLower(confirmation), "y")
+}
+
+// updatePR pushes the branch and refreshes the title, description and
+// metadata of an existing PR
+func (m *Manager) updatePR(backend provider.Provider, pr *provider.PullRequest, opts prOptions, title, description string) error {
+	fmt.Printf("Pushing %s...\n", pr.Head)
+	if err := m.git.Stream("push", "--force-with-lease", "origin", pr.Head); err != nil {
+		return fmt.Errorf("error: Failed to push %s: %w", pr.Head, err)
+	}
+
+	// Drafts are only converted when asked to
+	ready := opts.ready
+	if pr.Draft && !opts.ready && !opts.draft {
+		fmt.Print("PR is a draft. Mark it as ready for review? (y/N): ")
+
+		var confirmation string
+		fmt.Scanln(&confirmation)
+		ready = strings.HasPrefix(strings.ToLower(confirmation), "y")
+	}
+
+	updateOpts := provider.UpdateOptions{
+		Title:       title,
+		Description: description,
+		Ready:       ready,
+		Reviewers:   opts.reviewers,
+		Labels:      opts.labels,
+		Assignees:   opts.assignees,
+		Milestone:   opts.milestone,
+	}
+
+	if err := backend.Update(pr, updateOpts); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("✅ PR updated: %s\n", pr.URL)
+	if ready && pr.Draft {
+		fmt.Println("✅ PR marked as ready for review")
+	}
+
+	if opts.openBrowser && pr.URL != "" {
+		fmt.Println("Opening in browser...")
+		m.openURL(pr.URL)
+	}
+
+	return nil
+}
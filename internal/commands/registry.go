@@ -0,0 +1,301 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Command declares a git @ command. The registry of commands drives the
+// dispatch, the usage, the help of each command, shell completion and
+// docs/COMMANDS.md, so they cannot drift apart.
+type Command struct {
+	Name        string
+	Aliases     []string
+	Hint        string   // arguments shown after the name in the command list
+	Summary     string   // one line for the command list and completion
+	Usage       []string // synopses after "git @ <name>", one per form
+	Description string
+	Args        []Arg
+	Flags       []Flag
+	Subcommands []*Command
+	Examples    []Example
+	Sections    []Section // extra help sections, after the examples
+	Hidden      bool      // left out of the usage, completion and docs
+	NoRepo      bool      // runs outside a git repository
+	Run         func(m *Manager, args []string) error
+
+	parent *Command
+}
+
+// Arg declares a positional argument. Arguments with help are documented
+// under ARGUMENTS; the nth argument completes the nth positional.
+type Arg struct {
+	Name   string
+	Help   string
+	Values []string
+	Source string
+}
+
+// Example is a command line of the help, with an optional comment
+type Example struct {
+	Command string
+	Comment string
+}
+
+// Section is a titled block of text in the help
+type Section struct {
+	Title string
+	Body  string
+}
+
+var (
+	registryOnce sync.Once
+	registry     []*Command
+)
+
+// Commands returns the commands of the manager in the order of the usage
+func Commands() []*Command {
+	registryOnce.Do(func() {
+		registry = []*Command{
+			workCommand(),
+			hotfixCommand(),
+			saveCommand(),
+			squashCommand(),
+			prCommand(),
+			branchCommand(),
+			stackCommand(),
+			restackCommand(),
+			syncCommand(),
+			sweepCommand(),
+			worktreeCommand(),
+			infoCommand(),
+			hashCommand(),
+			productCommand(),
+			featureCommand(),
+			issueCommand(),
+			versionCommand(),
+			releaseCommand(),
+			masterCommand(),
+			switchCommand(),
+			wipCommand(),
+			timeCommand(),
+			changesCommand(),
+			logsCommand(),
+			labelCommand(),
+			idCommand(),
+			pathCommand(),
+			trunkCommand(),
+			ignoreCommand(),
+			initLocalCommand(),
+			initRemoteCommand(),
+			securityCommand(),
+			goCommand(),
+			aliasCommand(),
+			pluginsCommand(),
+		}
+		for _, c := range registry {
+			c.link()
+		}
+	})
+	return registry
+}
+
+// link points the subcommands of c at their parent
+func (c *Command) link() {
+	for _, sub := range c.Subcommands {
+		sub.parent = c
+		sub.link()
+	}
+}
+
+// FindCommand returns the command named name or one of its aliases, or nil
+func FindCommand(cmds []*Command, name string) *Command {
+	for _, c := range cmds {
+		if c.Name == name {
+			return c
+		}
+		for _, alias := range c.Aliases {
+			if alias == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// Subcommand returns the subcommand named name, or nil
+func (c *Command) Subcommand(name string) *Command {
+	return FindCommand(c.Subcommands, name)
+}
+
+// Path returns the name of the command after "git @", e.g. "pr status"
+func (c *Command) Path() string {
+	if c.parent != nil {
+		return c.parent.Path() + " " + c.Name
+	}
+	return c.Name
+}
+
+// AllFlags returns the flags of the command, with -h, --help last
+func (c *Command) AllFlags() []Flag {
+	return append(append([]Flag(nil), c.Flags...), helpFlag)
+}
+
+// command returns the registered command at path, e.g. "pr", "status"
+func command(path ...string) *Command {
+	c := FindCommand(Commands(), path[0])
+	for _, name := range path[1:] {
+		c = c.Subcommand(name)
+	}
+	return c
+}
+
+// help prints the help of the registered command at path, e.g. "pr", "status"
+func (m *Manager) help(path ...string) error {
+	fmt.Fprint(os.Stdout, command(path...).Help())
+	return nil
+}
+
+// isHelp reports whether args asks for the help of a command
+func isHelp(args []string) bool {
+	if len(args) != 1 {
+		return false
+	}
+	switch args[0] {
+	case "-h", "--help", "help", "h":
+		return true
+	}
+	return false
+}
+
+// Help returns the help of the command
+func (c *Command) Help() string {
+	var b strings.Builder
+
+	usage := c.Usage
+	if len(usage) == 0 {
+		usage = []string{c.Hint}
+	}
+	for i, line := range usage {
+		prefix := "       "
+		if i == 0 {
+			prefix = "Usage: "
+		}
+		fmt.Fprintf(&b, "%s%s\n", prefix, strings.TrimSpace("git @ "+c.Path()+" "+line))
+	}
+
+	if c.Description != "" {
+		writeSection(&b, "DESCRIPTION", c.Description)
+	}
+
+	var rows [][2]string
+	for _, arg := range c.Args {
+		if arg.Help != "" {
+			rows = append(rows, [2]string{arg.Name, arg.Help})
+		}
+	}
+	if len(rows) > 0 {
+		writeSection(&b, "ARGUMENTS", formatRows(rows, true))
+	}
+
+	rows = nil
+	for _, flag := range c.AllFlags() {
+		rows = append(rows, [2]string{flag.names(), flag.Help})
+	}
+	writeSection(&b, "OPTIONS", formatRows(rows, true))
+
+	if len(c.Subcommands) > 0 {
+		rows = nil
+		for _, sub := range c.Subcommands {
+			rows = append(rows, [2]string{strings.TrimSpace(sub.Name + " " + sub.Hint), sub.Summary})
+		}
+		writeSection(&b, "SUBCOMMANDS", formatRows(rows, true))
+	}
+
+	if len(c.Examples) > 0 {
+		rows = nil
+		for _, example := range c.Examples {
+			comment := ""
+			if example.Comment != "" {
+				comment = "# " + example.Comment
+			}
+			rows = append(rows, [2]string{example.Command, comment})
+		}
+		writeSection(&b, "EXAMPLES", formatRows(rows, false))
+	}
+
+	for _, section := range c.Sections {
+		writeSection(&b, section.Title, section.Body)
+	}
+	return b.String()
+}
+
+// writeSection writes a titled section, indenting its body
+func writeSection(b *strings.Builder, title, body string) {
+	fmt.Fprintf(b, "\n%s:\n", title)
+	for _, line := range strings.Split(strings.TrimRight(body, "\n"), "\n") {
+		if line == "" {
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(b, "  %s\n", line)
+	}
+}
+
+// helpWidth is the width the text of a help column is wrapped to
+const helpWidth = 78
+
+// formatRows aligns two columns, optionally wrapping the second one
+func formatRows(rows [][2]string, wrapText bool) string {
+	column := 0
+	for _, row := range rows {
+		if len(row[0]) > column && len(row[0]) < 40 {
+			column = len(row[0])
+		}
+	}
+	column += 2
+
+	var b strings.Builder
+	for _, row := range rows {
+		if row[1] == "" {
+			b.WriteString(row[0] + "\n")
+			continue
+		}
+		left := row[0]
+		if len(left) >= column {
+			// Too long for the column: the text goes below
+			b.WriteString(left + "\n")
+			left = ""
+		}
+		lines := []string{row[1]}
+		if wrapText {
+			lines = wrap(row[1], helpWidth-2-column)
+		}
+		for i, line := range lines {
+			if i > 0 {
+				left = ""
+			}
+			fmt.Fprintf(&b, "%-*s%s\n", column, left, line)
+		}
+	}
+	return b.String()
+}
+
+// wrap splits text into lines of at most width characters
+func wrap(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
@@ -0,0 +1,502 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// Stack metadata is stored per branch in git config:
+//
+//	branch.<name>.at-parent       the branch it was created from
+//	branch.<name>.at-parent-base  the parent commit it is based on
+//
+// The recorded base lets restack move only the branch's own commits with
+// git rebase --onto, even after the parent was rewritten or squash-merged.
+
+// trunkBranch returns the configured trunk branch
+func (m *Manager) trunkBranch() string {
+	trunk, _ := m.git.GetConfig("at.trunk")
+	if trunk == "" {
+		trunk = "main"
+	}
+	return trunk
+}
+
+// branchExists reports whether a local branch exists
+func (m *Manager) branchExists(branch string) bool {
+	_, err := m.git.Run("rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// getStackParent returns the recorded parent of a branch, or ""
+func (m *Manager) getStackParent(branch string) string {
+	parent, _ := m.git.GetConfig(fmt.Sprintf("branch.%s.at-parent", branch))
+	return parent
+}
+
+// setStackParent records parent as the parent of branch, based on the
+// current tip of parent
+func (m *Manager) setStackParent(branch, parent string) error {
+	base, err := m.getHeadSHA(parent)
+	if err != nil {
+		return errs.New(errs.NotFound, "Branch '%s' does not exist", parent)
+	}
+
+	if err := m.git.SetConfig(fmt.Sprintf("branch.%s.at-parent", branch), parent); err != nil {
+		return fmt.Errorf("failed to record parent branch: %w", err)
+	}
+	return m.git.SetConfig(fmt.Sprintf("branch.%s.at-parent-base", branch), base)
+}
+
+// getStackChildren returns the branches whose recorded parent is branch
+func (m *Manager) getStackChildren(branch string) []string {
+	out, err := m.git.Run("config", "--get-regexp", `^branch\..*\.at-parent$`)
+	if err != nil {
+		return nil
+	}
+
+	var children []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		key, parent, ok := strings.Cut(line, " ")
+		if !ok || parent != branch {
+			continue
+		}
+		child := strings.TrimSuffix(strings.TrimPrefix(key, "branch."), ".at-parent")
+		if m.branchExists(child) {
+			children = append(children, child)
+		}
+	}
+
+	sort.Strings(children)
+	return children
+}
+
+// getStackAncestors returns the recorded parents of branch, nearest first
+func (m *Manager) getStackAncestors(branch string) []string {
+	var ancestors []string
+	seen := map[string]bool{branch: true}
+
+	for parent := m.getStackParent(branch); parent != "" && !seen[parent]; parent = m.getStackParent(parent) {
+		ancestors = append(ancestors, parent)
+		seen[parent] = true
+	}
+	return ancestors
+}
+
+// stackBaseBranch returns the branch a PR of branch should target: its
+// recorded parent while that parent is still open, otherwise the trunk
+func (m *Manager) stackBaseBranch(branch string) string {
+	trunk := m.trunkBranch()
+
+	for _, parent := range m.getStackAncestors(branch) {
+		if parent == trunk {
+			break
+		}
+		if m.branchExists(parent) && !m.isStackParentMerged(parent) {
+			return parent
+		}
+	}
+	return trunk
+}
+
+// isStackParentMerged reports whether a parent branch has been merged into
+// the trunk, including rebase and squash merges that rewrote its commits.
+// Branches without commits of their own are never considered merged.
+func (m *Manager) isStackParentMerged(parent string) bool {
+	trunk := m.trunkBranch()
+	if parent == trunk {
+		return false
+	}
+
+	base, _ := m.git.GetConfig(fmt.Sprintf("branch.%s.at-parent-base", parent))
+	if _, err := m.git.Run("merge-base", "--is-ancestor", parent, trunk); err == nil {
+		return base == "" || m.hasOwnCommits(base, parent)
+	}
+
+	if base == "" {
+		mergeBase, err := m.git.Run("merge-base", trunk, parent)
+		if err != nil {
+			return false
+		}
+		base = strings.TrimSpace(mergeBase)
+	}
+	if !m.hasOwnCommits(base, parent) {
+		return false
+	}
+	return m.patchesInTrunk(trunk, base, parent)
+}
+
+// hasOwnCommits reports whether branch has commits on top of base
+func (m *Manager) hasOwnCommits(base, branch string) bool {
+	count, err := m.git.Run("rev-list", "--count", fmt.Sprintf("%s..%s", base, branch))
+	return err == nil && strings.TrimSpace(count) != "0"
+}
+
+// patchesInTrunk reports whether the changes of base..branch are on the
+// trunk under other commits, by patch-id: commit by commit after a rebase
+// merge, or as a whole after a squash merge
+func (m *Manager) patchesInTrunk(trunk, base, branch string) bool {
+	// git cherry marks the commits with an equivalent on the trunk with "-"
+	applied := func(head string) bool {
+		out, err := m.git.Run("cherry", trunk, head, base)
+		if err != nil || strings.TrimSpace(out) == "" {
+			return false
+		}
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			if !strings.HasPrefix(line, "-") {
+				return false
+			}
+		}
+		return true
+	}
+	if applied(branch) {
+		return true
+	}
+
+	// A squash merge matches a single trunk commit with the whole diff of
+	// the branch. Patch-ids are computed from the diffs, writing no objects.
+	diff, err := m.git.Run("diff", "--no-color", "--no-ext-diff", base, branch)
+	if err != nil || diff == "" {
+		return false
+	}
+	squashed := m.patchIDs(diff + "\n")
+	if len(squashed) != 1 {
+		return false
+	}
+	log, err := m.git.Run("log", "-p", "--no-merges", "--no-color", "--no-ext-diff", base+".."+trunk)
+	if err != nil || log == "" {
+		return false
+	}
+	for _, id := range m.patchIDs(log + "\n") {
+		if id == squashed[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// patchIDs returns the stable patch-ids of the patches in a diff or log -p
+func (m *Manager) patchIDs(patches string) []string {
+	out, err := m.git.RunWithInput(patches, "patch-id", "--stable")
+	if err != nil || out == "" {
+		return nil
+	}
+	var ids []string
+	for _, line := range strings.Split(out, "\n") {
+		if id, _, ok := strings.Cut(line, " "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Stack shows the stack of the current branch
+func (m *Manager) Stack(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "-h", "--help", "help", "h":
+			return m.help("stack")
+		case "parent":
+			return m.stackParent(args[1:])
+		default:
+			return errs.New(errs.Usage, "Unknown option '%s'", args[0])
+		}
+	}
+
+	currentBranch, err := m.git.GetCurrentBranch()
+	if err != nil {
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+
+	ancestors := m.getStackAncestors(currentBranch)
+	root := currentBranch
+	if len(ancestors) > 0 {
+		root = ancestors[len(ancestors)-1]
+	}
+
+	tree := m.stackTree(root, "", currentBranch)
+	if output.JSONEnabled() {
+		return output.JSON(map[string]interface{}{"current_branch": currentBranch, "stack": tree})
+	}
+
+	output.Title("📚 Stack")
+	fmt.Println(root)
+	printStackTree(tree, "")
+
+	if len(ancestors) == 0 && len(m.getStackChildren(currentBranch)) == 0 {
+		fmt.Println()
+		fmt.Println("No stack recorded for this branch.")
+		fmt.Println("Create stacked branches with: git @ work <type> <description>")
+		fmt.Println("Or set a parent with:         git @ stack parent <branch>")
+	}
+
+	return nil
+}
+
+// stackNode is a branch of a stack and the branches stacked on it
+type stackNode struct {
+	Branch   string      `json:"branch"`
+	Ahead    int         `json:"ahead"` // commits missing on the parent
+	Current  bool        `json:"current"`
+	Children []stackNode `json:"children"`
+}
+
+// stackTree returns branch, stacked on parent ("" for the root), with its
+// descendants
+func (m *Manager) stackTree(branch, parent, currentBranch string) stackNode {
+	node := stackNode{Branch: branch, Current: branch == currentBranch, Children: []stackNode{}}
+	if parent != "" {
+		if ahead, err := m.git.Run("rev-list", "--count", fmt.Sprintf("%s..%s", parent, branch)); err == nil {
+			node.Ahead, _ = strconv.Atoi(strings.TrimSpace(ahead))
+		}
+	}
+	for _, child := range m.getStackChildren(branch) {
+		node.Children = append(node.Children, m.stackTree(child, branch, currentBranch))
+	}
+	return node
+}
+
+// printStackTree prints the descendants of node as a tree
+func printStackTree(node stackNode, indent string) {
+	for i, child := range node.Children {
+		connector, childIndent := "├── ", "│   "
+		if i == len(node.Children)-1 {
+			connector, childIndent = "└── ", "    "
+		}
+
+		line := fmt.Sprintf("%s%s%s (%d ahead)", indent, connector, child.Branch, child.Ahead)
+		if child.Current {
+			line += " ← current"
+		}
+		fmt.Println(line)
+
+		printStackTree(child, indent+childIndent)
+	}
+}
+
+// stackParent shows or sets the parent of the current branch
+func (m *Manager) stackParent(args []string) error {
+	currentBranch, err := m.git.GetCurrentBranch()
+	if err != nil {
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+
+	if len(args) == 0 {
+		parent := m.getStackParent(currentBranch)
+		if output.JSONEnabled() {
+			return output.JSON(map[string]string{"branch": currentBranch, "parent": parent})
+		}
+		if parent == "" {
+			fmt.Printf("No parent recorded for %s\n", currentBranch)
+			return nil
+		}
+		fmt.Println(parent)
+		return nil
+	}
+
+	parent := args[0]
+	if parent == currentBranch {
+		return errs.New(errs.PolicyViolation, "A branch cannot be its own parent")
+	}
+	for _, ancestor := range m.getStackAncestors(parent) {
+		if ancestor == currentBranch {
+			return errs.New(errs.PolicyViolation, "'%s' is stacked on %s", parent, currentBranch)
+		}
+	}
+
+	// Keep the existing base so restack moves only this branch's commits
+	mergeBase, err := m.git.Run("merge-base", parent, currentBranch)
+	if err != nil {
+		return errs.New(errs.NotFound, "Branch '%s' does not exist", parent)
+	}
+	if err := m.git.SetConfig(fmt.Sprintf("branch.%s.at-parent", currentBranch), parent); err != nil {
+		return fmt.Errorf("failed to record parent branch: %w", err)
+	}
+	if err := m.git.SetConfig(fmt.Sprintf("branch.%s.at-parent-base", currentBranch), strings.TrimSpace(mergeBase)); err != nil {
+		return fmt.Errorf("failed to record parent branch: %w", err)
+	}
+
+	if output.JSONEnabled() {
+		return output.JSON(map[string]string{"branch": currentBranch, "parent": parent})
+	}
+	fmt.Printf("✅ %s is now stacked on %s\n", currentBranch, parent)
+	return nil
+}
+
+// Restack rebases the current branch and its descendants onto their parents
+func (m *Manager) Restack(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "-h", "--help", "help", "h":
+			return m.help("restack")
+		default:
+			return errs.New(errs.Usage, "Unknown option '%s'", args[0])
+		}
+	}
+
+	currentBranch, err := m.git.GetCurrentBranch()
+	if err != nil {
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+
+	status, err := m.git.Run("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+	if strings.TrimSpace(status) != "" {
+		return errs.New(errs.DirtyTree, "You have uncommitted changes. Commit or stash them before restacking")
+	}
+
+	// Restack the current branch first, then every descendant
+	queue := []string{currentBranch}
+	restacked := 0
+	for len(queue) > 0 {
+		branch := queue[0]
+		queue = queue[1:]
+
+		moved, err := m.restackBranch(branch)
+		if err != nil {
+			return err
+		}
+		if moved {
+			restacked++
+		}
+
+		queue = append(queue, m.getStackChildren(branch)...)
+	}
+
+	if _, err := m.git.Run("checkout", currentBranch); err != nil {
+		return errs.Wrap(errs.KindOf(err), err, "Failed to switch back to %s", currentBranch)
+	}
+
+	if restacked == 0 {
+		fmt.Println("✅ Stack is up to date")
+	} else {
+		fmt.Printf("✅ Restacked %d branch(es)\n", restacked)
+	}
+	return nil
+}
+
+// restackBranch rebases branch onto the tip of its parent, moving it to the
+// parent's parent when the parent was merged or deleted. It reports whether
+// the branch was rebased.
+func (m *Manager) restackBranch(branch string) (bool, error) {
+	parent := m.getStackParent(branch)
+	if parent == "" {
+		return false, nil
+	}
+
+	newParent, oldBase, err := m.restackTarget(branch)
+	if err != nil {
+		return false, err
+	}
+
+	newBase, err := m.getHeadSHA(newParent)
+	if err != nil {
+		return false, errs.New(errs.NotFound, "Parent branch '%s' does not exist", newParent)
+	}
+	if oldBase == newBase && newParent == parent {
+		return false, nil
+	}
+
+	fmt.Printf("Rebasing %s onto %s...\n", branch, newParent)
+	if _, err := m.git.Run("rebase", "--onto", newParent, oldBase, branch); err != nil {
+		if m.operationInProgress() == "" {
+			return false, err
+		}
+		output.Info("Resolve the conflicts, run 'git rebase --continue', then run 'git @ restack' again")
+		return false, errs.Wrap(errs.Conflict, err, "Conflicts while rebasing %s onto %s", branch, newParent)
+	}
+
+	if err := m.setStackParent(branch, newParent); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// restackTarget returns the branch that branch should be based on, skipping
+// merged and deleted parents, and the commit it is currently based on
+func (m *Manager) restackTarget(branch string) (string, string, error) {
+	trunk := m.trunkBranch()
+
+	newParent := m.getStackParent(branch)
+	if newParent == "" {
+		newParent = trunk
+	}
+	for newParent != trunk && (!m.branchExists(newParent) || m.isStackParentMerged(newParent)) {
+		next := m.getStackParent(newParent)
+		if next == "" {
+			next = trunk
+		}
+		fmt.Printf("Parent %s of %s is merged or deleted, moving onto %s\n", newParent, branch, next)
+		newParent = next
+	}
+
+	oldBase, _ := m.git.GetConfig(fmt.Sprintf("branch.%s.at-parent-base", branch))
+	if oldBase == "" {
+		mergeBase, err := m.git.Run("merge-base", newParent, branch)
+		if err != nil {
+			return "", "", fmt.Errorf("error: Cannot find merge base of %s and %s", branch, newParent)
+		}
+		oldBase = strings.TrimSpace(mergeBase)
+	}
+
+	return newParent, oldBase, nil
+}
+
+func stackCommand() *Command {
+	return &Command{
+		Name:    "stack",
+		Summary: "Show the stack of parent and child branches",
+		Usage:   []string{"", "parent [<branch>]"},
+		Description: `Show the stack of the current branch: the chain of parent branches it was
+created from and every branch stacked on top of it.
+
+'git @ work' records the branch it was created from as its parent, so
+building feature B on top of unmerged feature A creates a stack. 'git @ pr'
+targets the parent branch until it is merged.`,
+		Subcommands: []*Command{
+			{Name: "parent", Hint: "[<branch>]", Summary: "Show the parent of the current branch, or stack it on <branch>",
+				Args: []Arg{{Name: "<branch>", Source: SourceBranches}}},
+		},
+		Examples: []Example{
+			{"git @ work feature auth-api", "Create feature-auth-api from trunk"},
+			{"git @ work feature auth-ui", "Create feature-auth-ui on top of it"},
+			{"git @ stack", "Show the stack"},
+			{"git @ stack parent feature-x", "Stack an existing branch on feature-x"},
+			{"git @ restack", "Rebase the stack after changes"},
+		},
+		Sections: []Section{
+			{"STORAGE", `branch.<name>.at-parent           Parent branch
+branch.<name>.at-parent-base      Parent commit the branch is based on`},
+		},
+		Run: (*Manager).Stack,
+	}
+}
+
+func restackCommand() *Command {
+	return &Command{
+		Name:    "restack",
+		Summary: "Rebase stacked branches onto their parents",
+		Description: `Rebase the current branch onto its parent, then every branch stacked on
+top of it onto its own parent. Only the commits of each branch are moved
+(git rebase --onto), so rewritten or squash-merged parents are handled.
+
+When a parent has been merged into the trunk (merged, rebased or squashed,
+detected by patch-id) or deleted, the branch is moved onto the parent's
+parent (usually the trunk).`,
+		Examples: []Example{
+			{"git @ restack", "Restack from the current branch"},
+		},
+		Sections: []Section{
+			{"CONFLICTS", `Restacking stops at the first conflict. Resolve it, run
+'git rebase --continue', then run 'git @ restack' again.`},
+		},
+		Run: (*Manager).Restack,
+	}
+}
@@ -0,0 +1,462 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// syncStateFile holds the progress of an interrupted sync, inside the git dir
+const syncStateFile = "gitat-sync"
+
+// Sync strategies
+const (
+	syncRebase = "rebase"
+	syncMerge  = "merge"
+)
+
+// syncState is the progress of a sync stopped by a conflict
+type syncState struct {
+	Original string   // branch checked out when sync started
+	Strategy string   // rebase or merge
+	Branch   string   // branch being updated when sync stopped
+	Target   string   // branch it was being updated onto
+	Pending  []string // branches still to update
+}
+
+// format serializes the state as key=value lines
+func (s syncState) format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "original=%s\n", s.Original)
+	fmt.Fprintf(&b, "strategy=%s\n", s.Strategy)
+	fmt.Fprintf(&b, "branch=%s\n", s.Branch)
+	fmt.Fprintf(&b, "target=%s\n", s.Target)
+	for _, branch := range s.Pending {
+		fmt.Fprintf(&b, "pending=%s\n", branch)
+	}
+	return b.String()
+}
+
+// parseSyncState parses a state written by syncState.format
+func parseSyncState(content string) (syncState, error) {
+	var state syncState
+	for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return state, fmt.Errorf("invalid sync state line: %q", line)
+		}
+		switch key {
+		case "original":
+			state.Original = value
+		case "strategy":
+			state.Strategy = value
+		case "branch":
+			state.Branch = value
+		case "target":
+			state.Target = value
+		case "pending":
+			state.Pending = append(state.Pending, value)
+		}
+	}
+
+	if state.Original == "" || state.Branch == "" {
+		return state, fmt.Errorf("incomplete sync state")
+	}
+	return state, nil
+}
+
+// Sync updates the trunk and rebases or merges work branches onto it
+func (m *Manager) Sync(args []string) error {
+	cmd := command("sync")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("sync")
+	}
+	if err := cmd.extraArgs(flags, 0); err != nil {
+		return err
+	}
+	all := flags.Has("all")
+
+	if flags.Has("continue") && flags.Has("abort") {
+		return errs.New(errs.Usage, "--continue and --abort cannot be used together")
+	}
+	if flags.Has("continue") {
+		return m.continueSync()
+	}
+	if flags.Has("abort") {
+		return m.abortSync()
+	}
+
+	// The flags are named after the strategies; the last one given wins
+	strategy := flags.Last(syncRebase, syncMerge)
+
+	if _, err := m.loadSyncState(); err == nil {
+		return errs.New(errs.Conflict, "A sync is already in progress\nRun 'git @ sync --continue' or 'git @ sync --abort'")
+	}
+
+	if strategy == "" {
+		strategy, _ = m.git.GetConfig("at.sync.strategy")
+		if strategy == "" {
+			strategy = syncRebase
+		}
+	}
+	if strategy != syncRebase && strategy != syncMerge {
+		return errs.New(errs.Usage, "Invalid sync strategy '%s' (use rebase or merge)", strategy)
+	}
+
+	currentBranch, err := m.git.GetCurrentBranch()
+	if err != nil {
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+
+	status, err := m.git.Run("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+	if status != "" {
+		return errs.New(errs.DirtyTree, "You have uncommitted changes. Commit or stash them before syncing")
+	}
+
+	trunk := m.trunkBranch()
+	if !m.branchExists(trunk) {
+		return errs.New(errs.NotFound, "Trunk branch '%s' does not exist\nPlease ensure the trunk branch exists or configure it with: git @ _trunk <branch>", trunk)
+	}
+
+	m.updateTrunk(trunk, currentBranch)
+
+	var branches []string
+	if all {
+		branches = m.syncWorkBranches(trunk)
+	} else if currentBranch != trunk {
+		branches = []string{currentBranch}
+	}
+
+	if len(branches) == 0 {
+		fmt.Println("✅ Nothing else to sync")
+		return nil
+	}
+
+	return m.syncBranches(currentBranch, strategy, branches)
+}
+
+// updateTrunk fetches origin and fast-forwards the trunk without checking it out
+func (m *Manager) updateTrunk(trunk, currentBranch string) {
+	if _, err := m.git.Run("remote", "get-url", "origin"); err != nil {
+		fmt.Println("No origin remote configured, skipping fetch")
+		return
+	}
+
+	fmt.Println("Fetching origin...")
+	if err := m.git.Stream("fetch", "--prune", "origin"); err != nil {
+		fmt.Printf("Warning: Failed to fetch origin: %v\n", err)
+		return
+	}
+
+	// fetch into a local branch only allows fast-forwards, and refuses the
+	// checked out branch, which is merged instead
+	var err error
+	if currentBranch == trunk {
+		_, err = m.git.Run("merge", "--ff-only", "origin/"+trunk)
+	} else {
+		_, err = m.git.Run("fetch", "origin", fmt.Sprintf("%s:%s", trunk, trunk))
+	}
+	if err != nil {
+		fmt.Printf("Warning: Could not fast-forward %s to origin/%s (diverged or checked out elsewhere)\n", trunk, trunk)
+		return
+	}
+	fmt.Printf("✅ %s is up to date with origin\n", trunk)
+}
+
+// syncWorkBranches returns the local work branches, parents before the
+// branches stacked on them
+func (m *Manager) syncWorkBranches(trunk string) []string {
+	all, err := m.git.GetBranches()
+	if err != nil {
+		return nil
+	}
+
+	var branches []string
+	depth := make(map[string]int)
+	for _, branch := range all {
+		if branch == trunk || m.getWorkType(branch) == "" {
+			continue
+		}
+		branches = append(branches, branch)
+		depth[branch] = len(m.getStackAncestors(branch))
+	}
+
+	sort.Slice(branches, func(i, j int) bool {
+		if depth[branches[i]] != depth[branches[j]] {
+			return depth[branches[i]] < depth[branches[j]]
+		}
+		return branches[i] < branches[j]
+	})
+	return branches
+}
+
+// syncBranches updates each branch onto its parent, saving the progress
+// when a conflict stops it. Branches checked out in other worktrees are
+// skipped, and other failures do not stop the remaining branches.
+func (m *Manager) syncBranches(original, strategy string, branches []string) error {
+	top, _ := m.git.Run("rev-parse", "--show-toplevel")
+	var failed []string
+	var failure error
+	for i, branch := range branches {
+		if wt := m.findWorktree(branch); wt != nil && wt.Path != top {
+			output.Warning("Skipping %s: checked out in %s", branch, wt.Path)
+			continue
+		}
+
+		target, err := m.syncBranch(branch, strategy)
+		if err != nil {
+			// Only a rebase or merge stopped by conflicts can be continued
+			if m.operationInProgress() == "" {
+				output.Warning("Failed to sync %s: %v", branch, err)
+				failed = append(failed, branch)
+				if failure == nil {
+					failure = err
+				}
+				continue
+			}
+			state := syncState{
+				Original: original,
+				Strategy: strategy,
+				Branch:   branch,
+				Target:   target,
+				Pending:  branches[i+1:],
+			}
+			if saveErr := m.saveSyncState(state); saveErr != nil {
+				return fmt.Errorf("failed to save sync state: %w", saveErr)
+			}
+			output.Info("Resolve the conflicts and run 'git @ sync --continue', or run 'git @ sync --abort'")
+			return errs.Wrap(errs.Conflict, err, "Conflicts while updating %s onto %s", branch, target)
+		}
+	}
+
+	if len(failed) > 0 {
+		if _, err := m.git.Run("checkout", original); err != nil {
+			output.Warning("Failed to switch back to %s: %v", original, err)
+		}
+		return errs.Wrap(errs.KindOf(failure), failure, "Failed to sync %s", strings.Join(failed, ", "))
+	}
+	return m.finishSync(original)
+}
+
+// syncBranch rebases or merges branch onto its parent and returns the parent
+func (m *Manager) syncBranch(branch, strategy string) (string, error) {
+	target, oldBase, err := m.restackTarget(branch)
+	if err != nil {
+		return target, err
+	}
+
+	// A stacked branch is also moved when its parent changes; other
+	// branches only follow the trunk
+	parent := m.getStackParent(branch)
+	if _, err := m.git.Run("merge-base", "--is-ancestor", target, branch); err == nil && (parent == "" || parent == target) {
+		fmt.Printf("%s is up to date with %s\n", branch, target)
+		return target, nil
+	}
+
+	if strategy == syncMerge {
+		fmt.Printf("Merging %s into %s...\n", target, branch)
+		if _, err := m.git.Run("checkout", branch); err != nil {
+			return target, err
+		}
+		if _, err := m.git.Run("merge", "--no-edit", target); err != nil {
+			return target, err
+		}
+	} else {
+		fmt.Printf("Rebasing %s onto %s...\n", branch, target)
+		if _, err := m.git.Run("rebase", "--onto", target, oldBase, branch); err != nil {
+			return target, err
+		}
+	}
+
+	if err := m.updateStackParent(branch, target); err != nil {
+		return target, err
+	}
+	fmt.Printf("✅ %s updated\n", branch)
+	return target, nil
+}
+
+// updateStackParent records target as the new parent of a stacked branch.
+// Branches that were never stacked are left without a parent.
+func (m *Manager) updateStackParent(branch, target string) error {
+	if m.getStackParent(branch) == "" {
+		return nil
+	}
+	return m.setStackParent(branch, target)
+}
+
+// continueSync resumes a sync after the conflicts were resolved
+func (m *Manager) continueSync() error {
+	state, err := m.loadSyncState()
+	if err != nil {
+		return errs.New(errs.NothingToDo, "No sync in progress")
+	}
+
+	switch m.operationInProgress() {
+	case syncRebase:
+		if _, err := m.git.Run("-c", "core.editor=true", "rebase", "--continue"); err != nil {
+			return errs.New(errs.Conflict, "Rebase of %s still has conflicts\nResolve them and run 'git @ sync --continue' again", state.Branch)
+		}
+	case syncMerge:
+		unmerged, _ := m.git.Run("diff", "--name-only", "--diff-filter=U")
+		if unmerged != "" {
+			return errs.New(errs.Conflict, "Merge into %s still has conflicts:\n%s", state.Branch, unmerged)
+		}
+		if _, err := m.git.Run("commit", "--no-edit"); err != nil {
+			return fmt.Errorf("failed to commit merge: %w", err)
+		}
+	}
+
+	if err := m.updateStackParent(state.Branch, state.Target); err != nil {
+		return err
+	}
+	fmt.Printf("✅ %s updated\n", state.Branch)
+
+	if err := m.removeSyncState(); err != nil {
+		return err
+	}
+	return m.syncBranches(state.Original, state.Strategy, state.Pending)
+}
+
+// abortSync stops a sync and restores the branch being updated
+func (m *Manager) abortSync() error {
+	state, err := m.loadSyncState()
+	if err != nil {
+		return errs.New(errs.NothingToDo, "No sync in progress")
+	}
+
+	switch m.operationInProgress() {
+	case syncRebase:
+		if _, err := m.git.Run("rebase", "--abort"); err != nil {
+			return fmt.Errorf("failed to abort rebase: %w", err)
+		}
+	case syncMerge:
+		if _, err := m.git.Run("merge", "--abort"); err != nil {
+			return fmt.Errorf("failed to abort merge: %w", err)
+		}
+	}
+
+	if err := m.removeSyncState(); err != nil {
+		return err
+	}
+	if _, err := m.git.Run("checkout", state.Original); err != nil {
+		return errs.Wrap(errs.KindOf(err), err, "Failed to switch back to %s", state.Original)
+	}
+
+	fmt.Printf("Sync aborted, %s was left unchanged\n", state.Branch)
+	return nil
+}
+
+// finishSync switches back to the original branch
+func (m *Manager) finishSync(original string) error {
+	if _, err := m.git.Run("checkout", original); err != nil {
+		return errs.Wrap(errs.KindOf(err), err, "Failed to switch back to %s", original)
+	}
+	fmt.Println("✅ Sync complete")
+	return nil
+}
+
+// operationInProgress returns the git operation stopped by a conflict,
+// syncRebase or syncMerge, or ""
+func (m *Manager) operationInProgress() string {
+	gitDir, err := m.git.Run("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return ""
+	}
+
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		if _, err := os.Stat(filepath.Join(gitDir, dir)); err == nil {
+			return syncRebase
+		}
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "MERGE_HEAD")); err == nil {
+		return syncMerge
+	}
+	return ""
+}
+
+// syncStatePath returns the path of the sync state file
+func (m *Manager) syncStatePath() (string, error) {
+	gitDir, err := m.git.Run("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", errs.New(errs.NotARepo, "Not in a git repository")
+	}
+	return filepath.Join(gitDir, syncStateFile), nil
+}
+
+func (m *Manager) loadSyncState() (syncState, error) {
+	path, err := m.syncStatePath()
+	if err != nil {
+		return syncState{}, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return syncState{}, err
+	}
+	return parseSyncState(string(content))
+}
+
+func (m *Manager) saveSyncState(state syncState) error {
+	path, err := m.syncStatePath()
+	if err != nil {
+		return err
+	}
+	return m.writeFile(path, []byte(state.format()))
+}
+
+func (m *Manager) removeSyncState() error {
+	path, err := m.syncStatePath()
+	if err != nil {
+		return err
+	}
+	if err := m.removeFile(path); err != nil {
+		return fmt.Errorf("failed to remove sync state: %w", err)
+	}
+	return nil
+}
+
+func syncCommand() *Command {
+	return &Command{
+		Name:    "sync",
+		Hint:    "[--all]",
+		Summary: "Update trunk and rebase or merge work branches",
+		Usage:   []string{"[options]"},
+		Description: `Bring work branches up to date. Fetches origin, fast-forwards the trunk
+without checking it out, then rebases or merges the current branch (or
+every work branch) onto its parent: the trunk, or the parent branch of a
+stacked branch.`,
+		Flags: []Flag{
+			{Short: "a", Long: "all", Help: "Update all local work branches"},
+			{Short: "r", Long: "rebase", Help: "Rebase branches (overrides at.sync.strategy)"},
+			{Short: "m", Long: "merge", Help: "Merge the parent into branches (overrides at.sync.strategy)"},
+			{Long: "continue", Help: "Resume after resolving conflicts"},
+			{Long: "abort", Help: "Stop and restore the branch being updated"},
+		},
+		Examples: []Example{
+			{"git @ sync", "Update trunk and the current branch"},
+			{"git @ sync --all", "Update trunk and every work branch"},
+			{"git @ sync --merge", "Merge instead of rebasing"},
+			{"git @ sync --continue", "Resume after fixing conflicts"},
+		},
+		Sections: []Section{
+			{"CONFLICTS", `Sync stops at the first conflict. Resolve it and run 'git @ sync --continue'
+to update the remaining branches, or 'git @ sync --abort' to stop.
+Branches checked out in other worktrees are skipped with a warning; other
+failures are reported once the remaining branches are synced.`},
+			{"CONFIGURATION", `git config at.sync.strategy rebase   # Rebase work branches (default)
+git config at.sync.strategy merge    # Merge the trunk into work branches`},
+		},
+		Run: (*Manager).Sync,
+	}
+}
@@ -0,0 +1,404 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// timeLogFile is the time journal, inside the gitat-logs directory of the
+// common git dir so every worktree writes to the same journal
+const timeLogFile = "time.log"
+
+// defaultIdleGap is the longest gap between two events still counted as work
+const defaultIdleGap = 30 * time.Minute
+
+// Time journal events
+const (
+	timeStart = "start"
+	timeStop  = "stop"
+	timeSave  = "save"
+)
+
+// timeEvent is a line of the time journal. The branch context is captured
+// when the event is written, so reports keep the issue the work was for.
+type timeEvent struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Branch  string    `json:"branch"`
+	Issue   string    `json:"issue,omitempty"`
+	Feature string    `json:"feature,omitempty"`
+	Command string    `json:"command"`
+}
+
+// key returns the value the event is grouped by in reports
+func (e timeEvent) key(by string) string {
+	switch by {
+	case "branch":
+		return e.Branch
+	case "feature":
+		return e.Feature
+	default:
+		return e.Issue
+	}
+}
+
+// timeTotal is a line of a time report
+type timeTotal struct {
+	Key      string
+	Duration time.Duration
+	Branches []string
+}
+
+// timeReport is the JSON report of the time command
+type timeReport struct {
+	By          string           `json:"by"`
+	Since       *time.Time       `json:"since"` // null for the whole journal
+	IdleMinutes int              `json:"idle_minutes"`
+	Totals      []timeReportItem `json:"totals"`
+	Minutes     int              `json:"minutes"`
+}
+
+// timeReportItem is the time spent on an issue, branch or feature
+type timeReportItem struct {
+	Key      string   `json:"key"`
+	Minutes  int      `json:"minutes"`
+	Branches []string `json:"branches"`
+}
+
+// newTimeReport returns the report of totals, in whole minutes
+func newTimeReport(by string, since time.Time, idle time.Duration, totals []timeTotal) timeReport {
+	report := timeReport{By: by, IdleMinutes: int(idle.Minutes()), Totals: []timeReportItem{}}
+	if !since.IsZero() {
+		report.Since = &since
+	}
+	for _, total := range totals {
+		minutes := int(total.Duration.Round(time.Minute).Minutes())
+		report.Totals = append(report.Totals, timeReportItem{Key: total.Key, Minutes: minutes, Branches: total.Branches})
+		report.Minutes += minutes
+	}
+	return report
+}
+
+// timeLogPath returns the path of the time journal
+func (m *Manager) timeLogPath() (string, error) {
+	commonDir, err := m.git.Run("rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", errs.New(errs.NotARepo, "Not in a git repository")
+	}
+	commonDir = strings.TrimSpace(commonDir)
+	if commonDir == "" {
+		return "", errs.New(errs.NotARepo, "Not in a git repository")
+	}
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(m.config.RepoPath, commonDir)
+	}
+	return filepath.Join(commonDir, "gitat-logs", timeLogFile), nil
+}
+
+// timeTrackingEnabled reports whether events are journaled; at.time=false
+// turns time tracking off
+func (m *Manager) timeTrackingEnabled() bool {
+	setting, _ := m.git.GetConfig("at.time")
+	return setting != "false"
+}
+
+// recordTimeEvent appends an event for branch to the time journal. Failures
+// never fail the command that triggered the event.
+func (m *Manager) recordTimeEvent(event, branch, command string) {
+	if branch == "" || !m.timeTrackingEnabled() {
+		return
+	}
+
+	ctx := m.getBranchContext(branch)
+	entry := timeEvent{
+		Time:    time.Now().UTC().Truncate(time.Second),
+		Event:   event,
+		Branch:  branch,
+		Issue:   ctx.Issue,
+		Feature: ctx.Feature,
+		Command: command,
+	}
+
+	if err := m.appendTimeEvent(entry); err != nil {
+		output.Warning("Failed to record time: %v", err)
+	}
+}
+
+// recordBranchSwitch journals the stop of from and the start of to
+func (m *Manager) recordBranchSwitch(command, from, to string) {
+	if from != to {
+		m.recordTimeEvent(timeStop, from, command)
+	}
+	m.recordTimeEvent(timeStart, to, command)
+}
+
+// appendTimeEvent writes an event to the time journal
+func (m *Manager) appendTimeEvent(entry timeEvent) error {
+	path, err := m.timeLogPath()
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return m.appendFile(path, append(line, '\n'))
+}
+
+// loadTimeEvents reads the time journal, skipping malformed lines
+func (m *Manager) loadTimeEvents() ([]timeEvent, error) {
+	path, err := m.timeLogPath()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open time log: %w", err)
+	}
+	defer file.Close()
+
+	var events []timeEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event timeEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.Time.IsZero() {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	// Worktrees may interleave writes, keep the journal in time order
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events, scanner.Err()
+}
+
+// summarizeTime attributes the time between consecutive events to the
+// branch active after the first one. A stop ends the activity until the
+// next event, and gaps longer than idle are counted as idle only, on the
+// assumption that work stopped soon after the last event. Only time after
+// since is counted; the last activity runs until now.
+func summarizeTime(events []timeEvent, by string, since, now time.Time, idle time.Duration) []timeTotal {
+	totals := make(map[string]*timeTotal)
+	branches := make(map[string]map[string]bool)
+
+	for i, event := range events {
+		if event.Event == timeStop {
+			continue
+		}
+
+		end := now
+		if i+1 < len(events) {
+			end = events[i+1].Time
+		}
+		start := event.Time
+		if end.Sub(start) > idle {
+			end = start.Add(idle)
+		}
+		if start.Before(since) {
+			start = since
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		key := event.key(by)
+		total, ok := totals[key]
+		if !ok {
+			total = &timeTotal{Key: key}
+			totals[key] = total
+			branches[key] = make(map[string]bool)
+		}
+		total.Duration += end.Sub(start)
+		if !branches[key][event.Branch] {
+			branches[key][event.Branch] = true
+			total.Branches = append(total.Branches, event.Branch)
+		}
+	}
+
+	result := make([]timeTotal, 0, len(totals))
+	for _, total := range totals {
+		sort.Strings(total.Branches)
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Duration != result[j].Duration {
+			return result[i].Duration > result[j].Duration
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// parseSince parses --since: a date (2006-01-02), a duration (36h) or a
+// number of days or weeks (7d, 2w)
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	if match := regexp.MustCompile(`^(\d+)([dw])$`).FindStringSubmatch(value); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		if match[2] == "w" {
+			n *= 7
+		}
+		return now.AddDate(0, 0, -n), nil
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+	return time.Time{}, errs.New(errs.Usage, "Invalid --since '%s' (use a date like 2006-01-02, or 7d, 2w, 36h)", value)
+}
+
+// formatDuration formats a duration as 1h 05m
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// Time reports the time spent per issue, branch or feature
+func (m *Manager) Time(args []string) error {
+	cmd := command("time")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("time")
+	}
+	if err := cmd.extraArgs(flags, 0); err != nil {
+		return err
+	}
+	csvOutput := flags.Has("csv")
+	if csvOutput && output.JSONEnabled() {
+		return errs.New(errs.Usage, "--csv cannot be used with --json")
+	}
+	now := time.Now()
+
+	// The last of --since and --all wins
+	since := now.AddDate(0, 0, -7)
+	switch flags.Last("since", "all") {
+	case "since":
+		if since, err = parseSince(flags.String("since"), now); err != nil {
+			return err
+		}
+	case "all":
+		since = time.Time{}
+	}
+
+	by := "issue"
+	if flags.Has("by") {
+		by = flags.String("by")
+		if by != "issue" && by != "branch" && by != "feature" {
+			return errs.New(errs.Usage, "Invalid --by '%s' (use issue, branch or feature)", by)
+		}
+	}
+
+	idle := defaultIdleGap
+	if flags.Has("idle") {
+		parsed, err := time.ParseDuration(flags.String("idle"))
+		if err != nil || parsed <= 0 {
+			return errs.New(errs.Usage, "Invalid --idle '%s' (use a duration like 45m)", flags.String("idle"))
+		}
+		idle = parsed
+	}
+
+	events, err := m.loadTimeEvents()
+	if err != nil {
+		return err
+	}
+	totals := summarizeTime(events, by, since, now, idle)
+
+	if output.JSONEnabled() {
+		return output.JSON(newTimeReport(by, since, idle, totals))
+	}
+
+	if csvOutput {
+		writer := csv.NewWriter(os.Stdout)
+		writer.Write([]string{by, "hours", "minutes", "branches"})
+		for _, total := range totals {
+			writer.Write([]string{
+				total.Key,
+				strconv.FormatFloat(total.Duration.Hours(), 'f', 2, 64),
+				strconv.Itoa(int(total.Duration.Round(time.Minute).Minutes())),
+				strings.Join(total.Branches, " "),
+			})
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	if len(totals) == 0 {
+		fmt.Println("No time recorded for this period")
+		if !m.timeTrackingEnabled() {
+			fmt.Println("Time tracking is off, enable it with: git config at.time true")
+		}
+		return nil
+	}
+
+	header := strings.ToUpper(by[:1]) + by[1:]
+	var rows [][]string
+	var sum time.Duration
+	for _, total := range totals {
+		key := total.Key
+		if key == "" {
+			key = "(none)"
+		}
+		rows = append(rows, []string{key, formatDuration(total.Duration), strings.Join(total.Branches, ", ")})
+		sum += total.Duration
+	}
+
+	if since.IsZero() {
+		output.Title("⏱️  Time by " + by)
+	} else {
+		output.Title(fmt.Sprintf("⏱️  Time by %s since %s", by, since.Format("2006-01-02 15:04")))
+	}
+	output.Table([]string{header, "Time", "Branches"}, rows)
+	fmt.Printf("\nTotal: %s (gaps over %s counted as idle)\n", formatDuration(sum), idle)
+	return nil
+}
+
+func timeCommand() *Command {
+	return &Command{
+		Name:    "time",
+		Hint:    "[--by issue]",
+		Summary: "Report time spent per issue, branch or feature",
+		Usage:   []string{"[--since <when>] [--by issue|branch|feature] [--idle <duration>] [--csv]"},
+		Description: `Report the time spent per issue, branch or feature. gitAT journals when
+'work', 'hotfix', 'master', 'wip -c' and 'switch' change branch and when
+'save' commits, and attributes the time between events to the branch in use.`,
+		Flags: []Flag{
+			{Short: "s", Long: "since", Value: "when", Help: "Start of the report: 2006-01-02, 7d, 2w or 36h (default: 7d)", Values: []string{"1d", "7d", "2w", "30d"}},
+			{Long: "all", Help: "Report the whole journal"},
+			{Short: "b", Long: "by", Value: "field", Help: "Group by issue (default), branch or feature", Values: []string{"issue", "branch", "feature"}},
+			{Long: "idle", Value: "dur", Help: "Longest gap counted as work (default: 30m); longer gaps count as this much and the rest as idle"},
+			{Long: "csv", Help: "Export as CSV"},
+		},
+		Examples: []Example{
+			{"git @ time", "Time per issue over the last week"},
+			{"git @ time --since 2026-10-01 --csv", "Monthly export for timesheets"},
+			{"git @ time --by branch --idle 1h", "Time per branch, allowing 1h gaps"},
+		},
+		Sections: []Section{
+			{"STORAGE", `Journal: .git/gitat-logs/time.log (one JSON event per line)
+Disable with: git config at.time false`},
+		},
+		Run: (*Manager).Time,
+	}
+}
@@ -0,0 +1,461 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// wipRefPrefix is the namespace of WIP snapshots: refs/gitat/wip/<branch>/<n>
+const wipRefPrefix = "refs/gitat/wip/"
+
+// wipSnapshot is a WIP snapshot of a branch
+type wipSnapshot struct {
+	Branch  string `json:"branch"`
+	N       int    `json:"n"`
+	Ref     string `json:"ref"`
+	Hash    string `json:"hash"`
+	Date    string `json:"date"` // relative, e.g. "2 hours ago"
+	Message string `json:"message"`
+}
+
+// wipSnapshots returns the snapshots of branch, oldest first
+func (m *Manager) wipSnapshots(branch string) ([]wipSnapshot, error) {
+	prefix := wipRefPrefix + branch + "/"
+	out, err := m.git.Run("for-each-ref", "--format=%(refname)%00%(objectname)%00%(creatordate:relative)%00%(contents:subject)", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list WIP snapshots: %w", err)
+	}
+
+	var snapshots []wipSnapshot
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(fields[0], prefix))
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, wipSnapshot{Branch: branch, N: n, Ref: fields[0], Hash: fields[1], Date: fields[2], Message: fields[3]})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].N < snapshots[j].N
+	})
+	return snapshots, nil
+}
+
+// findWIPSnapshot returns snapshot n of branch, or the latest when n is ""
+func (m *Manager) findWIPSnapshot(branch, n string) (*wipSnapshot, error) {
+	snapshots, err := m.wipSnapshots(branch)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, errs.New(errs.NotFound, "No WIP snapshots for %s", branch)
+	}
+	if n == "" {
+		return &snapshots[len(snapshots)-1], nil
+	}
+
+	for i := range snapshots {
+		if strconv.Itoa(snapshots[i].N) == n {
+			return &snapshots[i], nil
+		}
+	}
+	return nil, errs.New(errs.NotFound, "WIP snapshot %s not found for %s", n, branch)
+}
+
+// createWIPSnapshot stores the index, working tree and untracked files of
+// the current branch as a stash-shaped commit under refs/gitat/wip, without
+// touching HEAD, the index or the working tree. It returns nil when there is
+// nothing to snapshot.
+func (m *Manager) createWIPSnapshot(branch, message string) (*wipSnapshot, error) {
+	status, err := m.git.Run("status", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	if status == "" {
+		return nil, nil
+	}
+
+	head, err := m.git.Run("rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return nil, errs.New(errs.NothingToDo, "Cannot snapshot a branch without commits")
+	}
+	subject, _ := m.git.Run("log", "-1", "--format=%h %s", head)
+
+	// Index commit (parent HEAD), as git stash records it
+	indexTree, err := m.git.Run("write-tree")
+	if err != nil {
+		return nil, errs.New(errs.Conflict, "Cannot snapshot with unresolved conflicts")
+	}
+	indexCommit, err := m.git.Run("commit-tree", indexTree, "-p", head, "-m", fmt.Sprintf("index on %s: %s", branch, subject))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record index: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "gitat-wip-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Working tree: a copy of the index updated with tracked changes
+	gitDir, err := m.git.Run("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return nil, errs.New(errs.NotARepo, "Not in a git repository")
+	}
+	index, err := os.ReadFile(filepath.Join(gitDir, "index"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	workIndex := filepath.Join(tmpDir, "work-index")
+	if err := os.WriteFile(workIndex, index, 0644); err != nil {
+		return nil, fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	env := []string{"GIT_INDEX_FILE=" + workIndex}
+	if _, err := m.git.RunWithEnv(env, "add", "--update"); err != nil {
+		return nil, fmt.Errorf("failed to record working tree: %w", err)
+	}
+	workTree, err := m.git.RunWithEnv(env, "write-tree")
+	if err != nil {
+		return nil, fmt.Errorf("failed to record working tree: %w", err)
+	}
+
+	parents := []string{"-p", head, "-p", indexCommit}
+
+	// Untracked files go into a third, parentless commit. -z keeps names
+	// with newlines or quotes as they are, and literal pathspecs keep
+	// names with glob characters from matching other files.
+	untracked, _ := m.git.Run("ls-files", "-z", "--others", "--exclude-standard")
+	if untracked = strings.TrimSuffix(untracked, "\x00"); untracked != "" {
+		env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmpDir, "untracked-index"), "GIT_LITERAL_PATHSPECS=1"}
+		addArgs := append([]string{"add", "--"}, strings.Split(untracked, "\x00")...)
+		if _, err := m.git.RunWithEnv(env, addArgs...); err != nil {
+			return nil, fmt.Errorf("failed to record untracked files: %w", err)
+		}
+		untrackedTree, err := m.git.RunWithEnv(env, "write-tree")
+		if err != nil {
+			return nil, fmt.Errorf("failed to record untracked files: %w", err)
+		}
+		untrackedCommit, err := m.git.Run("commit-tree", untrackedTree, "-m", fmt.Sprintf("untracked files on %s: %s", branch, subject))
+		if err != nil {
+			return nil, fmt.Errorf("failed to record untracked files: %w", err)
+		}
+		parents = append(parents, "-p", untrackedCommit)
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("WIP on %s: %s", branch, subject)
+	} else {
+		message = fmt.Sprintf("On %s: %s", branch, message)
+	}
+	commitArgs := append([]string{"commit-tree", workTree}, parents...)
+	snapshot, err := m.git.Run(append(commitArgs, "-m", message)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record WIP snapshot: %w", err)
+	}
+
+	existing, err := m.wipSnapshots(branch)
+	if err != nil {
+		return nil, err
+	}
+	n := 1
+	if len(existing) > 0 {
+		n = existing[len(existing)-1].N + 1
+	}
+
+	ref := fmt.Sprintf("%s%s/%d", wipRefPrefix, branch, n)
+	if _, err := m.git.Run("update-ref", "-m", "gitat wip", ref, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to store WIP snapshot: %w", err)
+	}
+
+	return &wipSnapshot{Branch: branch, N: n, Ref: ref, Hash: snapshot, Message: message}, nil
+}
+
+// snapshotWIP marks the current branch as WIP and snapshots its changes
+func (m *Manager) snapshotWIP(message string) error {
+	if err := m.setWIP(); err != nil {
+		return err
+	}
+
+	currentBranch, err := m.git.GetCurrentBranch()
+	if err != nil {
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+
+	snapshot, err := m.createWIPSnapshot(currentBranch, message)
+	if err != nil {
+		return err
+	}
+	if snapshot == nil {
+		fmt.Println("No changes to snapshot")
+		return nil
+	}
+
+	fmt.Printf("✅ Saved WIP snapshot %d of %s (%s)\n", snapshot.N, currentBranch, snapshot.Hash[:7])
+	return nil
+}
+
+// listWIP shows the snapshots of the current branch, or of all branches
+func (m *Manager) listWIP(args []string) error {
+	cmd := command("wip", "list")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("wip", "list")
+	}
+	if err := cmd.extraArgs(flags, 0); err != nil {
+		return err
+	}
+	all := flags.Has("all")
+
+	branches := []string{}
+	if all {
+		out, err := m.git.Run("for-each-ref", "--format=%(refname)", wipRefPrefix)
+		if err != nil {
+			return fmt.Errorf("failed to list WIP snapshots: %w", err)
+		}
+		seen := make(map[string]bool)
+		for _, ref := range strings.Split(out, "\n") {
+			rest := strings.TrimPrefix(ref, wipRefPrefix)
+			if idx := strings.LastIndex(rest, "/"); idx > 0 && !seen[rest[:idx]] {
+				seen[rest[:idx]] = true
+				branches = append(branches, rest[:idx])
+			}
+		}
+		sort.Strings(branches)
+	} else {
+		currentBranch, err := m.git.GetCurrentBranch()
+		if err != nil {
+			return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+		}
+		branches = append(branches, currentBranch)
+	}
+
+	snapshots := []wipSnapshot{}
+	for _, branch := range branches {
+		list, err := m.wipSnapshots(branch)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, list...)
+	}
+	if output.JSONEnabled() {
+		return output.JSON(map[string][]wipSnapshot{"snapshots": snapshots})
+	}
+
+	var rows [][]string
+	for _, snapshot := range snapshots {
+		rows = append(rows, []string{snapshot.Branch, strconv.Itoa(snapshot.N), snapshot.Hash[:7], snapshot.Date, snapshot.Message})
+	}
+	if len(rows) == 0 {
+		fmt.Println("No WIP snapshots")
+		return nil
+	}
+
+	output.Table([]string{"Branch", "#", "Commit", "Created", "Message"}, rows)
+	return nil
+}
+
+// showWIPSnapshot prints the changes of a snapshot
+func (m *Manager) showWIPSnapshot(args []string) error {
+	cmd := command("wip", "show")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("wip", "show")
+	}
+	if err := cmd.extraArgs(flags, 1); err != nil {
+		return err
+	}
+
+	currentBranch, err := m.git.GetCurrentBranch()
+	if err != nil {
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+
+	n := ""
+	if len(flags.Args()) > 0 {
+		n = flags.Args()[0]
+	}
+	snapshot, err := m.findWIPSnapshot(currentBranch, n)
+	if err != nil {
+		return err
+	}
+
+	diff, err := m.git.Run("stash", "show", "--include-untracked", "--patch", "--stat", snapshot.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to show WIP snapshot: %w", err)
+	}
+
+	fmt.Printf("WIP snapshot %d of %s: %s\n\n", snapshot.N, currentBranch, snapshot.Message)
+	fmt.Println(diff)
+	return nil
+}
+
+// applyWIPSnapshot applies a snapshot of branch to the working tree
+func (m *Manager) applyWIPSnapshot(branch, n string, restoreIndex bool) error {
+	snapshot, err := m.findWIPSnapshot(branch, n)
+	if err != nil {
+		return err
+	}
+
+	applyArgs := []string{"stash", "apply"}
+	if restoreIndex {
+		applyArgs = append(applyArgs, "--index")
+	}
+	if _, err := m.git.Run(append(applyArgs, snapshot.Hash)...); err != nil {
+		return errs.Wrap(errs.Conflict, err, "Failed to apply WIP snapshot %d (conflicts or local changes in the way)", snapshot.N)
+	}
+
+	fmt.Printf("✅ Applied WIP snapshot %d of %s\n", snapshot.N, branch)
+	return nil
+}
+
+// applyWIP applies a snapshot of the current branch
+func (m *Manager) applyWIP(args []string) error {
+	cmd := command("wip", "apply")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("wip", "apply")
+	}
+	if err := cmd.extraArgs(flags, 1); err != nil {
+		return err
+	}
+
+	currentBranch, err := m.git.GetCurrentBranch()
+	if err != nil {
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+
+	n := ""
+	if len(flags.Args()) > 0 {
+		n = flags.Args()[0]
+	}
+	return m.applyWIPSnapshot(currentBranch, n, flags.Has("index"))
+}
+
+// dropWIP deletes one or all snapshots of the current branch
+func (m *Manager) dropWIP(args []string) error {
+	cmd := command("wip", "drop")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("wip", "drop")
+	}
+	if err := cmd.extraArgs(flags, 1); err != nil {
+		return err
+	}
+
+	currentBranch, err := m.git.GetCurrentBranch()
+	if err != nil {
+		return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+	}
+
+	var snapshots []wipSnapshot
+	if flags.Has("all") {
+		snapshots, err = m.wipSnapshots(currentBranch)
+		if err != nil {
+			return err
+		}
+	} else {
+		n := ""
+		if len(flags.Args()) > 0 {
+			n = flags.Args()[0]
+		}
+		snapshot, err := m.findWIPSnapshot(currentBranch, n)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, *snapshot)
+	}
+
+	for _, snapshot := range snapshots {
+		if _, err := m.git.Run("update-ref", "-d", snapshot.Ref); err != nil {
+			return fmt.Errorf("failed to drop WIP snapshot %d: %w", snapshot.N, err)
+		}
+		fmt.Printf("🗑️  Dropped WIP snapshot %d (%s)\n", snapshot.N, snapshot.Hash[:7])
+	}
+	return nil
+}
+
+// pushWIP pushes the snapshots of the current branch, or all snapshots
+func (m *Manager) pushWIP(args []string) error {
+	cmd := command("wip", "push")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("wip", "push")
+	}
+	if err := cmd.extraArgs(flags, 1); err != nil {
+		return err
+	}
+
+	remote := "origin"
+	if len(flags.Args()) > 0 {
+		remote = flags.Args()[0]
+	}
+	all := flags.Has("all")
+
+	refs := wipRefPrefix + "*"
+	if !all {
+		currentBranch, err := m.git.GetCurrentBranch()
+		if err != nil {
+			return errs.New(errs.DetachedHead, "Not on a branch (detached HEAD state)")
+		}
+		refs = wipRefPrefix + currentBranch + "/*"
+	}
+
+	if err := m.git.Stream("push", "--force", remote, refs+":"+refs); err != nil {
+		return fmt.Errorf("failed to push WIP snapshots to %s: %w", remote, err)
+	}
+	fmt.Printf("✅ Pushed WIP snapshots to %s\n", remote)
+	return nil
+}
+
+// fetchWIP fetches all WIP snapshots from a remote
+func (m *Manager) fetchWIP(args []string) error {
+	cmd := command("wip", "fetch")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("wip", "fetch")
+	}
+	if err := cmd.extraArgs(flags, 1); err != nil {
+		return err
+	}
+
+	remote := "origin"
+	if len(flags.Args()) > 0 {
+		remote = flags.Args()[0]
+	}
+
+	refs := wipRefPrefix + "*"
+	if err := m.git.Stream("fetch", remote, "+"+refs+":"+refs); err != nil {
+		return fmt.Errorf("failed to fetch WIP snapshots from %s: %w", remote, err)
+	}
+	fmt.Printf("✅ Fetched WIP snapshots from %s\n", remote)
+	return nil
+}
@@ -0,0 +1,416 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// worktreeInfo is an entry of git worktree list --porcelain
+type worktreeInfo struct {
+	Path     string `json:"path"`
+	Head     string `json:"head"`
+	Branch   string `json:"branch"`
+	Bare     bool   `json:"bare"`
+	Detached bool   `json:"detached"`
+	Locked   bool   `json:"locked"`
+	Prunable bool   `json:"prunable"`
+}
+
+// worktreeReport is a worktree as reported by worktree list --json
+type worktreeReport struct {
+	worktreeInfo
+	Current bool `json:"current"` // the worktree gitAT runs in
+}
+
+// parseWorktrees parses git worktree list --porcelain
+func parseWorktrees(out string) []worktreeInfo {
+	var worktrees []worktreeInfo
+	var current *worktreeInfo
+
+	for _, line := range strings.Split(out, "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, worktreeInfo{Path: value})
+			current = &worktrees[len(worktrees)-1]
+		case "HEAD":
+			if current != nil {
+				current.Head = value
+			}
+		case "branch":
+			if current != nil {
+				current.Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		case "bare":
+			if current != nil {
+				current.Bare = true
+			}
+		case "detached":
+			if current != nil {
+				current.Detached = true
+			}
+		case "locked":
+			if current != nil {
+				current.Locked = true
+			}
+		case "prunable":
+			if current != nil {
+				current.Prunable = true
+			}
+		}
+	}
+
+	return worktrees
+}
+
+// getWorktrees returns the worktrees of the repository, main worktree first
+func (m *Manager) getWorktrees() ([]worktreeInfo, error) {
+	out, err := m.git.Run("worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	return parseWorktrees(out), nil
+}
+
+// findWorktree returns the worktree with branch checked out, or nil
+func (m *Manager) findWorktree(branch string) *worktreeInfo {
+	worktrees, err := m.getWorktrees()
+	if err != nil {
+		return nil
+	}
+	for i := range worktrees {
+		if worktrees[i].Branch == branch {
+			return &worktrees[i]
+		}
+	}
+	return nil
+}
+
+// useWorktree reports whether work branches are created in worktrees: the
+// --worktree and --no-worktree flags win over at.worktree
+func (m *Manager) useWorktree(flagOn, flagOff bool) bool {
+	if flagOn {
+		return true
+	}
+	if flagOff {
+		return false
+	}
+	setting, _ := m.git.GetConfig("at.worktree")
+	return setting == "true"
+}
+
+// worktreeDir returns the directory holding gitAT worktrees. It defaults to
+// <repo>.worktrees next to the repository; relative at.worktree.dir values
+// are resolved from the main worktree.
+func (m *Manager) worktreeDir() (string, error) {
+	worktrees, err := m.getWorktrees()
+	if err != nil || len(worktrees) == 0 {
+		return "", errs.New(errs.NotARepo, "Not in a git repository")
+	}
+	root := worktrees[0].Path
+
+	dir, _ := m.git.GetConfig("at.worktree.dir")
+	if dir == "" {
+		return filepath.Join(filepath.Dir(root), filepath.Base(root)+".worktrees"), nil
+	}
+	if strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[2:])
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	return filepath.Clean(dir), nil
+}
+
+// worktreePath returns the worktree path of a branch
+func (m *Manager) worktreePath(branch string) (string, error) {
+	dir, err := m.worktreeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, branch), nil
+}
+
+// createWorktreeBranch creates branchName from baseBranch in a new worktree,
+// leaving the current checkout and its uncommitted changes untouched
+func (m *Manager) createWorktreeBranch(branchName, baseBranch string) error {
+	workType := strings.Split(branchName, "-")[0]
+
+	if !m.validateBranchName(branchName) {
+		return errs.New(errs.Usage, "Invalid branch name '%s'\nBranch names must contain only alphanumeric characters, hyphens, underscores, and slashes", branchName)
+	}
+	if m.branchExists(branchName) {
+		return errs.New(errs.PolicyViolation, "Branch '%s' already exists", branchName)
+	}
+
+	path, err := m.worktreePath(branchName)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return errs.New(errs.PolicyViolation, "Worktree directory '%s' already exists", path)
+	}
+
+	fmt.Printf("Creating %s branch %s in worktree: %s\n", workType, branchName, path)
+	if _, err := m.git.Run("worktree", "add", "-b", branchName, path, baseBranch); err != nil {
+		return errs.Wrap(errs.KindOf(err), err, "Failed to create worktree for '%s'", branchName)
+	}
+
+	if err := m.setStackParent(branchName, baseBranch); err != nil {
+		fmt.Printf("Warning: Failed to record parent branch: %v\n", err)
+	}
+	m.inheritBranchContext(branchName, baseBranch)
+
+	// Work moves to the new worktree, which shares the time journal
+	currentBranch, _ := m.git.GetCurrentBranch()
+	m.recordBranchSwitch("worktree", currentBranch, branchName)
+	if err := m.setBranch(branchName); err != nil {
+		fmt.Printf("Warning: Failed to set working branch, but %s branch is ready\n", workType)
+	}
+
+	fmt.Println()
+	fmt.Printf("✅ %s branch '%s' created in a worktree!\n", workType, branchName)
+	fmt.Println()
+	fmt.Println("Current status:")
+	fmt.Printf("  Branch: %s\n", branchName)
+	fmt.Printf("  Base: %s\n", baseBranch)
+	fmt.Printf("  Worktree: %s\n", path)
+	fmt.Println()
+	fmt.Println("Next steps:")
+	fmt.Printf("  cd %s\n", path)
+	fmt.Println()
+
+	return nil
+}
+
+// Worktree manages the worktrees of work branches
+func (m *Manager) Worktree(args []string) error {
+	if len(args) == 0 {
+		return m.listWorktrees()
+	}
+
+	switch args[0] {
+	case "-h", "--help", "help", "h":
+		return m.help("worktree")
+	case "list", "ls":
+		return m.listWorktrees()
+	case "open":
+		cmd := command("worktree", "open")
+		flags, err := cmd.parse(args[1:])
+		if err != nil {
+			return err
+		}
+		if flags.Has("help") {
+			return m.help("worktree", "open")
+		}
+		if err := cmd.extraArgs(flags, 1); err != nil {
+			return err
+		}
+		if len(flags.Args()) == 1 {
+			return m.openWorktree(flags.Args()[0])
+		}
+		choice, ok, err := m.pickBranch("Open the worktree of", "", func(c branchChoice) bool { return !c.Remote })
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errs.New(errs.Usage, "Usage: git @ worktree open <branch>")
+		}
+		return m.openWorktree(choice.Name)
+	case "remove", "rm":
+		return m.removeWorktree(args[1:])
+	case "prune":
+		return m.pruneWorktrees(false)
+	default:
+		return errs.New(errs.Usage, "Unknown worktree command '%s'", args[0])
+	}
+}
+
+// listWorktrees shows all worktrees
+func (m *Manager) listWorktrees() error {
+	worktrees, err := m.getWorktrees()
+	if err != nil {
+		return err
+	}
+
+	top, _ := m.git.Run("rev-parse", "--show-toplevel")
+
+	if output.JSONEnabled() {
+		reports := []worktreeReport{}
+		for _, wt := range worktrees {
+			reports = append(reports, worktreeReport{worktreeInfo: wt, Current: wt.Path == top})
+		}
+		return output.JSON(map[string][]worktreeReport{"worktrees": reports})
+	}
+
+	var rows [][]string
+	for _, wt := range worktrees {
+		branch := wt.Branch
+		switch {
+		case wt.Bare:
+			branch = "(bare)"
+		case wt.Detached:
+			branch = "(detached)"
+		}
+
+		var state []string
+		if wt.Path == top {
+			state = append(state, "current")
+		}
+		if wt.Locked {
+			state = append(state, "locked")
+		}
+		if wt.Prunable {
+			state = append(state, "prunable")
+		}
+
+		head := wt.Head
+		if len(head) > 7 {
+			head = head[:7]
+		}
+		rows = append(rows, []string{branch, head, wt.Path, strings.Join(state, ", ")})
+	}
+
+	output.Table([]string{"Branch", "HEAD", "Path", "State"}, rows)
+	return nil
+}
+
+// openWorktree prints the worktree path of branch, creating the worktree
+// when the branch has none. Only the path is printed to stdout, so it can be
+// used as: cd "$(git @ worktree open <branch>)"
+func (m *Manager) openWorktree(branch string) error {
+	if wt := m.findWorktree(branch); wt != nil {
+		fmt.Println(wt.Path)
+		return nil
+	}
+
+	if !m.branchExists(branch) {
+		return errs.New(errs.NotFound, "Branch '%s' does not exist", branch)
+	}
+
+	path, err := m.worktreePath(branch)
+	if err != nil {
+		return err
+	}
+	if _, err := m.git.Run("worktree", "add", path, branch); err != nil {
+		return errs.Wrap(errs.KindOf(err), err, "Failed to create worktree for '%s'", branch)
+	}
+
+	output.Info("Created worktree for %s", branch)
+	fmt.Println(path)
+	return nil
+}
+
+// removeWorktree removes the worktree of a branch
+func (m *Manager) removeWorktree(args []string) error {
+	cmd := command("worktree", "remove")
+	flags, err := cmd.parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.Has("help") {
+		return m.help("worktree", "remove")
+	}
+	if err := cmd.extraArgs(flags, 1); err != nil {
+		return err
+	}
+	force := flags.Has("force")
+
+	var target string
+	if len(flags.Args()) > 0 {
+		target = flags.Args()[0]
+	} else {
+		hasWorktree := func(c branchChoice) bool { return !c.Remote && m.findWorktree(c.Name) != nil }
+		choice, ok, err := m.pickBranch("Remove the worktree of", "", hasWorktree)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errs.New(errs.Usage, "Usage: git @ worktree remove <branch|path> [--force]")
+		}
+		target = choice.Name
+	}
+
+	path := target
+	if wt := m.findWorktree(target); wt != nil {
+		path = wt.Path
+	}
+
+	removeArgs := []string{"worktree", "remove"}
+	if force {
+		removeArgs = append(removeArgs, "--force")
+	}
+	if _, err := m.git.Run(append(removeArgs, path)...); err != nil {
+		return errs.Wrap(errs.DirtyTree, err, "Failed to remove worktree '%s' (use --force to discard changes)", path)
+	}
+
+	fmt.Printf("✅ Removed worktree %s\n", path)
+	return nil
+}
+
+// pruneWorktrees forgets the worktrees whose directory was deleted. The
+// worktrees of branches being deleted are removed by sweep, as git refuses
+// to delete a branch checked out in a worktree.
+func (m *Manager) pruneWorktrees(quiet bool) error {
+	worktrees, err := m.getWorktrees()
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.git.Run("worktree", "prune"); err != nil {
+		return fmt.Errorf("failed to prune worktrees: %w", err)
+	}
+
+	pruned, verb := 0, "Pruned"
+	if m.dryRun() {
+		verb = "Would prune"
+	}
+	for _, wt := range worktrees {
+		if wt.Prunable && !wt.Locked {
+			fmt.Printf("🗑️  %s worktree %s (directory deleted)\n", verb, wt.Path)
+			pruned++
+		}
+	}
+
+	if pruned == 0 && !quiet {
+		fmt.Println("No worktrees to prune")
+	}
+	return nil
+}
+
+func worktreeCommand() *Command {
+	return &Command{
+		Name:    "worktree",
+		Summary: "Manage worktrees of work branches",
+		Usage:   []string{"[list]", "open [<branch>]", "remove [<branch>|<path>] [--force]", "prune"},
+		Description: `Manage the worktrees of work branches. In worktree mode, 'git @ work' and
+'git @ hotfix' create each branch in its own directory instead of
+switching the current checkout, so uncommitted work is never stashed.`,
+		Subcommands: []*Command{
+			{Name: "list", Summary: "Show all worktrees (default)"},
+			{Name: "open", Hint: "[<branch>]", Summary: "Print the worktree path of a branch, creating it if needed",
+				Args: []Arg{{Name: "<branch>", Source: SourceBranches}}},
+			{Name: "remove", Hint: "[<branch>]", Summary: "Remove the worktree of a branch (--force discards changes)",
+				Args: []Arg{{Name: "<branch>", Source: SourceBranches}}, Flags: []Flag{{Short: "f", Long: "force", Help: "Discard the changes of the worktree"}}},
+			{Name: "prune", Summary: "Forget worktrees whose directory was deleted"},
+		},
+		Examples: []Example{
+			{"git @ work feature login -w", "Create feature-login in a worktree"},
+			{"cd \"$(git @ worktree open feature-login)\"", ""},
+			{"git @ worktree remove feature-login", ""},
+			{"git @ sweep", "Also removes the worktrees of swept branches"},
+		},
+		Sections: []Section{
+			{"CONFIGURATION", `git config at.worktree true              # Always use worktrees for work/hotfix
+git config at.worktree.dir ../worktrees  # Worktree directory (default: ../<repo>.worktrees)`},
+		},
+		Run: (*Manager).Worktree,
+	}
+}
@@ -0,0 +1,210 @@
+// Package errs defines the typed errors of gitAT and the process exit codes
+// they map to, so scripts can tell why a command failed.
+package errs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/git"
+)
+
+// Kind classifies an error
+type Kind int
+
+// Error kinds, see ExitCode for the exit code of each
+const (
+	Unknown Kind = iota
+	Usage
+	NotARepo
+	DetachedHead
+	DirtyTree
+	Conflict
+	NotFound
+	ProviderAuth
+	PolicyViolation
+	NothingToDo
+	Cancelled
+)
+
+// kinds holds the name and exit code of each kind. The codes are part of
+// the command line interface: do not renumber them.
+var kinds = map[Kind]struct {
+	name string
+	code int
+}{
+	Unknown:         {"error", 1},
+	Usage:           {"usage", 2},
+	NotARepo:        {"not_a_repo", 3},
+	DetachedHead:    {"detached_head", 4},
+	DirtyTree:       {"dirty_tree", 5},
+	Conflict:        {"conflict", 6},
+	NotFound:        {"not_found", 7},
+	ProviderAuth:    {"provider_auth", 8},
+	PolicyViolation: {"policy_violation", 9},
+	NothingToDo:     {"nothing_to_do", 10},
+	Cancelled:       {"cancelled", 130},
+}
+
+// String returns the name of the kind used in JSON errors
+func (k Kind) String() string {
+	if info, ok := kinds[k]; ok {
+		return info.name
+	}
+	return kinds[Unknown].name
+}
+
+// ExitCode returns the process exit code of the kind
+func (k Kind) ExitCode() int {
+	if info, ok := kinds[k]; ok {
+		return info.code
+	}
+	return kinds[Unknown].code
+}
+
+// Error is an error of a known kind
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+// Error returns the message, followed by the cause when there is one
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	if e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Exit is the exit status of an external command, such as a plugin, that
+// already reported its own error. git @ exits with the same code without
+// printing anything.
+type Exit struct {
+	Name string
+	Code int
+}
+
+// Error returns the command and its exit status
+func (e *Exit) Error() string {
+	return fmt.Sprintf("%s exited with status %d", e.Name, e.Code)
+}
+
+// New returns an error of kind with a formatted message
+func New(kind Kind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap returns an error of kind caused by err
+func Wrap(kind Kind, err error, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// Is reports whether err is of kind
+func Is(err error, kind Kind) bool {
+	return KindOf(err) == kind
+}
+
+// KindOf returns the kind of err. Errors without a kind are classified from
+// the git failure they wrap where possible, and are Unknown otherwise.
+func KindOf(err error) Kind {
+	if err == nil {
+		return Unknown
+	}
+
+	var typed *Error
+	if errors.As(err, &typed) && typed.Kind != Unknown {
+		return typed.Kind
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return Cancelled
+	}
+
+	var gitErr *git.GitError
+	if errors.As(err, &gitErr) {
+		stderr := strings.ToLower(gitErr.Stderr)
+		switch {
+		case strings.Contains(stderr, "not a git repository"):
+			return NotARepo
+		case strings.Contains(stderr, "conflict"):
+			return Conflict
+		case strings.Contains(stderr, "would be overwritten"),
+			strings.Contains(stderr, "uncommitted changes"),
+			strings.Contains(stderr, "unstaged changes"):
+			return DirtyTree
+		case strings.Contains(stderr, "did not match any"),
+			strings.Contains(stderr, "unknown revision"),
+			strings.Contains(stderr, "not found"):
+			return NotFound
+		}
+	}
+
+	return Unknown
+}
+
+// ExitCode returns the process exit code for err, 0 when err is nil
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exit *Exit
+	if errors.As(err, &exit) {
+		return exit.Code
+	}
+	return KindOf(err).ExitCode()
+}
+
+// Message returns the message of err without the legacy "error: " prefix
+// and status emoji, so every error prints the same way
+func Message(err error) string {
+	message := strings.TrimSpace(err.Error())
+	message = strings.TrimPrefix(message, "❌")
+	message = strings.TrimSpace(message)
+	for _, prefix := range []string{"error: ", "Error: "} {
+		message = strings.TrimPrefix(message, prefix)
+	}
+	return message
+}
+
+// jsonError is the machine-readable form of an error
+type jsonError struct {
+	Error struct {
+		Kind     string `json:"kind"`
+		ExitCode int    `json:"exit_code"`
+		Message  string `json:"message"`
+		Stderr   string `json:"stderr,omitempty"`
+	} `json:"error"`
+}
+
+// JSON returns the machine-readable form of err:
+//
+//	{"error": {"kind": "detached_head", "exit_code": 4, "message": "..."}}
+//
+// The stderr of the failed git command is included when there is one.
+func JSON(err error) []byte {
+	var out jsonError
+	kind := KindOf(err)
+	out.Error.Kind = kind.String()
+	out.Error.ExitCode = kind.ExitCode()
+	out.Error.Message = Message(err)
+
+	var gitErr *git.GitError
+	if errors.As(err, &gitErr) {
+		out.Error.Stderr = gitErr.Stderr
+	}
+
+	data, _ := json.Marshal(out)
+	return data
+}
@@ -0,0 +1,101 @@
+package errs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/potsed/gitAT/internal/git"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{"typed", New(DetachedHead, "Not on a branch"), DetachedHead},
+		{"wrapped", fmt.Errorf("pr failed: %w", New(ProviderAuth, "not authenticated")), ProviderAuth},
+		{"plain", fmt.Errorf("error: something broke"), Unknown},
+		{"cancelled", &git.GitError{Args: []string{"fetch"}, Err: context.Canceled}, Cancelled},
+		{"timed out", &git.GitError{Args: []string{"push"}, Err: context.DeadlineExceeded}, Cancelled},
+		{"not a repo", &git.GitError{ExitCode: 128, Stderr: "fatal: not a git repository (or any of the parent directories): .git"}, NotARepo},
+		{"conflict", &git.GitError{ExitCode: 1, Stderr: "CONFLICT (content): Merge conflict in a.txt"}, Conflict},
+		{"dirty", &git.GitError{ExitCode: 1, Stderr: "error: Your local changes to the following files would be overwritten by checkout"}, DirtyTree},
+		{"missing", &git.GitError{ExitCode: 1, Stderr: "error: pathspec 'nope' did not match any file(s) known to git"}, NotFound},
+		{"typed wins", Wrap(PolicyViolation, &git.GitError{Stderr: "CONFLICT"}, "protected"), PolicyViolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KindOf(tt.err); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if code := ExitCode(nil); code != 0 {
+		t.Errorf("expected 0 for success, got %d", code)
+	}
+	if code := ExitCode(fmt.Errorf("boom")); code != 1 {
+		t.Errorf("expected 1 for unknown errors, got %d", code)
+	}
+
+	// The codes are documented, changing one breaks scripts
+	codes := map[Kind]int{
+		Usage: 2, NotARepo: 3, DetachedHead: 4, DirtyTree: 5, Conflict: 6, NotFound: 7,
+		ProviderAuth: 8, PolicyViolation: 9, NothingToDo: 10, Cancelled: 130,
+	}
+	for kind, code := range codes {
+		if got := ExitCode(New(kind, "x")); got != code {
+			t.Errorf("expected exit code %d for %s, got %d", code, kind, got)
+		}
+	}
+
+	// External commands keep their own exit code
+	if code := ExitCode(fmt.Errorf("plugin: %w", &Exit{Name: "git-@-x", Code: 42})); code != 42 {
+		t.Errorf("expected the exit code of the external command, got %d", code)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := map[string]string{
+		"error: Not in a git repository": "Not in a git repository",
+		"❌ Squashing failed":             "Squashing failed",
+		"failed to commit changes":       "failed to commit changes",
+	}
+	for in, want := range tests {
+		if got := Message(fmt.Errorf("%s", in)); got != want {
+			t.Errorf("Message(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	err := fmt.Errorf("failed to switch: %w", &git.GitError{
+		Args:     []string{"checkout", "nope"},
+		ExitCode: 1,
+		Stderr:   "error: pathspec 'nope' did not match any file(s) known to git",
+	})
+
+	var out struct {
+		Error struct {
+			Kind     string `json:"kind"`
+			ExitCode int    `json:"exit_code"`
+			Message  string `json:"message"`
+			Stderr   string `json:"stderr"`
+		} `json:"error"`
+	}
+	if jsonErr := json.Unmarshal(JSON(err), &out); jsonErr != nil {
+		t.Fatalf("invalid JSON: %v", jsonErr)
+	}
+	if out.Error.Kind != "not_found" || out.Error.ExitCode != 7 {
+		t.Errorf("unexpected kind %s (%d)", out.Error.Kind, out.Error.ExitCode)
+	}
+	if out.Error.Stderr == "" || out.Error.Message == "" {
+		t.Errorf("expected message and stderr, got %+v", out.Error)
+	}
+}
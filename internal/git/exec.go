@@ -0,0 +1,233 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// NetworkTimeout is the default timeout of network commands. Local
+// commands run hooks of any length and have none by default.
+// GITAT_GIT_TIMEOUT (a duration like 30m, 0 for none) sets the timeout of
+// every command.
+var NetworkTimeout = 10 * time.Minute
+
+// networkCommands talk to a remote and may take a while
+var networkCommands = map[string]bool{
+	"fetch":     true,
+	"pull":      true,
+	"push":      true,
+	"clone":     true,
+	"ls-remote": true,
+}
+
+// GitError describes a failed git command
+type GitError struct {
+	Args     []string
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+// Error returns the command and the reason git gave for the failure
+func (e *GitError) Error() string {
+	command := "git"
+	if len(e.Args) > 0 {
+		command += " " + e.Args[0]
+	}
+
+	switch {
+	case errors.Is(e.Err, context.DeadlineExceeded):
+		return command + " timed out"
+	case errors.Is(e.Err, context.Canceled):
+		return command + " cancelled"
+	case e.Stderr != "":
+		return fmt.Sprintf("%s failed (exit code %d): %s", command, e.ExitCode, e.Stderr)
+	case e.ExitCode > 0:
+		return fmt.Sprintf("%s failed (exit code %d)", command, e.ExitCode)
+	default:
+		return fmt.Sprintf("%s failed: %v", command, e.Err)
+	}
+}
+
+// Unwrap returns the underlying error
+func (e *GitError) Unwrap() error {
+	return e.Err
+}
+
+// Cancelled reports whether the command was interrupted or timed out
+func (e *GitError) Cancelled() bool {
+	return errors.Is(e.Err, context.Canceled) || errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// RunOptions controls how a git command is executed
+type RunOptions struct {
+	// Env adds environment variables
+	Env []string
+
+	// Stdin is passed to git as its standard input
+	Stdin io.Reader
+
+	// Stdout and Stderr stream the output as it is produced. Streamed
+	// stdout is not returned; stderr is still kept for the GitError.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Timeout overrides the default timeout of the command; a negative
+	// timeout disables it
+	Timeout time.Duration
+}
+
+// WithContext returns a copy of the repository whose commands are cancelled
+// with ctx
+func (r *Repository) WithContext(ctx context.Context) *Repository {
+	clone := *r
+	clone.ctx = ctx
+	return &clone
+}
+
+// WithPlanner returns a copy of the repository that records its mutating
+// commands in plan instead of running them, for dry runs. A nil plan runs
+// every command.
+func (r *Repository) WithPlanner(plan *Planner) *Repository {
+	clone := *r
+	clone.plan = plan
+	return &clone
+}
+
+// WithTrace returns a copy of the repository that writes every command it
+// runs, with its duration, to w. A nil w disables tracing.
+func (r *Repository) WithTrace(w io.Writer) *Repository {
+	clone := *r
+	clone.trace = w
+	return &clone
+}
+
+// context returns the context commands run under
+func (r *Repository) context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// Exec runs a git command with opts and returns its trimmed output. In a
+// dry run mutating commands are added to the plan and return no output.
+func (r *Repository) Exec(ctx context.Context, opts RunOptions, args ...string) (string, error) {
+	if r.plan != nil && IsMutating(args) {
+		r.plan.Add("git %s", FormatArgs(args))
+		return "", nil
+	}
+
+	start := time.Now()
+	output, err := r.exec(ctx, opts, args...)
+	if r.trace != nil {
+		r.traceCommand(args, time.Since(start), err)
+	}
+	return output, err
+}
+
+// traceCommand writes a command, its duration and its exit code on failure
+func (r *Repository) traceCommand(args []string, elapsed time.Duration, err error) {
+	status := elapsed.Round(time.Millisecond).String()
+	var gitErr *GitError
+	if errors.As(err, &gitErr) {
+		status += fmt.Sprintf(", exit %d", gitErr.ExitCode)
+	}
+	fmt.Fprintf(r.trace, "+ git %s (%s)\n", FormatArgs(args), status)
+}
+
+// exec runs a git command
+func (r *Repository) exec(ctx context.Context, opts RunOptions, args ...string) (string, error) {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = commandTimeout(args)
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.Path
+	cmd.Stdin = opts.Stdin
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+
+	// Let git clean up after the interrupt before it is killed
+	cmd.Cancel = func() error {
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = 5 * time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	if opts.Stdout != nil {
+		cmd.Stdout = opts.Stdout
+	}
+	cmd.Stderr = &stderr
+	if opts.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(opts.Stderr, &stderr)
+	}
+
+	if err := cmd.Run(); err != nil {
+		gitErr := &GitError{
+			Args:     args,
+			ExitCode: -1,
+			Stderr:   strings.TrimSpace(stderr.String()),
+			Err:      err,
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			gitErr.Err = ctxErr
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			gitErr.ExitCode = exitErr.ExitCode()
+		}
+		return "", gitErr
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// RunContext executes a git command, cancelled with ctx
+func (r *Repository) RunContext(ctx context.Context, args ...string) (string, error) {
+	return r.Exec(ctx, RunOptions{}, args...)
+}
+
+// RunWithInput executes a git command with input as its standard input
+func (r *Repository) RunWithInput(input string, args ...string) (string, error) {
+	return r.Exec(r.context(), RunOptions{Stdin: strings.NewReader(input)}, args...)
+}
+
+// Stream executes a long-running git command such as fetch or push, showing
+// its output as it is produced
+func (r *Repository) Stream(args ...string) error {
+	_, err := r.Exec(r.context(), RunOptions{Stdout: os.Stdout, Stderr: os.Stderr}, args...)
+	return err
+}
+
+// commandTimeout returns the default timeout of a git command, 0 for none
+func commandTimeout(args []string) time.Duration {
+	if value := os.Getenv("GITAT_GIT_TIMEOUT"); value != "" {
+		if timeout, err := time.ParseDuration(value); err == nil {
+			return timeout
+		}
+	}
+
+	if len(args) > 0 && networkCommands[args[0]] {
+		return NetworkTimeout
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRepository initializes an empty repository in a temp directory
+func newTestRepository(t *testing.T) *Repository {
+	t.Helper()
+	repo := NewRepository(t.TempDir())
+	if _, err := repo.Run("init", "--initial-branch=master"); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	return repo
+}
+
+func TestGitErrorCapturesStderr(t *testing.T) {
+	repo := newTestRepository(t)
+
+	_, err := repo.Run("checkout", "does-not-exist")
+	var gitErr *GitError
+	if !errors.As(err, &gitErr) {
+		t.Fatalf("expected a GitError, got %T: %v", err, err)
+	}
+	if gitErr.ExitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", gitErr.ExitCode)
+	}
+	if strings.Join(gitErr.Args, " ") != "checkout does-not-exist" {
+		t.Errorf("unexpected args %v", gitErr.Args)
+	}
+	if !strings.Contains(gitErr.Stderr, "does-not-exist") {
+		t.Errorf("expected the stderr of git, got %q", gitErr.Stderr)
+	}
+	if !strings.Contains(err.Error(), "git checkout failed (exit code 1): ") {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+	if gitErr.Cancelled() {
+		t.Error("a failed command is not cancelled")
+	}
+}
+
+func TestRunWithInput(t *testing.T) {
+	repo := newTestRepository(t)
+
+	hash, err := repo.RunWithInput("hello\n", "hash-object", "--stdin")
+	if err != nil {
+		t.Fatalf("hash-object failed: %v", err)
+	}
+	if hash != "ce013625030ba8dba906f756967f9e9ca394464a" {
+		t.Errorf("unexpected hash %s", hash)
+	}
+}
+
+func TestExecStreamsOutput(t *testing.T) {
+	repo := newTestRepository(t)
+
+	var stdout, stderr bytes.Buffer
+	output, err := repo.Exec(context.Background(), RunOptions{Stdout: &stdout, Stderr: &stderr}, "rev-parse", "--is-inside-work-tree")
+	if err != nil {
+		t.Fatalf("rev-parse failed: %v", err)
+	}
+	if output != "" || strings.TrimSpace(stdout.String()) != "true" {
+		t.Errorf("expected streamed output only, got %q and %q", output, stdout.String())
+	}
+
+	_, err = repo.Exec(context.Background(), RunOptions{Stderr: &stderr}, "rev-parse", "--verify", "nope")
+	var gitErr *GitError
+	if !errors.As(err, &gitErr) || gitErr.Stderr == "" || !strings.Contains(stderr.String(), gitErr.Stderr) {
+		t.Errorf("expected stderr to be streamed and kept, got %q and %v", stderr.String(), err)
+	}
+}
+
+func TestExecCancelled(t *testing.T) {
+	repo := newTestRepository(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := repo.WithContext(ctx).Run("status")
+
+	var gitErr *GitError
+	if !errors.As(err, &gitErr) || !gitErr.Cancelled() || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancelled GitError, got %v", err)
+	}
+	if err.Error() != "git status cancelled" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	repo := newTestRepository(t)
+
+	// cat-file --batch waits for input until the timeout stops it
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	defer writer.Close()
+	_, err = repo.Exec(context.Background(), RunOptions{Stdin: reader, Timeout: 100 * time.Millisecond}, "cat-file", "--batch")
+
+	if !errors.Is(err, context.DeadlineExceeded) || err.Error() != "git cat-file timed out" {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	t.Setenv("GITAT_GIT_TIMEOUT", "")
+	if got := commandTimeout([]string{"push", "origin"}); got != NetworkTimeout {
+		t.Errorf("expected the network timeout for push, got %s", got)
+	}
+	// Local commands run hooks, such as pre-commit, of any length
+	if got := commandTimeout([]string{"commit", "-m", "slow hooks"}); got != 0 {
+		t.Errorf("expected no timeout for commit, got %s", got)
+	}
+
+	t.Setenv("GITAT_GIT_TIMEOUT", "45m")
+	if got := commandTimeout([]string{"status"}); got != 45*time.Minute {
+		t.Errorf("expected GITAT_GIT_TIMEOUT to win, got %s", got)
+	}
+}
@@ -0,0 +1,254 @@
+// Package gittest provides test doubles for code that drives git: a
+// scriptable in-memory client and a builder for throwaway repositories.
+package gittest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/git"
+)
+
+// Fake is a scriptable git client. It records every invocation, keeps git
+// config in memory and answers other commands from canned outputs keyed by
+// argument prefix. Unscripted commands succeed with empty output.
+type Fake struct {
+	// Branch is returned by GetCurrentBranch; empty means detached HEAD
+	Branch string
+
+	// Branches and Merged back GetBranches and GetMergedBranches
+	Branches []string
+	Merged   map[string][]string
+
+	// Config holds the git config, multi-valued keys have several values
+	Config map[string][]string
+
+	// Calls records the arguments of every git invocation, in order
+	Calls [][]string
+
+	outputs map[string]string
+	errors  map[string]error
+}
+
+// NewFake returns a fake client on branch
+func NewFake(branch string) *Fake {
+	return &Fake{
+		Branch:  branch,
+		Merged:  make(map[string][]string),
+		Config:  make(map[string][]string),
+		outputs: make(map[string]string),
+		errors:  make(map[string]error),
+	}
+}
+
+// On makes commands starting with args (space separated) return output
+func (f *Fake) On(args, output string) *Fake {
+	f.outputs[args] = output
+	return f
+}
+
+// Fail makes commands starting with args (space separated) fail with stderr
+func (f *Fake) Fail(args string, stderr ...string) *Fake {
+	f.errors[args] = &git.GitError{
+		Args:     strings.Fields(args),
+		ExitCode: 1,
+		Stderr:   strings.Join(stderr, "\n"),
+		Err:      fmt.Errorf("exit status 1"),
+	}
+	return f
+}
+
+// Set sets a config value
+func (f *Fake) Set(key, value string) *Fake {
+	f.Config[key] = []string{value}
+	return f
+}
+
+// Called reports whether a command starting with args was run
+func (f *Fake) Called(args string) bool {
+	return len(f.CallsTo(args)) > 0
+}
+
+// CallsTo returns the invocations starting with args
+func (f *Fake) CallsTo(args string) [][]string {
+	var calls [][]string
+	for _, call := range f.Calls {
+		if matches(strings.Join(call, " "), args) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Run records the command and returns its scripted result
+func (f *Fake) Run(args ...string) (string, error) {
+	f.Calls = append(f.Calls, append([]string(nil), args...))
+
+	if len(args) > 0 && args[0] == "config" {
+		if output, err, ok := f.config(args[1:]); ok {
+			return output, err
+		}
+	}
+
+	// The longest matching prefix wins, so specific scripts override general ones
+	command := strings.Join(args, " ")
+	best := -1
+	var output string
+	var err error
+	for prefix, out := range f.outputs {
+		if matches(command, prefix) && len(prefix) > best {
+			best, output, err = len(prefix), out, nil
+		}
+	}
+	for prefix, e := range f.errors {
+		if matches(command, prefix) && len(prefix) >= best {
+			best, output, err = len(prefix), "", e
+		}
+	}
+	return output, err
+}
+
+// RunWithEnv records the command like Run, ignoring the environment
+func (f *Fake) RunWithEnv(env []string, args ...string) (string, error) {
+	return f.Run(args...)
+}
+
+// RunWithInput records the command like Run, ignoring the input
+func (f *Fake) RunWithInput(input string, args ...string) (string, error) {
+	return f.Run(args...)
+}
+
+// Stream records the command like Run, discarding the output
+func (f *Fake) Stream(args ...string) error {
+	_, err := f.Run(args...)
+	return err
+}
+
+// GetCurrentBranch returns Branch
+func (f *Fake) GetCurrentBranch() (string, error) {
+	if f.Branch == "" {
+		return "", &git.GitError{
+			Args:     []string{"symbolic-ref", "--short", "HEAD"},
+			ExitCode: 128,
+			Stderr:   "fatal: ref HEAD is not a symbolic ref",
+			Err:      fmt.Errorf("exit status 128"),
+		}
+	}
+	return f.Branch, nil
+}
+
+// GetConfig returns the last value of key
+func (f *Fake) GetConfig(key string) (string, error) {
+	output, err, _ := f.config([]string{"--get", key})
+	return output, err
+}
+
+// GetConfigAll returns all values of key
+func (f *Fake) GetConfigAll(key string) ([]string, error) {
+	values, ok := f.Config[key]
+	if !ok {
+		return nil, configError([]string{"--get-all", key}, 1)
+	}
+	return append([]string(nil), values...), nil
+}
+
+// SetConfig sets key to value
+func (f *Fake) SetConfig(key, value string) error {
+	f.Calls = append(f.Calls, []string{"config", key, value})
+	f.Set(key, value)
+	return nil
+}
+
+// GetBranches returns Branches
+func (f *Fake) GetBranches() ([]string, error) {
+	return append([]string(nil), f.Branches...), nil
+}
+
+// GetMergedBranches returns the branches scripted as merged into branch
+func (f *Fake) GetMergedBranches(branch string) ([]string, error) {
+	return append([]string(nil), f.Merged[branch]...), nil
+}
+
+// DeleteBranch records the deletion and removes name from Branches
+func (f *Fake) DeleteBranch(name string, force bool) error {
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+	if _, err := f.Run("branch", flag, name); err != nil {
+		return err
+	}
+
+	for i, branch := range f.Branches {
+		if branch == name {
+			f.Branches = append(f.Branches[:i], f.Branches[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// config emulates the git config invocations used by gitAT. It reports
+// false for forms it does not know, which then fall back to the scripts.
+func (f *Fake) config(args []string) (string, error, bool) {
+	if len(args) == 0 {
+		return "", nil, false
+	}
+
+	switch {
+	case args[0] == "--get" && len(args) == 2:
+		values := f.Config[args[1]]
+		if len(values) == 0 {
+			return "", configError(args, 1), true
+		}
+		return values[len(values)-1], nil, true
+	case args[0] == "--get-all" && len(args) == 2:
+		values := f.Config[args[1]]
+		if len(values) == 0 {
+			return "", configError(args, 1), true
+		}
+		return strings.Join(values, "\n"), nil, true
+	case args[0] == "--get-regexp" && len(args) == 2:
+		var lines []string
+		for key, values := range f.Config {
+			if strings.Contains(key, strings.Trim(args[1], "^$")) {
+				for _, value := range values {
+					lines = append(lines, key+" "+value)
+				}
+			}
+		}
+		sort.Strings(lines)
+		return strings.Join(lines, "\n"), nil, true
+	case args[0] == "--add" && len(args) == 3:
+		f.Config[args[1]] = append(f.Config[args[1]], args[2])
+		return "", nil, true
+	case args[0] == "--replace-all" && len(args) == 3:
+		f.Config[args[1]] = []string{args[2]}
+		return "", nil, true
+	case (args[0] == "--unset" || args[0] == "--unset-all") && len(args) == 2:
+		if _, ok := f.Config[args[1]]; !ok {
+			return "", configError(args, 5), true
+		}
+		delete(f.Config, args[1])
+		return "", nil, true
+	case len(args) == 2 && !strings.HasPrefix(args[0], "-"):
+		f.Config[args[0]] = []string{args[1]}
+		return "", nil, true
+	}
+	return "", nil, false
+}
+
+// configError is the error of a git config invocation exiting with code
+func configError(args []string, code int) error {
+	return &git.GitError{
+		Args:     append([]string{"config"}, args...),
+		ExitCode: code,
+		Err:      fmt.Errorf("exit status %d", code),
+	}
+}
+
+// matches reports whether command is prefix or starts with prefix + " "
+func matches(command, prefix string) bool {
+	return command == prefix || strings.HasPrefix(command, prefix+" ")
+}
@@ -0,0 +1,68 @@
+package gittest
+
+import "testing"
+
+func TestFakeScripts(t *testing.T) {
+	fake := NewFake("main").
+		On("rev-parse", "abc").
+		On("rev-parse --show-toplevel", "/repo").
+		Fail("push")
+
+	if out, _ := fake.Run("rev-parse", "HEAD"); out != "abc" {
+		t.Errorf("expected the general script, got %q", out)
+	}
+	if out, _ := fake.Run("rev-parse", "--show-toplevel"); out != "/repo" {
+		t.Errorf("expected the longest prefix to win, got %q", out)
+	}
+	if _, err := fake.Run("push", "origin", "main"); err == nil {
+		t.Error("expected push to fail")
+	}
+	if out, err := fake.Run("status"); out != "" || err != nil {
+		t.Errorf("expected unscripted commands to succeed silently, got %q, %v", out, err)
+	}
+	if len(fake.CallsTo("rev-parse")) != 2 || fake.Called("rev-parse-x") {
+		t.Errorf("unexpected calls %v", fake.Calls)
+	}
+}
+
+func TestFakeConfig(t *testing.T) {
+	fake := NewFake("main")
+
+	if _, err := fake.GetConfig("at.trunk"); err == nil {
+		t.Error("expected unset key to fail")
+	}
+	fake.Run("config", "at.trunk", "develop")
+	if value, _ := fake.GetConfig("at.trunk"); value != "develop" {
+		t.Errorf("expected develop, got %q", value)
+	}
+
+	fake.Run("config", "--add", "at.pr.labels", "a")
+	fake.Run("config", "--add", "at.pr.labels", "b")
+	if values, _ := fake.GetConfigAll("at.pr.labels"); len(values) != 2 {
+		t.Errorf("expected two values, got %v", values)
+	}
+
+	fake.Run("config", "--unset-all", "at.pr.labels")
+	if _, err := fake.Run("config", "--get", "at.pr.labels"); err == nil {
+		t.Error("expected unset key to fail")
+	}
+}
+
+func TestRepoFixture(t *testing.T) {
+	repo := NewRepo(t).
+		Branch("feature-x").
+		Commit("a.txt", "a", "Add a")
+
+	if head := repo.Head(); head != "feature-x" {
+		t.Errorf("expected feature-x, got %s", head)
+	}
+	if subjects := repo.Subjects("master..feature-x"); len(subjects) != 1 || subjects[0] != "Add a" {
+		t.Errorf("unexpected subjects %v", subjects)
+	}
+
+	repo.Origin("git@github.com:acme/widgets.git")
+	repo.Run("push", "origin", "feature-x")
+	if _, err := repo.Remote().Run("rev-parse", "--verify", "feature-x"); err != nil {
+		t.Errorf("expected the push to reach the bare remote: %v", err)
+	}
+}
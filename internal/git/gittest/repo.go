@@ -0,0 +1,127 @@
+package gittest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/potsed/gitAT/internal/git"
+)
+
+// Repo is a throwaway git repository for integration tests. Its methods fail
+// the test on error and return the Repo, so fixtures read as a chain:
+//
+//	repo := gittest.NewRepo(t).
+//		Commit("a.txt", "a", "Add a").
+//		Branch("feature-x").
+//		Commit("b.txt", "b", "Add b")
+type Repo struct {
+	t    testing.TB
+	Dir  string
+	Git  *git.Repository
+	bare string
+}
+
+// NewRepo creates a repository on master with an initial commit and a fixed
+// identity, so tests do not depend on the user's git config
+func NewRepo(t testing.TB) *Repo {
+	t.Helper()
+
+	dir := t.TempDir()
+	r := &Repo{t: t, Dir: dir, Git: git.NewRepository(dir)}
+	r.Run("init", "--initial-branch=master")
+	r.Config("user.name", "gitAT Test")
+	r.Config("user.email", "test@example.com")
+	r.Config("commit.gpgsign", "false")
+	return r.Commit("README.md", "test repository\n", "Initial commit")
+}
+
+// Run runs a git command in the repository and returns its output
+func (r *Repo) Run(args ...string) string {
+	r.t.Helper()
+	output, err := r.Git.Run(args...)
+	if err != nil {
+		r.t.Fatalf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return output
+}
+
+// Write writes a file without committing it
+func (r *Repo) Write(path, content string) *Repo {
+	r.t.Helper()
+	full := filepath.Join(r.Dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		r.t.Fatalf("create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		r.t.Fatalf("write %s: %v", path, err)
+	}
+	return r
+}
+
+// Commit writes a file and commits it
+func (r *Repo) Commit(path, content, message string) *Repo {
+	r.t.Helper()
+	r.Write(path, content)
+	r.Run("add", path)
+	r.Run("commit", "-m", message)
+	return r
+}
+
+// Branch creates a branch from HEAD and checks it out
+func (r *Repo) Branch(name string) *Repo {
+	r.t.Helper()
+	r.Run("checkout", "-b", name)
+	return r
+}
+
+// Checkout checks out an existing branch
+func (r *Repo) Checkout(name string) *Repo {
+	r.t.Helper()
+	r.Run("checkout", name)
+	return r
+}
+
+// Config sets a git config value in the repository
+func (r *Repo) Config(key, value string) *Repo {
+	r.t.Helper()
+	r.Run("config", key, value)
+	return r
+}
+
+// Origin adds an origin remote that reports url (so platform detection sees
+// e.g. github.com) but pushes to a local bare repository
+func (r *Repo) Origin(url string) *Repo {
+	r.t.Helper()
+	if r.bare == "" {
+		r.bare = filepath.Join(r.t.TempDir(), "origin.git")
+		if _, err := git.NewRepository(filepath.Dir(r.bare)).Run("init", "--bare", r.bare); err != nil {
+			r.t.Fatalf("create bare remote: %v", err)
+		}
+	}
+	r.Run("remote", "add", "origin", url)
+	r.Config("remote.origin.pushurl", r.bare)
+	return r
+}
+
+// Remote returns the bare repository behind origin
+func (r *Repo) Remote() *git.Repository {
+	return git.NewRepository(r.bare)
+}
+
+// Head returns the checked out branch
+func (r *Repo) Head() string {
+	r.t.Helper()
+	return r.Run("rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// Subjects returns the commit subjects of revRange, newest first
+func (r *Repo) Subjects(revRange string) []string {
+	r.t.Helper()
+	output := r.Run("log", "--format=%s", revRange)
+	if output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
@@ -0,0 +1,184 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Planner collects the mutating commands of a dry run in the order they
+// would have run. Commands that only read, or only write objects (such as
+// commit-tree), still run so the plan follows the real code paths.
+type Planner struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+// NewPlanner returns an empty planner
+func NewPlanner() *Planner {
+	return &Planner{}
+}
+
+// Add records a step of the plan
+func (p *Planner) Add(format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.steps = append(p.steps, fmt.Sprintf(format, args...))
+}
+
+// Steps returns the recorded steps in order
+func (p *Planner) Steps() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.steps...)
+}
+
+// globalOptions are the git options taking a value before the command
+var globalOptions = map[string]bool{
+	"-C": true,
+	"-c": true,
+}
+
+// alwaysMutating lists the commands that change refs, the index, the
+// working tree or a remote whatever their arguments
+var alwaysMutating = map[string]bool{
+	"add":          true,
+	"am":           true,
+	"checkout":     true,
+	"cherry-pick":  true,
+	"clone":        true,
+	"commit":       true,
+	"fetch":        true,
+	"gc":           true,
+	"init":         true,
+	"merge":        true,
+	"mv":           true,
+	"prune":        true,
+	"pull":         true,
+	"push":         true,
+	"read-tree":    true,
+	"rebase":       true,
+	"reset":        true,
+	"restore":      true,
+	"revert":       true,
+	"rm":           true,
+	"switch":       true,
+	"update-index": true,
+	"update-ref":   true,
+}
+
+// IsMutating reports whether the git command args changes the repository,
+// its configuration or a remote
+func IsMutating(args []string) bool {
+	// Skip global options such as -C <path>
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if globalOptions[args[0]] {
+			args = args[1:]
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return false
+	}
+
+	command, rest := args[0], args[1:]
+	if alwaysMutating[command] {
+		return true
+	}
+
+	flags, positional := splitArgs(rest)
+	switch command {
+	case "branch":
+		if flags.any("-d", "-D", "--delete", "-m", "-M", "--move", "-c", "-C", "--copy",
+			"-f", "--force", "-u", "--set-upstream-to", "--unset-upstream", "--edit-description") {
+			return true
+		}
+		if flags.any("-l", "--list", "-a", "--all", "-r", "--remotes", "--merged", "--no-merged",
+			"--contains", "--no-contains", "--points-at", "--show-current", "-v", "-vv", "--format") {
+			return false
+		}
+		return len(positional) > 0
+	case "tag":
+		if flags.any("-d", "--delete", "-a", "--annotate", "-s", "--sign", "-f", "--force", "-m", "--message") {
+			return true
+		}
+		if flags.any("-l", "--list", "--contains", "--no-contains", "--points-at", "--merged", "--no-merged", "-n") {
+			return false
+		}
+		return len(positional) > 0
+	case "config":
+		if flags.any("--get", "--get-all", "--get-regexp", "--get-urlmatch", "-l", "--list") {
+			return false
+		}
+		if flags.any("--add", "--replace-all", "--unset", "--unset-all", "--remove-section", "--rename-section") {
+			return true
+		}
+		return len(positional) > 1
+	case "stash":
+		return len(positional) == 0 || !oneOf(positional[0], "list", "show", "create")
+	case "remote":
+		return len(positional) > 0 && !oneOf(positional[0], "show", "get-url")
+	case "worktree":
+		return len(positional) > 0 && positional[0] != "list"
+	case "symbolic-ref":
+		return flags.any("-d", "--delete") || len(positional) > 1
+	case "clean":
+		return !flags.any("-n", "--dry-run")
+	case "apply":
+		return !flags.any("--check", "--stat", "--numstat", "--summary")
+	}
+	return false
+}
+
+// argFlags is the set of flags given to a command
+type argFlags map[string]bool
+
+// any reports whether one of names was given
+func (f argFlags) any(names ...string) bool {
+	for _, name := range names {
+		if f[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// splitArgs separates the flags of a command from its positional arguments.
+// Flags are keyed without their =value.
+func splitArgs(args []string) (argFlags, []string) {
+	flags := argFlags{}
+	var positional []string
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return flags, append(positional, args[i+1:]...)
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			flags[strings.SplitN(arg, "=", 2)[0]] = true
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	return flags, positional
+}
+
+// oneOf reports whether value is one of values
+func oneOf(value string, values ...string) bool {
+	for _, v := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatArgs returns args as they would be typed in a shell
+func FormatArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"$`\\*?;&|<>(){}[]!#~") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
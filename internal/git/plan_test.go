@@ -0,0 +1,104 @@
+package git
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestIsMutating(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"status", "--porcelain"}, false},
+		{[]string{"log", "-1", "--format=%s"}, false},
+		{[]string{"rev-parse", "HEAD"}, false},
+		{[]string{"commit-tree", "HEAD^{tree}", "-m", "wip"}, false},
+		{[]string{"checkout", "-b", "feature-x"}, true},
+		{[]string{"commit", "-m", "Add x"}, true},
+		{[]string{"reset", "--hard", "HEAD~1"}, true},
+		{[]string{"push", "origin", "feature-x"}, true},
+		{[]string{"fetch", "--prune", "origin"}, true},
+		{[]string{"branch", "--format=%(refname:short)"}, false},
+		{[]string{"branch", "--merged", "master", "--format=%(refname:short)"}, false},
+		{[]string{"branch", "-D", "feature-x"}, true},
+		{[]string{"branch", "feature-y"}, true},
+		{[]string{"config", "--get", "at.trunk"}, false},
+		{[]string{"config", "--get-regexp", "^branch\\."}, false},
+		{[]string{"config", "at.trunk"}, false},
+		{[]string{"config", "at.trunk", "main"}, true},
+		{[]string{"config", "--unset-all", "at.label"}, true},
+		{[]string{"config", "--replace-all", "at.label", "x"}, true},
+		{[]string{"stash", "list"}, false},
+		{[]string{"stash", "create"}, false},
+		{[]string{"stash", "push", "-m", "x"}, true},
+		{[]string{"stash"}, true},
+		{[]string{"remote", "get-url", "origin"}, false},
+		{[]string{"remote", "add", "origin", "url"}, true},
+		{[]string{"worktree", "list", "--porcelain"}, false},
+		{[]string{"worktree", "remove", "../x"}, true},
+		{[]string{"symbolic-ref", "--short", "HEAD"}, false},
+		{[]string{"tag"}, false},
+		{[]string{"tag", "v1.0.0"}, true},
+		{[]string{"-C", "/tmp/repo", "commit", "-m", "x"}, true},
+		{[]string{"-C", "/tmp/repo", "status"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsMutating(tt.args); got != tt.want {
+			t.Errorf("IsMutating(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestFormatArgs(t *testing.T) {
+	got := FormatArgs([]string{"commit", "-m", "Add it's done", "--format=%s", ""})
+	want := `commit -m 'Add it'\''s done' --format=%s ''`
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestPlannerSkipsMutatingCommands(t *testing.T) {
+	repo := newTestRepository(t)
+	plan := NewPlanner()
+	dry := repo.WithPlanner(plan)
+
+	if _, err := dry.Run("config", "at.trunk", "main"); err != nil {
+		t.Fatalf("planned config failed: %v", err)
+	}
+	if _, err := dry.Run("commit", "--allow-empty", "-m", "Planned commit"); err != nil {
+		t.Fatalf("planned commit failed: %v", err)
+	}
+	if value, _ := dry.GetConfig("at.trunk"); value != "" {
+		t.Errorf("expected config to be unchanged, got %q", value)
+	}
+	if _, err := repo.Run("rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		t.Error("expected no commit to be made")
+	}
+
+	want := []string{"git config at.trunk main", "git commit --allow-empty -m 'Planned commit'"}
+	if got := plan.Steps(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected plan %q, got %q", want, got)
+	}
+}
+
+func TestTraceWritesCommands(t *testing.T) {
+	var trace bytes.Buffer
+	repo := newTestRepository(t).WithTrace(&trace)
+
+	repo.Run("rev-parse", "--git-dir")
+	repo.Run("rev-parse", "--verify", "missing")
+
+	lines := strings.Split(strings.TrimSpace(trace.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 traced commands, got %q", trace.String())
+	}
+	if !strings.HasPrefix(lines[0], "+ git rev-parse --git-dir (") || strings.Contains(lines[0], "exit") {
+		t.Errorf("unexpected trace %q", lines[0])
+	}
+	if !strings.Contains(lines[1], ", exit 128)") {
+		t.Errorf("expected the exit code of the failed command, got %q", lines[1])
+	}
+}
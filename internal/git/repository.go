@@ -1,14 +1,24 @@
 package git
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
+	"io"
 	"strings"
 )
 
 // Repository represents a Git repository
 type Repository struct {
 	Path string
+
+	// ctx cancels running commands, see WithContext
+	ctx context.Context
+
+	// plan records mutating commands instead of running them, see WithPlanner
+	plan *Planner
+
+	// trace receives every command with its duration, see WithTrace
+	trace io.Writer
 }
 
 // NewRepository creates a new Git repository instance
@@ -20,20 +30,17 @@ func NewRepository(path string) *Repository {
 
 // Run executes a Git command
 func (r *Repository) Run(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
-	cmd.Dir = r.Path
-	
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("git command failed: %w", err)
-	}
-	
-	return strings.TrimSpace(string(output)), nil
+	return r.Exec(r.context(), RunOptions{}, args...)
 }
 
-// GetCurrentBranch returns the current branch name
+// RunWithEnv executes a Git command with additional environment variables
+func (r *Repository) RunWithEnv(env []string, args ...string) (string, error) {
+	return r.Exec(r.context(), RunOptions{Env: env}, args...)
+}
+
+// GetCurrentBranch returns the current branch name, failing on a detached HEAD
 func (r *Repository) GetCurrentBranch() (string, error) {
-	return r.Run("rev-parse", "--abbrev-ref", "HEAD")
+	return r.Run("symbolic-ref", "--short", "HEAD")
 }
 
 // GetConfig gets a Git configuration value
@@ -41,6 +48,20 @@ func (r *Repository) GetConfig(key string) (string, error) {
 	return r.Run("config", "--get", key)
 }
 
+// GetConfigAll gets all values of a multi-valued Git configuration key
+func (r *Repository) GetConfigAll(key string) ([]string, error) {
+	output, err := r.Run("config", "--get-all", key)
+	if err != nil {
+		return nil, err
+	}
+
+	if output == "" {
+		return []string{}, nil
+	}
+
+	return strings.Split(output, "\n"), nil
+}
+
 // SetConfig sets a Git configuration value
 func (r *Repository) SetConfig(key, value string) error {
 	_, err := r.Run("config", key, value)
@@ -0,0 +1,140 @@
+// Package label renders gitAT label templates.
+//
+// A template is plain text with placeholders in braces:
+//
+//	{product}          the value of product
+//	{type|upper}       the value of type, upper-cased (filters: upper, lower)
+//	{?[{issue}] }      an optional section, dropped when a placeholder in it
+//	                   is empty
+//	{{ and }}          literal braces
+//
+// Optional sections can be nested; an inner section that collapses does not
+// collapse the section around it.
+package label
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Values maps placeholder names to their values
+type Values map[string]string
+
+// Render expands template with values. Placeholders that are not in values
+// are an error, so typos in at.label are reported instead of ignored.
+func Render(template string, values Values) (string, error) {
+	p := &parser{src: template, values: values}
+	out, _, err := p.parse(false)
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// Validate checks that template is well formed and only uses placeholders
+// listed in names
+func Validate(template string, names []string) error {
+	values := make(Values, len(names))
+	for _, name := range names {
+		values[name] = name
+	}
+	_, err := Render(template, values)
+	return err
+}
+
+// parser renders a template in a single pass
+type parser struct {
+	src    string
+	pos    int
+	values Values
+}
+
+// parse renders until the end of the template or, inside a section, until
+// its closing brace. It reports whether a placeholder rendered empty.
+func (p *parser) parse(inSection bool) (string, bool, error) {
+	var b strings.Builder
+	empty := false
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], "{{"):
+			b.WriteByte('{')
+			p.pos += 2
+		case strings.HasPrefix(p.src[p.pos:], "}}"):
+			b.WriteByte('}')
+			p.pos += 2
+		case c == '}' && inSection:
+			p.pos++
+			return b.String(), empty, nil
+		case c == '}':
+			return "", false, fmt.Errorf("unexpected '}' at position %d (use }} for a literal brace)", p.pos)
+		case strings.HasPrefix(p.src[p.pos:], "{?"):
+			p.pos += 2
+			section, sectionEmpty, err := p.parse(true)
+			if err != nil {
+				return "", false, err
+			}
+			if !sectionEmpty {
+				b.WriteString(section)
+			}
+		case c == '{':
+			value, err := p.placeholder()
+			if err != nil {
+				return "", false, err
+			}
+			if value == "" {
+				empty = true
+			}
+			b.WriteString(value)
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	if inSection {
+		return "", false, fmt.Errorf("unterminated section in '%s'", p.src)
+	}
+	return b.String(), empty, nil
+}
+
+// placeholder renders {name} or {name|filter} at the current position
+func (p *parser) placeholder() (string, error) {
+	end := strings.IndexByte(p.src[p.pos:], '}')
+	if end < 0 {
+		return "", fmt.Errorf("unterminated placeholder at position %d", p.pos)
+	}
+	expr := p.src[p.pos+1 : p.pos+end]
+	p.pos += end + 1
+
+	name, filter, _ := strings.Cut(expr, "|")
+	name = strings.TrimSpace(name)
+	value, ok := p.values[name]
+	if !ok {
+		return "", fmt.Errorf("unknown placeholder {%s} (available: %s)", name, p.names())
+	}
+
+	switch strings.TrimSpace(filter) {
+	case "":
+	case "upper":
+		value = strings.ToUpper(value)
+	case "lower":
+		value = strings.ToLower(value)
+	default:
+		return "", fmt.Errorf("unknown filter '%s' in {%s}", filter, expr)
+	}
+	return value, nil
+}
+
+// names returns the available placeholder names, sorted
+func (p *parser) names() string {
+	names := make([]string, 0, len(p.values))
+	for name := range p.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,47 @@
+package label
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	values := Values{"product": "shop", "feature": "", "issue": "SHOP-1", "type": "feature"}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"[{product}.{issue}]", "[shop.SHOP-1]"},
+		{"{type|upper}: {issue|lower}", "FEATURE: shop-1"},
+		{"{?[{feature}] }{issue}", "SHOP-1"},
+		{"{?[{issue}] }{product}", "[SHOP-1] shop"},
+		{"{?({product}{?/{feature}})}", "(shop)"},
+		{"{{literal}} {product}", "{literal} shop"},
+		{"plain text", "plain text"},
+	}
+	for _, tt := range tests {
+		got, err := Render(tt.template, values)
+		if err != nil {
+			t.Errorf("Render(%q) failed: %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	values := Values{"issue": "SHOP-1"}
+
+	for _, template := range []string{"{unknown}", "{issue", "{?[{issue}]", "}", "{issue|title}"} {
+		if _, err := Render(template, values); err == nil {
+			t.Errorf("Expected an error for %q", template)
+		}
+	}
+
+	if err := Validate("{product}", []string{"issue"}); err == nil || !strings.Contains(err.Error(), "available: issue") {
+		t.Errorf("Expected unknown placeholder error, got %v", err)
+	}
+}
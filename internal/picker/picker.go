@@ -0,0 +1,319 @@
+// Package picker is a fuzzy finder for the terminal: typing part of a name
+// narrows the items to the best matches, the arrow keys choose one.
+//
+// Matching is case-insensitive and in order, not contiguous: "fli" matches
+// "feature-login". Matches at the start of the name or of a word (after
+// - / _ . or a space) and runs of consecutive letters score higher, gaps
+// lower, so "fl" ranks feature-login above fix-all-links.
+package picker
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/term"
+
+	"github.com/potsed/gitAT/internal/errs"
+)
+
+// Item is a choice. Its name is matched; the detail is shown next to it.
+type Item struct {
+	Name   string
+	Detail string
+}
+
+// Func picks one of items, starting on items[selected], and returns its
+// index
+type Func func(title string, items []Item, selected int) (int, error)
+
+// Available reports whether Run can ask the user: stdin is a terminal
+func Available() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Run is the Func of the terminal. It reads the keys from stdin and draws
+// below the cursor on stderr, leaving stdout to the command.
+func Run(title string, items []Item, selected int) (int, error) {
+	if len(items) == 0 {
+		return -1, errs.New(errs.NotFound, "Nothing to pick from")
+	}
+
+	final, err := tea.NewProgram(New(title, items, selected), tea.WithOutput(os.Stderr)).Run()
+	if err != nil {
+		return -1, fmt.Errorf("failed to run the picker: %w", err)
+	}
+	if choice := final.(Model).choice; choice >= 0 {
+		return choice, nil
+	}
+	return -1, errs.New(errs.Cancelled, "Cancelled")
+}
+
+// Scores of Match
+const (
+	scoreLetter      = 16 // every matched letter
+	bonusStart       = 12 // first letter of the name
+	bonusWord        = 8  // first letter of a word
+	bonusConsecutive = 10 // letter right after the previous match
+	penaltyGap       = 1  // every letter skipped between two matches
+	maxGapPenalty    = 8
+)
+
+// Match scores text against pattern. It returns the positions of the
+// matched runes and false when the runes of pattern are not all in text, in
+// order.
+func Match(pattern, text string) (int, []int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(text)
+	if len(p) == 0 {
+		return 0, nil, true
+	}
+
+	// Try every start of the first letter, matching the rest greedily, and
+	// keep the best: "login" in "lib-login" starts at the second l
+	best, bestPositions := 0, []int(nil)
+	for start := range t {
+		if unicode.ToLower(t[start]) != p[0] {
+			continue
+		}
+		positions := []int{start}
+		for i, j := 1, start+1; i < len(p) && j < len(t); j++ {
+			if unicode.ToLower(t[j]) == p[i] {
+				positions = append(positions, j)
+				i++
+			}
+		}
+		if len(positions) < len(p) {
+			// Later starts have even fewer letters left
+			break
+		}
+		if score := score(t, positions); bestPositions == nil || score > best {
+			best, bestPositions = score, positions
+		}
+	}
+	return best, bestPositions, bestPositions != nil
+}
+
+// score sums the score of the runes of t at positions
+func score(t []rune, positions []int) int {
+	total := 0
+	for n, pos := range positions {
+		total += scoreLetter
+		switch {
+		case pos == 0:
+			total += bonusStart
+		case strings.ContainsRune("-/_. ", t[pos-1]):
+			total += bonusWord
+		}
+		if n > 0 {
+			if gap := pos - positions[n-1] - 1; gap == 0 {
+				total += bonusConsecutive
+			} else {
+				total -= min(gap*penaltyGap, maxGapPenalty)
+			}
+		}
+	}
+	return total
+}
+
+// Result is an item matching the pattern
+type Result struct {
+	Index     int // in the items
+	Score     int
+	Positions []int // matched runes of the name
+}
+
+// Filter returns the items matching pattern, best first. Equal scores keep
+// shorter names first, then the order of the items.
+func Filter(pattern string, items []Item) []Result {
+	var results []Result
+	for i, item := range items {
+		if score, positions, ok := Match(pattern, item.Name); ok {
+			results = append(results, Result{Index: i, Score: score, Positions: positions})
+		}
+	}
+	if pattern != "" {
+		sort.SliceStable(results, func(a, b int) bool {
+			if results[a].Score != results[b].Score {
+				return results[a].Score > results[b].Score
+			}
+			return len(items[results[a].Index].Name) < len(items[results[b].Index].Name)
+		})
+	}
+	return results
+}
+
+var (
+	primary   = lipgloss.Color("#7D56F4")
+	secondary = lipgloss.Color("#666666")
+
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(primary)
+	matchStyle    = lipgloss.NewStyle().Bold(true).Foreground(primary)
+	selectedStyle = lipgloss.NewStyle().Bold(true)
+	dimStyle      = lipgloss.NewStyle().Foreground(secondary)
+)
+
+// Model is the picker as a bubbletea model
+type Model struct {
+	title   string
+	items   []Item
+	input   textinput.Model
+	results []Result
+	cursor  int // in the results
+	offset  int // first result shown
+	rows    int
+	width   int
+	nameLen int    // width of the name column
+	choice  int    // index of the chosen item, -1 until then
+	done    bool   // chosen or cancelled
+	last    string // pattern of the results
+}
+
+// New returns the picker of items with the cursor on items[selected]
+func New(title string, items []Item, selected int) Model {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.PromptStyle = titleStyle
+	input.Placeholder = "type to filter"
+	input.Focus()
+
+	nameLen := 0
+	for _, item := range items {
+		nameLen = max(nameLen, ansi.StringWidth(item.Name))
+	}
+
+	m := Model{
+		title:   title,
+		items:   items,
+		input:   input,
+		results: Filter("", items),
+		rows:    10,
+		width:   80,
+		nameLen: min(nameLen, 40),
+		choice:  -1,
+	}
+	if selected > 0 && selected < len(items) {
+		m.cursor = selected
+		m.offset = max(selected-m.rows+1, 0)
+	}
+	return m
+}
+
+// Choice returns the index of the chosen item, false until one is chosen
+func (m Model) Choice() (int, bool) {
+	return m.choice, m.choice >= 0
+}
+
+// Init starts the cursor blinking
+func (m Model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles a message
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.rows = max(min(10, msg.Height-3), 1)
+		m.move(0)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.done = true
+			return m, tea.Quit
+		case "enter":
+			if len(m.results) == 0 {
+				return m, nil
+			}
+			m.choice, m.done = m.results[m.cursor].Index, true
+			return m, tea.Quit
+		case "up", "ctrl+p":
+			m.move(-1)
+			return m, nil
+		case "down", "ctrl+n":
+			m.move(1)
+			return m, nil
+		case "pgup":
+			m.move(-m.rows)
+			return m, nil
+		case "pgdown":
+			m.move(m.rows)
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if pattern := m.input.Value(); pattern != m.last {
+		m.last, m.results = pattern, Filter(pattern, m.items)
+		m.cursor, m.offset = 0, 0
+	}
+	return m, cmd
+}
+
+// move moves the cursor by delta, scrolling the results
+func (m *Model) move(delta int) {
+	m.cursor = max(min(m.cursor+delta, len(m.results)-1), 0)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.rows {
+		m.offset = m.cursor - m.rows + 1
+	}
+}
+
+// View renders the picker, and nothing once it is done
+func (m Model) View() string {
+	if m.done {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.title) + " " + m.input.View() + "\n")
+	for i := m.offset; i < len(m.results) && i < m.offset+m.rows; i++ {
+		result := m.results[i]
+		item := m.items[result.Index]
+
+		marker, name := "  ", highlight(item.Name, result.Positions)
+		if i == m.cursor {
+			marker, name = titleStyle.Render("▸ "), selectedStyle.Render(name)
+		}
+		padding := strings.Repeat(" ", max(m.nameLen-ansi.StringWidth(item.Name), 0))
+		line := marker + name + padding + "  " + dimStyle.Render(item.Detail)
+		b.WriteString(ansi.Truncate(line, max(m.width-1, 10), "…") + "\n")
+	}
+	if len(m.results) == 0 {
+		b.WriteString(dimStyle.Render("  No match") + "\n")
+	}
+	b.WriteString(dimStyle.Render(fmt.Sprintf("%d/%d • ↑/↓ move • enter select • esc cancel", len(m.results), len(m.items))))
+	return b.String()
+}
+
+// highlight renders the runes of name at positions as matched
+func highlight(name string, positions []int) string {
+	if len(positions) == 0 {
+		return name
+	}
+	matched := make(map[int]bool, len(positions))
+	for _, pos := range positions {
+		matched[pos] = true
+	}
+
+	var b strings.Builder
+	for i, r := range []rune(name) {
+		if matched[i] {
+			b.WriteString(matchStyle.Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package picker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		ok            bool
+		positions     []int
+	}{
+		{"", "main", true, nil},
+		{"fl", "feature-login", true, []int{0, 8}},
+		{"FL", "feature-login", true, []int{0, 8}},
+		{"login", "lib-login", true, []int{4, 5, 6, 7, 8}},
+		{"lgn", "feature-login", true, []int{8, 10, 12}},
+		{"nl", "feature-login", false, nil},
+		{"mainx", "main", false, nil},
+	}
+	for _, tt := range tests {
+		_, positions, ok := Match(tt.pattern, tt.text)
+		if ok != tt.ok || !reflect.DeepEqual(positions, tt.positions) {
+			t.Errorf("Match(%q, %q) = %v %v, want %v %v", tt.pattern, tt.text, positions, ok, tt.positions, tt.ok)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	items := []Item{{Name: "fix-all-links"}, {Name: "main"}, {Name: "feature-login"}, {Name: "origin/feature-login"}}
+
+	names := func(results []Result) string {
+		var names []string
+		for _, result := range results {
+			names = append(names, items[result.Index].Name)
+		}
+		return strings.Join(names, " ")
+	}
+
+	if got := names(Filter("", items)); got != "fix-all-links main feature-login origin/feature-login" {
+		t.Errorf("expected every item in order without a pattern, got %q", got)
+	}
+	// Word starts and runs beat scattered letters; shorter names win ties
+	if got := names(Filter("fl", items)); got != "feature-login origin/feature-login fix-all-links" {
+		t.Errorf("expected the best matches first, got %q", got)
+	}
+	if got := names(Filter("login", items)); got != "feature-login origin/feature-login" {
+		t.Errorf("expected only the matches, got %q", got)
+	}
+}
+
+// typeKeys sends text to the model, one key per rune
+func typeKeys(m Model, text string) Model {
+	for _, r := range text {
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = next.(Model)
+	}
+	return m
+}
+
+// send sends a key to the model
+func send(m Model, key tea.KeyType) (Model, tea.Cmd) {
+	next, cmd := m.Update(tea.KeyMsg{Type: key})
+	return next.(Model), cmd
+}
+
+func TestModel(t *testing.T) {
+	items := []Item{{Name: "main"}, {Name: "feature-login", Detail: "feature"}, {Name: "feature-logout"}, {Name: "hotfix-crash"}}
+
+	m := New("Switch to", items, 2)
+	if m.cursor != 2 {
+		t.Errorf("expected the cursor on the selected item, got %d", m.cursor)
+	}
+	if view := m.View(); !strings.Contains(view, "Switch to") || !strings.Contains(view, "feature") || !strings.Contains(view, "4/4") {
+		t.Errorf("expected the title, items and count, got:\n%s", view)
+	}
+
+	m = typeKeys(m, "flog")
+	if len(m.results) != 2 || m.cursor != 0 {
+		t.Fatalf("expected two matches with the cursor on the first, got %v at %d", m.results, m.cursor)
+	}
+	m, _ = send(m, tea.KeyDown)
+	m, _ = send(m, tea.KeyDown)
+	m, cmd := send(m, tea.KeyEnter)
+	if choice, ok := m.Choice(); !ok || items[choice].Name != "feature-logout" || cmd == nil {
+		t.Errorf("expected feature-logout chosen, got %d %v", choice, ok)
+	}
+	if view := m.View(); view != "" {
+		t.Errorf("expected the picker to clear once done, got %q", view)
+	}
+
+	// Nothing matches: enter does nothing, esc cancels
+	m = typeKeys(New("Switch to", items, 0), "zz")
+	if m, cmd = send(m, tea.KeyEnter); cmd != nil || !strings.Contains(m.View(), "No match") {
+		t.Errorf("expected enter to wait for a match, got:\n%s", m.View())
+	}
+	m, _ = send(m, tea.KeyEsc)
+	if _, ok := m.Choice(); ok || !m.done {
+		t.Error("expected esc to cancel")
+	}
+}
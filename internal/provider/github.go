@@ -0,0 +1,223 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+)
+
+// GitHub creates pull requests through the GitHub CLI (gh)
+type GitHub struct {
+	run     Runner
+	checked bool
+}
+
+// Name returns the platform name
+func (g *GitHub) Name() string {
+	return "github"
+}
+
+// Create opens a pull request with gh and returns its URL
+func (g *GitHub) Create(opts CreateOptions) (string, error) {
+	if err := g.check(); err != nil {
+		return "", err
+	}
+
+	output, err := g.run("gh", g.createArgs(opts)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create pull request: %w", err)
+	}
+
+	return lastLine(output), nil
+}
+
+// check verifies that gh is installed and authenticated
+func (g *GitHub) check() error {
+	if g.checked {
+		return nil
+	}
+
+	if _, err := g.run("gh", "--version"); err != nil {
+		return cliMissingError("GitHub CLI (gh) not installed. Please install it or use the web interface.\nInstall: https://cli.github.com/")
+	}
+
+	if _, err := g.run("gh", "auth", "status"); err != nil {
+		return errs.New(errs.ProviderAuth, "GitHub CLI not authenticated. Please run 'gh auth login' first.")
+	}
+
+	g.checked = true
+	return nil
+}
+
+// createArgs builds the gh pr create arguments for the given options
+func (g *GitHub) createArgs(opts CreateOptions) []string {
+	args := []string{"pr", "create", "--title", opts.Title, "--body", opts.Description, "--base", opts.Base, "--head", opts.Head}
+
+	if opts.Draft {
+		args = append(args, "--draft")
+	}
+	if len(opts.Reviewers) > 0 {
+		args = append(args, "--reviewer", strings.Join(opts.Reviewers, ","))
+	}
+	if len(opts.Labels) > 0 {
+		args = append(args, "--label", strings.Join(opts.Labels, ","))
+	}
+	if len(opts.Assignees) > 0 {
+		args = append(args, "--assignee", strings.Join(opts.Assignees, ","))
+	}
+	if opts.Milestone != "" {
+		args = append(args, "--milestone", opts.Milestone)
+	}
+
+	return args
+}
+
+// githubPR is the JSON shape returned by gh pr list/view --json
+type githubPR struct {
+	Number        int    `json:"number"`
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	Body          string `json:"body"`
+	State         string `json:"state"`
+	IsDraft       bool   `json:"isDraft"`
+	HeadRefName   string `json:"headRefName"`
+	BaseRefName   string `json:"baseRefName"`
+	Mergeable     string `json:"mergeable"`
+	LatestReviews []struct {
+		State string `json:"state"`
+	} `json:"latestReviews"`
+	StatusCheckRollup []struct {
+		Name       string `json:"name"`
+		Context    string `json:"context"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+		State      string `json:"state"`
+	} `json:"statusCheckRollup"`
+}
+
+// githubPRFields lists the fields requested from gh
+const githubPRFields = "number,url,title,body,state,isDraft,headRefName,baseRefName,mergeable,latestReviews,statusCheckRollup"
+
+// Find returns the open pull request whose head is branch
+func (g *GitHub) Find(branch string) (*PullRequest, error) {
+	if err := g.check(); err != nil {
+		return nil, err
+	}
+
+	output, err := g.run("gh", "pr", "list", "--head", branch, "--state", "open", "--limit", "1", "--json", githubPRFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	var prs []githubPR
+	if err := json.Unmarshal([]byte(output), &prs); err != nil {
+		return nil, fmt.Errorf("failed to parse gh output: %w", err)
+	}
+
+	if len(prs) == 0 {
+		return nil, nil
+	}
+
+	return prs[0].toPullRequest(), nil
+}
+
+// toPullRequest converts the gh JSON into a PullRequest
+func (p githubPR) toPullRequest() *PullRequest {
+	pr := &PullRequest{
+		Number:      p.Number,
+		URL:         p.URL,
+		Title:       p.Title,
+		Description: p.Body,
+		State:       strings.ToLower(p.State),
+		Draft:       p.IsDraft,
+		Head:        p.HeadRefName,
+		Base:        p.BaseRefName,
+		Mergeable:   strings.ToLower(p.Mergeable),
+	}
+
+	for _, review := range p.LatestReviews {
+		switch review.State {
+		case "APPROVED":
+			pr.Approvals++
+		case "CHANGES_REQUESTED":
+			pr.ChangesRequested++
+		}
+	}
+
+	for _, item := range p.StatusCheckRollup {
+		// Check runs report status/conclusion, commit statuses report context/state
+		name := item.Name
+		if name == "" {
+			name = item.Context
+		}
+
+		state := CheckPending
+		switch {
+		case item.State != "":
+			state = githubCheckState(item.State)
+		case item.Status == "COMPLETED":
+			state = githubCheckState(item.Conclusion)
+		}
+
+		pr.Checks = append(pr.Checks, Check{Name: name, State: state})
+	}
+
+	return pr
+}
+
+// githubCheckState maps a GitHub check conclusion or status state
+func githubCheckState(value string) string {
+	switch value {
+	case "SUCCESS", "NEUTRAL":
+		return CheckSuccess
+	case "FAILURE", "ERROR", "CANCELLED", "TIMED_OUT", "ACTION_REQUIRED", "STARTUP_FAILURE":
+		return CheckFailure
+	case "SKIPPED", "STALE":
+		return CheckSkipped
+	default:
+		return CheckPending
+	}
+}
+
+// Update edits the pull request and marks it ready for review if requested
+func (g *GitHub) Update(pr *PullRequest, opts UpdateOptions) error {
+	if err := g.check(); err != nil {
+		return err
+	}
+
+	number := strconv.Itoa(pr.Number)
+	args := []string{"pr", "edit", number}
+	if opts.Title != "" {
+		args = append(args, "--title", opts.Title)
+	}
+	if opts.Description != "" {
+		args = append(args, "--body", opts.Description)
+	}
+	if len(opts.Reviewers) > 0 {
+		args = append(args, "--add-reviewer", strings.Join(opts.Reviewers, ","))
+	}
+	if len(opts.Labels) > 0 {
+		args = append(args, "--add-label", strings.Join(opts.Labels, ","))
+	}
+	if len(opts.Assignees) > 0 {
+		args = append(args, "--add-assignee", strings.Join(opts.Assignees, ","))
+	}
+	if opts.Milestone != "" {
+		args = append(args, "--milestone", opts.Milestone)
+	}
+
+	if _, err := g.run("gh", args...); err != nil {
+		return fmt.Errorf("failed to update pull request: %w", err)
+	}
+
+	if opts.Ready && pr.Draft {
+		if _, err := g.run("gh", "pr", "ready", number); err != nil {
+			return fmt.Errorf("failed to mark pull request as ready: %w", err)
+		}
+	}
+
+	return nil
+}
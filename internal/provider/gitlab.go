@@ -0,0 +1,256 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+)
+
+// GitLab creates merge requests through the GitLab CLI (glab)
+type GitLab struct {
+	run     Runner
+	checked bool
+}
+
+// Name returns the platform name
+func (g *GitLab) Name() string {
+	return "gitlab"
+}
+
+// Create opens a merge request with glab and returns its URL
+func (g *GitLab) Create(opts CreateOptions) (string, error) {
+	if err := g.check(); err != nil {
+		return "", err
+	}
+
+	output, err := g.run("glab", g.createArgs(opts)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create merge request: %w", err)
+	}
+
+	return lastLine(output), nil
+}
+
+// check verifies that glab is installed and authenticated
+func (g *GitLab) check() error {
+	if g.checked {
+		return nil
+	}
+
+	if _, err := g.run("glab", "--version"); err != nil {
+		return cliMissingError("GitLab CLI (glab) not installed. Please install it or use the web interface.\nInstall: https://gitlab.com/gitlab-org/cli")
+	}
+
+	if _, err := g.run("glab", "auth", "status"); err != nil {
+		return errs.New(errs.ProviderAuth, "GitLab CLI not authenticated. Please run 'glab auth login' first.")
+	}
+
+	g.checked = true
+	return nil
+}
+
+// createArgs builds the glab mr create arguments for the given options
+func (g *GitLab) createArgs(opts CreateOptions) []string {
+	args := []string{"mr", "create", "--title", opts.Title, "--description", opts.Description, "--source-branch", opts.Head, "--target-branch", opts.Base}
+
+	if opts.Draft {
+		args = append(args, "--draft")
+	}
+	if len(opts.Reviewers) > 0 {
+		args = append(args, "--reviewer", strings.Join(opts.Reviewers, ","))
+	}
+	if len(opts.Labels) > 0 {
+		args = append(args, "--label", strings.Join(opts.Labels, ","))
+	}
+	if len(opts.Assignees) > 0 {
+		args = append(args, "--assignee", strings.Join(opts.Assignees, ","))
+	}
+	if opts.Milestone != "" {
+		args = append(args, "--milestone", opts.Milestone)
+	}
+
+	args = append(args, "--yes")
+	return args
+}
+
+// gitlabMR is the JSON shape returned by glab mr list/view --output json
+type gitlabMR struct {
+	IID                 int    `json:"iid"`
+	WebURL              string `json:"web_url"`
+	Title               string `json:"title"`
+	Description         string `json:"description"`
+	State               string `json:"state"`
+	Draft               bool   `json:"draft"`
+	SourceBranch        string `json:"source_branch"`
+	TargetBranch        string `json:"target_branch"`
+	DetailedMergeStatus string `json:"detailed_merge_status"`
+	HasConflicts        bool   `json:"has_conflicts"`
+	HeadPipeline        *struct {
+		Status string `json:"status"`
+	} `json:"head_pipeline"`
+}
+
+// Find returns the open merge request whose source is branch
+func (g *GitLab) Find(branch string) (*PullRequest, error) {
+	if err := g.check(); err != nil {
+		return nil, err
+	}
+
+	output, err := g.run("glab", "mr", "list", "--source-branch", branch, "--output", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merge requests: %w", err)
+	}
+
+	var mrs []gitlabMR
+	if err := json.Unmarshal([]byte(output), &mrs); err != nil {
+		return nil, fmt.Errorf("failed to parse glab output: %w", err)
+	}
+
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+
+	// The list endpoint omits pipeline and merge details
+	output, err = g.run("glab", "mr", "view", strconv.Itoa(mrs[0].IID), "--output", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to view merge request: %w", err)
+	}
+
+	var mr gitlabMR
+	if err := json.Unmarshal([]byte(output), &mr); err != nil {
+		return nil, fmt.Errorf("failed to parse glab output: %w", err)
+	}
+
+	pr := mr.toPullRequest()
+	pr.Approvals = g.approvals(mr.IID)
+	if requested := g.changesRequested(mr.IID); requested > 0 {
+		pr.ChangesRequested = requested
+	} else if mr.DetailedMergeStatus == "requested_changes" {
+		pr.ChangesRequested = 1
+	}
+	return pr, nil
+}
+
+// approvals returns the number of approvals of a merge request
+func (g *GitLab) approvals(iid int) int {
+	output, err := g.run("glab", "api", fmt.Sprintf("projects/:id/merge_requests/%d/approvals", iid))
+	if err != nil {
+		return 0
+	}
+
+	var result struct {
+		ApprovedBy []json.RawMessage `json:"approved_by"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return 0
+	}
+	return len(result.ApprovedBy)
+}
+
+// changesRequested returns the number of reviewers who requested changes
+// on a merge request
+func (g *GitLab) changesRequested(iid int) int {
+	output, err := g.run("glab", "api", fmt.Sprintf("projects/:id/merge_requests/%d/reviewers", iid))
+	if err != nil {
+		return 0
+	}
+
+	var reviewers []struct {
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal([]byte(output), &reviewers); err != nil {
+		return 0
+	}
+
+	count := 0
+	for _, reviewer := range reviewers {
+		if reviewer.State == "requested_changes" {
+			count++
+		}
+	}
+	return count
+}
+
+// toPullRequest converts the glab JSON into a PullRequest
+func (m gitlabMR) toPullRequest() *PullRequest {
+	pr := &PullRequest{
+		Number:      m.IID,
+		URL:         m.WebURL,
+		Title:       m.Title,
+		Description: m.Description,
+		State:       m.State,
+		Draft:       m.Draft,
+		Head:        m.SourceBranch,
+		Base:        m.TargetBranch,
+	}
+
+	switch {
+	case m.HasConflicts:
+		pr.Mergeable = "conflicting"
+	case m.DetailedMergeStatus == "mergeable":
+		pr.Mergeable = "mergeable"
+	case m.DetailedMergeStatus != "":
+		pr.Mergeable = strings.ReplaceAll(m.DetailedMergeStatus, "_", " ")
+	default:
+		pr.Mergeable = "unknown"
+	}
+
+	if m.HeadPipeline != nil {
+		pr.Checks = append(pr.Checks, Check{Name: "pipeline", State: gitlabPipelineState(m.HeadPipeline.Status)})
+	}
+
+	return pr
+}
+
+// gitlabPipelineState maps a GitLab pipeline status
+func gitlabPipelineState(status string) string {
+	switch status {
+	case "success":
+		return CheckSuccess
+	case "failed", "canceled":
+		return CheckFailure
+	case "skipped", "manual":
+		return CheckSkipped
+	default:
+		return CheckPending
+	}
+}
+
+// Update edits the merge request and marks it ready if requested
+func (g *GitLab) Update(pr *PullRequest, opts UpdateOptions) error {
+	if err := g.check(); err != nil {
+		return err
+	}
+
+	args := []string{"mr", "update", strconv.Itoa(pr.Number)}
+	if opts.Title != "" {
+		args = append(args, "--title", opts.Title)
+	}
+	if opts.Description != "" {
+		args = append(args, "--description", opts.Description)
+	}
+	if len(opts.Reviewers) > 0 {
+		args = append(args, "--reviewer", strings.Join(opts.Reviewers, ","))
+	}
+	if len(opts.Labels) > 0 {
+		args = append(args, "--label", strings.Join(opts.Labels, ","))
+	}
+	if len(opts.Assignees) > 0 {
+		args = append(args, "--assignee", strings.Join(opts.Assignees, ","))
+	}
+	if opts.Milestone != "" {
+		args = append(args, "--milestone", opts.Milestone)
+	}
+	if opts.Ready && pr.Draft {
+		args = append(args, "--ready")
+	}
+
+	if _, err := g.run("glab", args...); err != nil {
+		return fmt.Errorf("failed to update merge request: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrCLIMissing matches the error of a provider whose command line tool is
+// not installed, so callers can fall back to the web interface
+var ErrCLIMissing = errors.New("provider CLI not installed")
+
+// cliMissingError explains how to install a missing command line tool
+type cliMissingError string
+
+// Error returns what is missing and how to install it
+func (e cliMissingError) Error() string {
+	return string(e)
+}
+
+// Is makes the error match ErrCLIMissing
+func (e cliMissingError) Is(target error) bool {
+	return target == ErrCLIMissing
+}
+
+// Runner executes an external command and returns its trimmed output
+type Runner func(name string, args ...string) (string, error)
+
+// ExecRunner returns a Runner that executes commands in the given directory
+func ExecRunner(dir string) Runner {
+	return ExecRunnerContext(context.Background(), dir)
+}
+
+// ExecRunnerContext returns a Runner that executes commands in the given
+// directory, stopping them when ctx is cancelled. Failures include what the
+// command printed on stderr.
+func ExecRunnerContext(ctx context.Context, dir string) Runner {
+	return func(name string, args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Dir = dir
+
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+
+		output, err := cmd.Output()
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", fmt.Errorf("%s command cancelled", name)
+			}
+			if message := strings.TrimSpace(stderr.String()); message != "" {
+				return "", fmt.Errorf("%s command failed: %w: %s", name, err, message)
+			}
+			return "", fmt.Errorf("%s command failed: %w", name, err)
+		}
+
+		return strings.TrimSpace(string(output)), nil
+	}
+}
+
+// CreateOptions holds everything needed to open a pull/merge request
+type CreateOptions struct {
+	Title       string
+	Description string
+	Base        string
+	Head        string
+	Draft       bool
+	Reviewers   []string
+	Labels      []string
+	Assignees   []string
+	Milestone   string
+}
+
+// UpdateOptions holds the changes applied to an existing pull request.
+// Reviewers, labels and assignees are added to the existing ones.
+type UpdateOptions struct {
+	Title       string
+	Description string
+	Ready       bool
+	Reviewers   []string
+	Labels      []string
+	Assignees   []string
+	Milestone   string
+}
+
+// Check states reported by providers
+const (
+	CheckSuccess = "success"
+	CheckFailure = "failure"
+	CheckPending = "pending"
+	CheckSkipped = "skipped"
+)
+
+// Check is a CI check or status reported on a pull request
+type Check struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// PullRequest describes an existing pull/merge request
+type PullRequest struct {
+	Number           int     `json:"number"`
+	URL              string  `json:"url"`
+	Title            string  `json:"title"`
+	Description      string  `json:"-"`
+	State            string  `json:"state"`
+	Draft            bool    `json:"draft"`
+	Head             string  `json:"head"`
+	Base             string  `json:"base"`
+	Approvals        int     `json:"approvals"`
+	ChangesRequested int     `json:"changes_requested"`
+	Checks           []Check `json:"checks"`
+	Mergeable        string  `json:"mergeable"`
+}
+
+// CheckSummary returns a short summary of the CI check results
+func (pr *PullRequest) CheckSummary() string {
+	if len(pr.Checks) == 0 {
+		return "none"
+	}
+
+	counts := make(map[string]int)
+	for _, check := range pr.Checks {
+		counts[check.State]++
+	}
+
+	var parts []string
+	for _, state := range []string{CheckSuccess, CheckFailure, CheckPending, CheckSkipped} {
+		if counts[state] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[state], state))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ReviewSummary returns a short summary of the review state
+func (pr *PullRequest) ReviewSummary() string {
+	return fmt.Sprintf("%d approved, %d changes requested", pr.Approvals, pr.ChangesRequested)
+}
+
+// Provider is a Git hosting platform backend able to create pull requests
+type Provider interface {
+	// Name returns the platform name (github, gitlab, ...)
+	Name() string
+
+	// Create opens a pull request and returns its URL
+	Create(opts CreateOptions) (string, error)
+
+	// Find returns the open pull request for a branch, or nil when there is none
+	Find(branch string) (*PullRequest, error)
+
+	// Update edits an existing pull request
+	Update(pr *PullRequest, opts UpdateOptions) error
+}
+
+// New returns the provider backend for the given platform, or nil when
+// the platform has no CLI integration
+func New(platform string, run Runner) Provider {
+	switch platform {
+	case "github":
+		return &GitHub{run: run}
+	case "gitlab":
+		return &GitLab{run: run}
+	default:
+		return nil
+	}
+}
+
+// lastLine returns the last non-empty line of a command output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
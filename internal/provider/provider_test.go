@@ -0,0 +1,207 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeRunner records invocations and returns canned outputs
+type fakeRunner struct {
+	calls   [][]string
+	outputs map[string]string
+	fail    map[string]bool
+}
+
+func (f *fakeRunner) run(name string, args ...string) (string, error) {
+	call := append([]string{name}, args...)
+	f.calls = append(f.calls, call)
+
+	key := strings.Join(call[:min(3, len(call))], " ")
+	if f.fail[key] {
+		return "", fmt.Errorf("%s failed", key)
+	}
+	return f.outputs[key], nil
+}
+
+func testOptions() CreateOptions {
+	return CreateOptions{
+		Title:       "Fix login",
+		Description: "body",
+		Base:        "main",
+		Head:        "hotfix-login",
+		Draft:       true,
+		Reviewers:   []string{"alice", "bob"},
+		Labels:      []string{"urgent"},
+		Assignees:   []string{"@me"},
+		Milestone:   "v1.2",
+	}
+}
+
+func TestGitHubCreate(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"gh pr create": "Creating pull request\nhttps://github.com/o/r/pull/7",
+	}}
+
+	url, err := New("github", runner.run).Create(testOptions())
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if url != "https://github.com/o/r/pull/7" {
+		t.Errorf("Expected PR URL, got %q", url)
+	}
+
+	want := []string{"gh", "pr", "create", "--title", "Fix login", "--body", "body", "--base", "main", "--head", "hotfix-login",
+		"--draft", "--reviewer", "alice,bob", "--label", "urgent", "--assignee", "@me", "--milestone", "v1.2"}
+	last := runner.calls[len(runner.calls)-1]
+	if !reflect.DeepEqual(last, want) {
+		t.Errorf("Unexpected gh invocation:\n got %v\nwant %v", last, want)
+	}
+}
+
+func TestGitLabCreate(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"glab mr create": "https://gitlab.com/o/r/-/merge_requests/3",
+	}}
+
+	opts := testOptions()
+	opts.Draft = false
+	opts.Milestone = ""
+
+	if _, err := New("gitlab", runner.run).Create(opts); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	want := []string{"glab", "mr", "create", "--title", "Fix login", "--description", "body", "--source-branch", "hotfix-login", "--target-branch", "main",
+		"--reviewer", "alice,bob", "--label", "urgent", "--assignee", "@me", "--yes"}
+	last := runner.calls[len(runner.calls)-1]
+	if !reflect.DeepEqual(last, want) {
+		t.Errorf("Unexpected glab invocation:\n got %v\nwant %v", last, want)
+	}
+}
+
+func TestCreateRequiresAuthentication(t *testing.T) {
+	runner := &fakeRunner{fail: map[string]bool{"gh auth status": true}}
+
+	_, err := New("github", runner.run).Create(testOptions())
+	if err == nil || !strings.Contains(err.Error(), "not authenticated") {
+		t.Errorf("Expected authentication error, got %v", err)
+	}
+
+	for _, call := range runner.calls {
+		if len(call) > 2 && call[1] == "pr" {
+			t.Errorf("PR must not be created when gh is not authenticated")
+		}
+	}
+}
+
+func TestNewUnsupportedPlatform(t *testing.T) {
+	for _, platform := range []string{"bitbucket", "generic", "unknown"} {
+		if New(platform, nil) != nil {
+			t.Errorf("Expected no CLI provider for %s", platform)
+		}
+	}
+}
+
+func TestGitHubFind(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"gh pr list": `[{"number":12,"url":"https://github.com/o/r/pull/12","title":"Add auth","state":"OPEN","isDraft":true,
+			"headRefName":"feature-auth","baseRefName":"main","mergeable":"MERGEABLE",
+			"latestReviews":[{"state":"APPROVED"},{"state":"CHANGES_REQUESTED"},{"state":"APPROVED"}],
+			"statusCheckRollup":[
+				{"__typename":"CheckRun","name":"build","status":"COMPLETED","conclusion":"SUCCESS"},
+				{"__typename":"CheckRun","name":"lint","status":"IN_PROGRESS","conclusion":""},
+				{"__typename":"StatusContext","context":"ci/deploy","state":"FAILURE"}]}]`,
+	}}
+
+	pr, err := New("github", runner.run).Find("feature-auth")
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if pr == nil {
+		t.Fatal("Expected a pull request")
+	}
+
+	if pr.Number != 12 || !pr.Draft || pr.Mergeable != "mergeable" || pr.State != "open" {
+		t.Errorf("Unexpected pull request: %+v", pr)
+	}
+	if pr.Approvals != 2 || pr.ChangesRequested != 1 {
+		t.Errorf("Expected 2 approvals and 1 change request, got %s", pr.ReviewSummary())
+	}
+	if got := pr.CheckSummary(); got != "1 success, 1 failure, 1 pending" {
+		t.Errorf("Unexpected check summary: %s", got)
+	}
+}
+
+func TestGitHubFindNone(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{"gh pr list": "[]"}}
+
+	pr, err := New("github", runner.run).Find("feature-auth")
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if pr != nil {
+		t.Errorf("Expected no pull request, got %+v", pr)
+	}
+}
+
+func TestGitLabFind(t *testing.T) {
+	runner := &fakeRunner{outputs: map[string]string{
+		"glab mr list": `[{"iid":4}]`,
+		"glab mr view": `{"iid":4,"web_url":"https://gitlab.com/o/r/-/merge_requests/4","title":"Fix","state":"opened",
+			"draft":false,"source_branch":"bugfix-x","target_branch":"main","detailed_merge_status":"mergeable",
+			"head_pipeline":{"status":"running"}}`,
+		"glab api projects/:id/merge_requests/4/approvals": `{"approved_by":[{"user":{"username":"alice"}}]}`,
+		"glab api projects/:id/merge_requests/4/reviewers": `[{"user":{"username":"alice"},"state":"approved"},
+			{"user":{"username":"bob"},"state":"requested_changes"}]`,
+	}}
+
+	pr, err := New("gitlab", runner.run).Find("bugfix-x")
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	if pr.Number != 4 || pr.Mergeable != "mergeable" || pr.Approvals != 1 || pr.ChangesRequested != 1 {
+		t.Errorf("Unexpected merge request: %+v", pr)
+	}
+	if got := pr.CheckSummary(); got != "1 pending" {
+		t.Errorf("Unexpected check summary: %s", got)
+	}
+}
+
+func TestGitHubUpdate(t *testing.T) {
+	runner := &fakeRunner{}
+	pr := &PullRequest{Number: 12, Draft: true}
+
+	err := New("github", runner.run).Update(pr, UpdateOptions{Title: "Add auth", Description: "body", Ready: true, Labels: []string{"api"}})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	calls := runner.calls[len(runner.calls)-2:]
+	want := [][]string{
+		{"gh", "pr", "edit", "12", "--title", "Add auth", "--body", "body", "--add-label", "api"},
+		{"gh", "pr", "ready", "12"},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("Unexpected gh invocations:\n got %v\nwant %v", calls, want)
+	}
+}
+
+func TestGitLabUpdate(t *testing.T) {
+	runner := &fakeRunner{}
+	pr := &PullRequest{Number: 4, Draft: false}
+
+	err := New("gitlab", runner.run).Update(pr, UpdateOptions{Title: "Fix", Description: "body", Ready: true})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// Ready is ignored for merge requests that are not drafts
+	want := []string{"glab", "mr", "update", "4", "--title", "Fix", "--description", "body"}
+	last := runner.calls[len(runner.calls)-1]
+	if !reflect.DeepEqual(last, want) {
+		t.Errorf("Unexpected glab invocation:\n got %v\nwant %v", last, want)
+	}
+}
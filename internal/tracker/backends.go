@@ -0,0 +1,283 @@
+package tracker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/errs"
+)
+
+// Jira fetches issues from the Jira REST API
+type Jira struct {
+	cfg Config
+}
+
+// Name returns the tracker name
+func (j *Jira) Name() string {
+	return "jira"
+}
+
+// Fetch returns a Jira issue such as PROJ-123
+func (j *Jira) Fetch(key string) (*Issue, error) {
+	key = strings.ToUpper(strings.TrimSpace(key))
+	endpoint := fmt.Sprintf("%s/rest/api/2/issue/%s?fields=summary,issuetype,status,labels", j.cfg.BaseURL, url.PathEscape(key))
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Jira Cloud uses email + API token, Jira Data Center personal access tokens
+	if j.cfg.User != "" {
+		req.SetBasicAuth(j.cfg.User, j.cfg.Token)
+	} else if j.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+j.cfg.Token)
+	}
+
+	var result struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary   string `json:"summary"`
+			IssueType struct {
+				Name string `json:"name"`
+			} `json:"issuetype"`
+			Status struct {
+				Name string `json:"name"`
+			} `json:"status"`
+			Labels []string `json:"labels"`
+		} `json:"fields"`
+	}
+	if err := do(j.cfg.Client, req, key, &result); err != nil {
+		return nil, err
+	}
+
+	return &Issue{
+		Key:    result.Key,
+		Title:  result.Fields.Summary,
+		Type:   result.Fields.IssueType.Name,
+		Status: result.Fields.Status.Name,
+		URL:    fmt.Sprintf("%s/browse/%s", j.cfg.BaseURL, result.Key),
+		Labels: result.Fields.Labels,
+	}, nil
+}
+
+// GitHub fetches issues from the GitHub REST API
+type GitHub struct {
+	cfg Config
+}
+
+// Name returns the tracker name
+func (g *GitHub) Name() string {
+	return "github"
+}
+
+// Fetch returns a GitHub issue given as 123, #123 or owner/repo#123
+func (g *GitHub) Fetch(key string) (*Issue, error) {
+	project, number, err := splitIssueKey(key, g.cfg.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/repos/%s/issues/%d", g.cfg.BaseURL, project, number)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if g.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.cfg.Token)
+	}
+
+	var result struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		State   string `json:"state"`
+		HTMLURL string `json:"html_url"`
+		Labels  []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		Type *struct {
+			Name string `json:"name"`
+		} `json:"type"`
+	}
+	if err := do(g.cfg.Client, req, key, &result); err != nil {
+		return nil, err
+	}
+
+	issue := &Issue{
+		Key:    strconv.Itoa(result.Number),
+		Title:  result.Title,
+		Type:   "issue",
+		Status: result.State,
+		URL:    result.HTMLURL,
+	}
+	if result.Type != nil && result.Type.Name != "" {
+		issue.Type = result.Type.Name
+	}
+	for _, label := range result.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	return issue, nil
+}
+
+// GitLab fetches issues from the GitLab REST API
+type GitLab struct {
+	cfg Config
+}
+
+// Name returns the tracker name
+func (g *GitLab) Name() string {
+	return "gitlab"
+}
+
+// Fetch returns a GitLab issue given as 123, #123 or group/project#123
+func (g *GitLab) Fetch(key string) (*Issue, error) {
+	project, number, err := splitIssueKey(key, g.cfg.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/issues/%d", g.cfg.BaseURL, url.PathEscape(project), number)
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if g.cfg.Token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.cfg.Token)
+	}
+
+	var result struct {
+		IID       int      `json:"iid"`
+		Title     string   `json:"title"`
+		State     string   `json:"state"`
+		WebURL    string   `json:"web_url"`
+		IssueType string   `json:"issue_type"`
+		Labels    []string `json:"labels"`
+	}
+	if err := do(g.cfg.Client, req, key, &result); err != nil {
+		return nil, err
+	}
+
+	return &Issue{
+		Key:    strconv.Itoa(result.IID),
+		Title:  result.Title,
+		Type:   result.IssueType,
+		Status: result.State,
+		URL:    result.WebURL,
+		Labels: result.Labels,
+	}, nil
+}
+
+// Linear fetches issues from the Linear GraphQL API
+type Linear struct {
+	cfg Config
+}
+
+// Name returns the tracker name
+func (l *Linear) Name() string {
+	return "linear"
+}
+
+// linearIssueQuery fetches an issue by its identifier (ENG-123)
+const linearIssueQuery = `query Issue($id: String!) {
+  issue(id: $id) {
+    identifier
+    title
+    url
+    state { name }
+    labels { nodes { name } }
+  }
+}`
+
+// Fetch returns a Linear issue such as ENG-123
+func (l *Linear) Fetch(key string) (*Issue, error) {
+	key = strings.ToUpper(strings.TrimSpace(key))
+
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     linearIssueQuery,
+		"variables": map[string]string{"id": key},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, l.cfg.BaseURL+"/graphql", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.cfg.Token != "" {
+		req.Header.Set("Authorization", l.cfg.Token)
+	}
+
+	var result struct {
+		Data struct {
+			Issue *struct {
+				Identifier string `json:"identifier"`
+				Title      string `json:"title"`
+				URL        string `json:"url"`
+				State      struct {
+					Name string `json:"name"`
+				} `json:"state"`
+				Labels struct {
+					Nodes []struct {
+						Name string `json:"name"`
+					} `json:"nodes"`
+				} `json:"labels"`
+			} `json:"issue"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := do(l.cfg.Client, req, key, &result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Errors) > 0 || result.Data.Issue == nil {
+		if len(result.Errors) > 0 && !strings.Contains(strings.ToLower(result.Errors[0].Message), "not found") {
+			return nil, fmt.Errorf("linear request failed: %s", result.Errors[0].Message)
+		}
+		return nil, errs.New(errs.NotFound, "issue %s not found", key)
+	}
+
+	found := result.Data.Issue
+	issue := &Issue{
+		Key:    found.Identifier,
+		Title:  found.Title,
+		Type:   "issue",
+		Status: found.State.Name,
+		URL:    found.URL,
+	}
+	for _, label := range found.Labels.Nodes {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	return issue, nil
+}
+
+// splitIssueKey splits 123, #123 or owner/repo#123 into project and number
+func splitIssueKey(key, defaultProject string) (string, int, error) {
+	project := defaultProject
+	number := strings.TrimSpace(key)
+
+	if idx := strings.LastIndex(number, "#"); idx >= 0 {
+		if idx > 0 {
+			project = number[:idx]
+		}
+		number = number[idx+1:]
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil || n <= 0 {
+		return "", 0, fmt.Errorf("invalid issue number '%s'", key)
+	}
+	if project == "" {
+		return "", 0, fmt.Errorf("no project configured for issue %s (git config at.tracker.project owner/repo)", key)
+	}
+	return project, n, nil
+}
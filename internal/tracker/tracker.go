@@ -0,0 +1,152 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/potsed/gitAT/internal/errs"
+)
+
+// Issue is a ticket fetched from an issue tracker
+type Issue struct {
+	Key    string
+	Title  string
+	Type   string
+	Status string
+	URL    string
+	Labels []string
+}
+
+// Closed reports whether the issue is in a done or closed state
+func (i *Issue) Closed() bool {
+	switch strings.ToLower(i.Status) {
+	case "done", "closed", "resolved", "completed", "canceled", "cancelled":
+		return true
+	default:
+		return false
+	}
+}
+
+// workTypeKeywords maps issue types and labels to gitAT work types, in
+// priority order
+var workTypeKeywords = []struct {
+	keyword  string
+	workType string
+}{
+	{"hotfix", "hotfix"},
+	{"incident", "hotfix"},
+	{"bug", "bugfix"},
+	{"defect", "bugfix"},
+	{"docs", "docs"},
+	{"documentation", "docs"},
+	{"refactor", "refactor"},
+	{"tech debt", "refactor"},
+	{"performance", "perf"},
+	{"test", "test"},
+	{"chore", "chore"},
+	{"task", "chore"},
+	{"maintenance", "chore"},
+	{"feature", "feature"},
+	{"story", "feature"},
+	{"enhancement", "feature"},
+	{"epic", "feature"},
+}
+
+// WorkType derives the gitAT work type from the issue type and labels,
+// defaulting to feature
+func (i *Issue) WorkType() string {
+	candidates := append([]string{i.Type}, i.Labels...)
+	for _, kw := range workTypeKeywords {
+		for _, candidate := range candidates {
+			if strings.Contains(strings.ToLower(candidate), kw.keyword) {
+				return kw.workType
+			}
+		}
+	}
+	return "feature"
+}
+
+// Config holds the settings of a tracker backend
+type Config struct {
+	Kind    string // jira, github, gitlab or linear
+	BaseURL string // API base URL, defaults to the hosted service
+	Token   string
+	User    string // Jira account email, enables basic authentication
+	Project string // owner/repo (GitHub) or group/project (GitLab)
+	Client  *http.Client
+}
+
+// Tracker fetches issues from an issue tracker
+type Tracker interface {
+	// Name returns the tracker name (jira, github, ...)
+	Name() string
+
+	// Fetch returns the issue with the given key
+	Fetch(key string) (*Issue, error)
+}
+
+// Kinds lists the supported tracker backends
+var Kinds = []string{"jira", "github", "gitlab", "linear"}
+
+// New returns the tracker backend for cfg
+func New(cfg Config) (Tracker, error) {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 15 * time.Second}
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	switch cfg.Kind {
+	case "jira":
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("jira tracker requires a base URL (git config at.tracker.url https://your-org.atlassian.net)")
+		}
+		return &Jira{cfg}, nil
+	case "github":
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = "https://api.github.com"
+		}
+		return &GitHub{cfg}, nil
+	case "gitlab":
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = "https://gitlab.com"
+		}
+		return &GitLab{cfg}, nil
+	case "linear":
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = "https://api.linear.app"
+		}
+		return &Linear{cfg}, nil
+	default:
+		return nil, fmt.Errorf("unsupported tracker '%s' (supported: %s)", cfg.Kind, strings.Join(Kinds, ", "))
+	}
+}
+
+// do sends req and decodes the JSON response into out
+func do(client *http.Client, req *http.Request, key string, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach tracker: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return errs.New(errs.ProviderAuth, "tracker authentication failed (%s), check your token", resp.Status)
+	case resp.StatusCode == http.StatusNotFound:
+		return errs.New(errs.NotFound, "issue %s not found", key)
+	case resp.StatusCode >= 300:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("tracker request failed (%s): %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse tracker response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package tracker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stubServer serves body for path and records the last request
+func stubServer(t *testing.T, path, body string, last **http.Request) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*last = r
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJiraFetch(t *testing.T) {
+	var req *http.Request
+	server := stubServer(t, "/rest/api/2/issue/PROJ-123", `{"key":"PROJ-123","fields":{
+		"summary":"Login fails with SSO","issuetype":{"name":"Bug"},"status":{"name":"In Progress"},"labels":["auth"]}}`, &req)
+
+	tr, err := New(Config{Kind: "jira", BaseURL: server.URL + "/", User: "me@example.com", Token: "secret"})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	issue, err := tr.Fetch("proj-123")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	want := &Issue{Key: "PROJ-123", Title: "Login fails with SSO", Type: "Bug", Status: "In Progress",
+		URL: server.URL + "/browse/PROJ-123", Labels: []string{"auth"}}
+	if !reflect.DeepEqual(issue, want) {
+		t.Errorf("Unexpected issue:\n got %+v\nwant %+v", issue, want)
+	}
+	if user, pass, ok := req.BasicAuth(); !ok || user != "me@example.com" || pass != "secret" {
+		t.Errorf("Expected basic auth, got %q", req.Header.Get("Authorization"))
+	}
+	if issue.WorkType() != "bugfix" {
+		t.Errorf("Expected bugfix, got %s", issue.WorkType())
+	}
+}
+
+func TestJiraRequiresURL(t *testing.T) {
+	if _, err := New(Config{Kind: "jira"}); err == nil {
+		t.Error("Expected an error without a base URL")
+	}
+}
+
+func TestGitHubFetch(t *testing.T) {
+	var req *http.Request
+	server := stubServer(t, "/repos/o/r/issues/42", `{"number":42,"title":"Add dark mode","state":"open",
+		"html_url":"https://github.com/o/r/issues/42","labels":[{"name":"enhancement"}]}`, &req)
+
+	tr, _ := New(Config{Kind: "github", BaseURL: server.URL, Project: "o/r", Token: "tok"})
+	issue, err := tr.Fetch("#42")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	if issue.Key != "42" || issue.Title != "Add dark mode" || issue.Status != "open" {
+		t.Errorf("Unexpected issue: %+v", issue)
+	}
+	if issue.WorkType() != "feature" {
+		t.Errorf("Expected feature, got %s", issue.WorkType())
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer tok" {
+		t.Errorf("Expected bearer token, got %q", got)
+	}
+}
+
+func TestGitLabFetch(t *testing.T) {
+	var req *http.Request
+	server := stubServer(t, "/api/v4/projects/group/app/issues/7", `{"iid":7,"title":"Outage on checkout","state":"closed",
+		"web_url":"https://gitlab.com/group/app/-/issues/7","issue_type":"incident","labels":[]}`, &req)
+
+	tr, _ := New(Config{Kind: "gitlab", BaseURL: server.URL, Token: "tok"})
+	issue, err := tr.Fetch("group/app#7")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	if issue.Key != "7" || issue.Type != "incident" {
+		t.Errorf("Unexpected issue: %+v", issue)
+	}
+	if issue.WorkType() != "hotfix" {
+		t.Errorf("Expected hotfix, got %s", issue.WorkType())
+	}
+	if !issue.Closed() {
+		t.Error("Expected closed issue")
+	}
+	if got := req.Header.Get("PRIVATE-TOKEN"); got != "tok" {
+		t.Errorf("Expected private token, got %q", got)
+	}
+	if !strings.Contains(req.URL.EscapedPath(), "group%2Fapp") {
+		t.Errorf("Expected URL-encoded project, got %s", req.URL.EscapedPath())
+	}
+}
+
+func TestLinearFetch(t *testing.T) {
+	var req *http.Request
+	server := stubServer(t, "/graphql", `{"data":{"issue":{"identifier":"ENG-9","title":"Update onboarding docs",
+		"url":"https://linear.app/t/issue/ENG-9","state":{"name":"Todo"},"labels":{"nodes":[{"name":"Documentation"}]}}}}`, &req)
+
+	tr, _ := New(Config{Kind: "linear", BaseURL: server.URL, Token: "lin_key"})
+	issue, err := tr.Fetch("eng-9")
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	if issue.Key != "ENG-9" || issue.WorkType() != "docs" {
+		t.Errorf("Unexpected issue: %+v (%s)", issue, issue.WorkType())
+	}
+	if req.Method != http.MethodPost || req.Header.Get("Authorization") != "lin_key" {
+		t.Errorf("Unexpected request: %s %q", req.Method, req.Header.Get("Authorization"))
+	}
+}
+
+func TestLinearNotFound(t *testing.T) {
+	var req *http.Request
+	server := stubServer(t, "/graphql", `{"data":{"issue":null},"errors":[{"message":"Entity not found"}]}`, &req)
+
+	tr, _ := New(Config{Kind: "linear", BaseURL: server.URL})
+	if _, err := tr.Fetch("ENG-404"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/1") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	tr, _ := New(Config{Kind: "github", BaseURL: server.URL, Project: "o/r"})
+	if _, err := tr.Fetch("1"); err == nil || !strings.Contains(err.Error(), "authentication") {
+		t.Errorf("Expected authentication error, got %v", err)
+	}
+	if _, err := tr.Fetch("2"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+	if _, err := tr.Fetch("abc"); err == nil {
+		t.Error("Expected invalid issue number error")
+	}
+}
+
+func TestNewUnsupported(t *testing.T) {
+	if _, err := New(Config{Kind: "trello"}); err == nil {
+		t.Error("Expected an error for an unsupported tracker")
+	}
+}
+
+func TestWorkType(t *testing.T) {
+	tests := []struct {
+		issue Issue
+		want  string
+	}{
+		{Issue{Type: "Story"}, "feature"},
+		{Issue{Type: "Task"}, "chore"},
+		{Issue{Type: "Defect"}, "bugfix"},
+		{Issue{Type: "issue", Labels: []string{"bug"}}, "bugfix"},
+		{Issue{Type: "issue"}, "feature"},
+	}
+	for _, tt := range tests {
+		if got := tt.issue.WorkType(); got != tt.want {
+			t.Errorf("WorkType(%+v) = %s, want %s", tt.issue, got, tt.want)
+		}
+	}
+}
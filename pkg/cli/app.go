@@ -1,152 +1,359 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/potsed/gitAT/internal/commands"
 	"github.com/potsed/gitAT/internal/config"
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/internal/git"
+	"github.com/potsed/gitAT/pkg/output"
+	"github.com/potsed/gitAT/pkg/tui"
+)
+
+// Build information, set by cmd/gitat from the linker flags
+var (
+	Version    = "v1.1.0"
+	CommitHash = ""
+	BuildDate  = ""
 )
 
 // App represents the CLI application
 type App struct {
-	config *config.Config
-	cmds   *commands.Manager
+	config   *config.Config
+	cmds     *commands.Manager
+	registry []*commands.Command
 }
 
 // NewApp creates a new CLI application
 func NewApp(cfg *config.Config) *App {
-	return &App{
+	a := &App{
 		config: cfg,
 		cmds:   commands.NewManager(cfg),
 	}
+	a.registry = append(append([]*commands.Command(nil), commands.Commands()...), a.builtins()...)
+	return a
+}
+
+// Execute runs the CLI application and returns the process exit code. With
+// --json errors are reported as JSON on stdout instead of text on stderr.
+func (a *App) Execute(args []string) int {
+	err := a.Run(args)
+	if err == nil {
+		return 0
+	}
+
+	// A plugin has reported its own failure
+	var exit *errs.Exit
+	if errors.As(err, &exit) {
+		return exit.Code
+	}
+
+	switch {
+	case output.JSONEnabled():
+		fmt.Fprintln(os.Stdout, string(errs.JSON(err)))
+	case errs.Is(err, errs.NothingToDo):
+		fmt.Fprintln(os.Stderr, errs.Message(err))
+	default:
+		fmt.Fprintf(os.Stderr, "error: %s\n", errs.Message(err))
+	}
+	return errs.ExitCode(err)
 }
 
 // Run executes the CLI application with the given arguments
 func (a *App) Run(args []string) error {
+	args = a.parseGlobalFlags(args)
+
 	if len(args) == 0 {
 		return a.showUsage()
 	}
-
-	command := args[0]
-	commandArgs := args[1:]
-
-	switch command {
-	case "work":
-		return a.cmds.Work(commandArgs)
-	case "hotfix":
-		return a.cmds.Hotfix(commandArgs)
-	case "save":
-		return a.cmds.Save(commandArgs)
-	case "squash":
-		return a.cmds.Squash(commandArgs)
-	case "pr":
-		return a.cmds.PullRequest(commandArgs)
-	case "branch":
-		return a.cmds.Branch(commandArgs)
-	case "sweep":
-		return a.cmds.Sweep(commandArgs)
-	case "info":
-		return a.cmds.Info(commandArgs)
-	case "hash":
-		return a.cmds.Hash(commandArgs)
-	case "product":
-		return a.cmds.Product(commandArgs)
-	case "feature":
-		return a.cmds.Feature(commandArgs)
-	case "issue":
-		return a.cmds.Issue(commandArgs)
-	case "version":
-		return a.cmds.Version(commandArgs)
-	case "release":
-		return a.cmds.Release(commandArgs)
-	case "master", "root":
-		return a.cmds.Master(commandArgs)
-	case "wip":
-		return a.cmds.WIP(commandArgs)
-	case "changes":
-		return a.cmds.Changes(commandArgs)
-	case "logs":
-		return a.cmds.Logs(commandArgs)
-	case "_label":
-		return a.cmds.Label(commandArgs)
-	case "_id":
-		return a.cmds.ID(commandArgs)
-	case "_path":
-		return a.cmds.Path(commandArgs)
-	case "_trunk":
-		return a.cmds.Trunk(commandArgs)
-	case "ignore":
-		return a.cmds.Ignore(commandArgs)
-	case "initlocal":
-		return a.cmds.InitLocal(commandArgs)
-	case "initremote":
-		return a.cmds.InitRemote(commandArgs)
-	case "_security":
-		return a.cmds.Security(commandArgs)
-	case "_go":
-		return a.cmds.Go(commandArgs)
-	case "help", "-h", "--help":
+	switch args[0] {
+	case "-h", "--help":
 		return a.showUsage()
 	case "-v", "--version":
 		return a.showVersion()
-	default:
-		return fmt.Errorf("unknown command: %s", command)
 	}
+
+	// Ctrl-C cancels the running git commands so they can clean up; a
+	// second Ctrl-C quits immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	a.cmds.SetContext(ctx)
+
+	if args[0] == "--tui" {
+		if len(args) > 1 {
+			return errs.New(errs.Usage, "--tui takes no command (see 'git @ --help')")
+		}
+		return a.runTUI()
+	}
+
+	ran, err := a.run(args, nil)
+	if plan := a.cmds.Plan(); plan != nil && ran {
+		a.showPlan(plan)
+	}
+	return err
 }
 
-// showUsage displays the usage information
-func (a *App) showUsage() error {
-	fmt.Fprintf(os.Stdout, `GitAT - Git Workflow Management Tool
-
-Usage: git @ <command> [options]
-
-Commands:
-  work <type> <description>    Create work branches following Conventional Commits
-  hotfix <description>         Create hotfix branches for urgent fixes
-  save "message"               Securely save changes with validation
-  squash [branch]              Squash commits with auto-detection of parent branch
-  pr [options]                 Create Pull Requests with auto-description generation
-  branch                       Manage working branch configuration
-  sweep                        Clean up local branches (merged + remote-deleted)
-  info                         Comprehensive status report from all commands
-  hash                         Detailed branch status and commit relationships
-  product [<name>]             Product name configuration
-  feature [<name>]             Feature name configuration
-  issue [<id>]                 Issue/task identifier configuration
-  version                      Semantic versioning management
-  release                      Create releases with proper tagging
-  master, root                 Switch to trunk branches
-  wip                          Work in progress management
-  changes                      View uncommitted changes
-  logs                         View commit history
-  _label                       Generate commit labels
-  _id                          Generate unique project identifiers
-  _path                        Get repository path
-  _trunk                       Manage trunk branch configuration
-  ignore                       Add patterns to .gitignore
-  initlocal                    Initialize local repository with branch structure
-  initremote                   Initialize remote repository with basic structure
-  _security                    Security utilities and status
-  _go                          Initialize GitAT for current repository
-
-Options:
-  -h, --help                   Show this help message
-  -v, --version                Show version information
-
-Examples:
-  git @ work feature add-user-authentication
-  git @ save "Add user authentication system"
-  git @ pr
-  git @ sweep
-
-For more information, visit: https://github.com/potsed/gitAT
-`)
+// run runs a command line after "git @". Built-in commands come first, then
+// at.alias.<name> aliases, then git-@-<name> plugins, which get their own
+// --help. aliases are the aliases being expanded. It reports whether a
+// command ran, rather than failing before or showing a help.
+func (a *App) run(args []string, aliases []string) (bool, error) {
+	cmd := commands.FindCommand(a.registry, args[0])
+	if cmd != nil {
+		if help := helpRequested(cmd, args[1:]); help != nil {
+			return false, a.showHelp(help)
+		}
+		if !cmd.NoRepo && a.config.RepoPath == "" {
+			return false, errs.New(errs.NotARepo, "Not in a git repository")
+		}
+		return true, cmd.Run(a.cmds, args[1:])
+	}
+
+	if expansion, ok := a.cmds.FindAlias(args[0]); ok {
+		return a.runAlias(args[0], expansion, args[1:], aliases)
+	}
+	if plugin, ok := a.cmds.FindPlugin(args[0]); ok {
+		return true, a.cmds.RunPlugin(plugin, args[1:])
+	}
+	return false, errs.New(errs.Usage, "unknown command: %s (see 'git @ help')", args[0])
+}
+
+// runAlias runs the command lines of an alias in order, stopping at the
+// first failure. An alias can run other aliases, but not itself.
+func (a *App) runAlias(name, expansion string, args []string, aliases []string) (bool, error) {
+	for _, alias := range aliases {
+		if alias == name {
+			return false, errs.New(errs.Usage, "Alias loop: %s -> %s (see 'git @ alias')", strings.Join(aliases, " -> "), name)
+		}
+	}
+	if len(aliases) == 0 && len(args) == 1 && (args[0] == "-h" || args[0] == "--help") {
+		fmt.Fprintf(os.Stdout, "'%s' is an alias for: %s\n", name, expansion)
+		return false, nil
+	}
+
+	lines, err := commands.ExpandAlias(expansion, args)
+	if err != nil {
+		return false, errs.Wrap(errs.Usage, err, "Invalid alias '%s'", name)
+	}
+	aliases = append(aliases[:len(aliases):len(aliases)], name)
+
+	ran := false
+	for _, line := range lines {
+		if a.config.Verbose {
+			fmt.Fprintf(os.Stderr, "+ git @ %s (alias %s)\n", git.FormatArgs(line), name)
+		}
+		lineRan, err := a.run(line, aliases)
+		ran = ran || lineRan
+		if err != nil {
+			return ran, err
+		}
+	}
+	return ran, nil
+}
+
+// runTUI opens the terminal UI. Its commands run through run, like on the
+// command line, and honor --dry-run and --verbose.
+func (a *App) runTUI() error {
+	if a.config.RepoPath == "" {
+		return errs.New(errs.NotARepo, "Not in a git repository")
+	}
+
+	var flags []string
+	if a.config.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if a.config.Verbose {
+		flags = append(flags, "--verbose")
+	}
+	executable, _ := os.Executable()
+
+	// Traces would draw over the TUI; they go to the output pane instead
+	a.cmds.SetVerbose(nil)
+
+	return tui.Run(tui.Options{
+		Manager:    a.cmds,
+		Commands:   a.registry,
+		Executable: executable,
+		Flags:      flags,
+		Verbose:    a.config.Verbose,
+		Run: func(args []string) error {
+			// Each command gets its own plan
+			if a.config.DryRun {
+				a.cmds.SetDryRun(git.NewPlanner())
+			}
+			ran, err := a.run(args, nil)
+			if plan := a.cmds.Plan(); plan != nil && ran {
+				a.showPlan(plan)
+			}
+			return err
+		},
+	})
+}
+
+// helpRequested returns the command or subcommand whose help args asks
+// for with -h or --help, or nil
+func helpRequested(cmd *commands.Command, args []string) *commands.Command {
+	if len(args) > 0 {
+		if sub := cmd.Subcommand(args[0]); sub != nil {
+			cmd, args = sub, args[1:]
+		}
+	}
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return nil
+		case "-h", "--help":
+			return cmd
+		}
+	}
 	return nil
 }
 
+// parseGlobalFlags applies the flags accepted by every command and returns
+// the remaining arguments. Globals are taken before the command name and
+// among the options of a built-in command, up to its first positional
+// argument or "--". Aliases and plugins get their arguments untouched.
+func (a *App) parseGlobalFlags(args []string) []string {
+	jsonOutput := false
+	a.config.Verbose, a.config.DryRun = false, false
+	var rest []string
+	var cmd *commands.Command
+	named := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--json":
+			jsonOutput = true
+			continue
+		case arg == "--verbose":
+			a.config.Verbose = true
+			continue
+		case arg == "--dry-run":
+			a.config.DryRun = true
+			continue
+		case arg == "--":
+		case !strings.HasPrefix(arg, "-") || arg == "-":
+			switch {
+			case !named:
+				named, cmd = true, commands.FindCommand(a.registry, arg)
+				if cmd != nil {
+					rest = append(rest, arg)
+					continue
+				}
+			case cmd != nil && cmd.Subcommand(arg) != nil:
+				cmd = cmd.Subcommand(arg)
+				rest = append(rest, arg)
+				continue
+			}
+		default:
+			rest = append(rest, arg)
+			if takesValue(cmd, arg) && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				rest = append(rest, args[i])
+			}
+			continue
+		}
+		// The rest belongs to the command
+		rest = append(rest, args[i:]...)
+		break
+	}
+
+	output.SetJSON(jsonOutput)
+
+	// Git commands are traced on stderr so stdout keeps the command output
+	var trace io.Writer
+	if a.config.Verbose {
+		trace = os.Stderr
+	}
+	a.cmds.SetVerbose(trace)
+
+	var plan *git.Planner
+	if a.config.DryRun {
+		plan = git.NewPlanner()
+	}
+	a.cmds.SetDryRun(plan)
+
+	return rest
+}
+
+// takesValue reports whether the option arg of cmd is followed by its
+// value, as the command would parse it: a flag with a value that is not
+// attached, or a short flag with a value ending a group (-sb main)
+func takesValue(cmd *commands.Command, arg string) bool {
+	if cmd == nil {
+		return false
+	}
+	flags := cmd.AllFlags()
+	if name, ok := strings.CutPrefix(arg, "--"); ok {
+		if strings.Contains(name, "=") {
+			return false
+		}
+		for _, flag := range flags {
+			if flag.Long == name {
+				return flag.Value != ""
+			}
+		}
+		return false
+	}
+	for j, short := range arg[1:] {
+		for _, flag := range flags {
+			if flag.Short == string(short) && flag.Value != "" {
+				// The value is attached unless the flag ends the group
+				return j == len(arg)-2
+			}
+		}
+	}
+	return false
+}
+
+// showPlan prints the changes a dry run would have made, in order
+func (a *App) showPlan(plan *git.Planner) {
+	// Keep stdout for the JSON document
+	w := io.Writer(os.Stdout)
+	if output.JSONEnabled() {
+		w = os.Stderr
+	}
+
+	steps := plan.Steps()
+	if len(steps) == 0 {
+		fmt.Fprintln(w, "\n📋 Dry run: nothing to change")
+		return
+	}
+
+	fmt.Fprintf(w, "\n📋 Dry run: nothing was changed. Planned steps:\n")
+	for i, step := range steps {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, step)
+	}
+}
+
 // showVersion displays the version information
 func (a *App) showVersion() error {
-	fmt.Fprintf(os.Stdout, "GitAT v1.1.0\n")
+	if output.JSONEnabled() {
+		return output.JSON(map[string]string{
+			"version":    strings.TrimPrefix(Version, "v"),
+			"commit":     CommitHash,
+			"build_date": BuildDate,
+		})
+	}
+
+	fmt.Fprintf(os.Stdout, "GitAT v%s\n", strings.TrimPrefix(Version, "v"))
+	if CommitHash != "" {
+		fmt.Fprintf(os.Stdout, "Commit: %s\n", CommitHash)
+	}
+	if BuildDate != "" {
+		fmt.Fprintf(os.Stdout, "Built: %s\n", BuildDate)
+	}
 	return nil
 }
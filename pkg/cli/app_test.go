@@ -1,9 +1,15 @@
 package cli
 
 import (
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/potsed/gitAT/internal/commands"
 	"github.com/potsed/gitAT/internal/config"
+	"github.com/potsed/gitAT/internal/errs"
+	"github.com/potsed/gitAT/internal/git/gittest"
+	"github.com/potsed/gitAT/pkg/output"
 )
 
 // createTestApp creates a test app with a temporary repository
@@ -199,3 +205,275 @@ func BenchmarkShowVersion(b *testing.B) {
 		app.showVersion()
 	}
 }
+
+// TestExecuteExitCodes tests the exit codes of failed commands
+func TestExecuteExitCodes(t *testing.T) {
+	app := createTestApp(t)
+
+	if code := app.Execute([]string{"help"}); code != 0 {
+		t.Errorf("expected help to exit 0, got %d", code)
+	}
+	if code := app.Execute([]string{"no-such-command"}); code != 2 {
+		t.Errorf("expected unknown commands to exit 2, got %d", code)
+	}
+
+	outside := NewApp(&config.Config{})
+	if code := outside.Execute([]string{"--json", "save", "x"}); code != 3 {
+		t.Errorf("expected commands outside a repository to exit 3, got %d", code)
+	}
+}
+
+// TestParseGlobalFlags tests that global flags are taken up to the
+// arguments of the command
+func TestParseGlobalFlags(t *testing.T) {
+	app := createTestApp(t)
+	defer output.SetJSON(false)
+
+	args := app.parseGlobalFlags([]string{"info", "--json"})
+	if len(args) != 1 || args[0] != "info" {
+		t.Errorf("expected --json to be removed, got %v", args)
+	}
+	if !output.JSONEnabled() {
+		t.Error("expected JSON output to be enabled")
+	}
+
+	app.parseGlobalFlags([]string{"info"})
+	if output.JSONEnabled() {
+		t.Error("expected JSON output to be disabled without --json")
+	}
+
+	args = app.parseGlobalFlags([]string{"--verbose", "squash", "--dry-run", "--pr"})
+	if len(args) != 2 || args[0] != "squash" || args[1] != "--pr" {
+		t.Errorf("expected --verbose and --dry-run to be removed, got %v", args)
+	}
+	if !app.config.Verbose || !app.config.DryRun || app.cmds.Plan() == nil {
+		t.Error("expected verbose and dry run to be enabled")
+	}
+
+	app.parseGlobalFlags([]string{"squash"})
+	if app.config.DryRun || app.cmds.Plan() != nil {
+		t.Error("expected dry run to be disabled without --dry-run")
+	}
+
+	args = app.parseGlobalFlags([]string{"plugins", "list", "--json"})
+	if len(args) != 2 || !output.JSONEnabled() {
+		t.Errorf("expected --json after a subcommand to be taken, got %v", args)
+	}
+
+	args = app.parseGlobalFlags([]string{"pr", "-b", "main", "--dry-run"})
+	if len(args) != 3 || !app.config.DryRun {
+		t.Errorf("expected --dry-run after a flag value to be taken, got %v", args)
+	}
+
+	// The arguments of the command, of plugins and after -- are left alone
+	for _, line := range [][]string{
+		{"save", "message", "--json"},
+		{"sync", "--", "--json"},
+		{"deploy", "--json"},
+	} {
+		args = app.parseGlobalFlags(line)
+		if len(args) != len(line) || output.JSONEnabled() {
+			t.Errorf("expected %v to be left alone, got %v", line, args)
+		}
+	}
+}
+
+// TestComplete tests the candidates printed for shell completion
+func TestComplete(t *testing.T) {
+	fake := gittest.NewFake("feature-x")
+	fake.Branches = []string{"feature-x", "master"}
+	fake.Set("at.pr.reviewer", "alice,bob")
+	app := NewApp(&config.Config{})
+	app.cmds = commands.NewManagerWithClients(&config.Config{}, fake, nil)
+
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"sq"}, []string{"squash\tSquash commits with auto-detection of parent branch"}},
+		{[]string{"ro"}, []string{"root\tSwitch to trunk branches"}},
+		{[]string{"squash", "--a"}, []string{"--auto\tEnable/disable automatic PR squashing"}},
+		{[]string{"squash", "--auto", ""}, []string{"on", "off", "status"}},
+		{[]string{"switch", ""}, []string{"feature-x", "master"}},
+		{[]string{"pr", "-r", ""}, []string{"alice", "bob"}},
+		{[]string{"pr", "--title", "x", ""}, []string{
+			"status\tShow the PR, reviews and checks of the current branch",
+			"list\tShow the PRs of all work branches",
+		}},
+		{[]string{"pr", "status", "-"}, []string{"-h\tShow this help message", "--help\tShow this help message", "--json\tPrint query results and errors as JSON", "--verbose\tPrint every git command with its duration",
+			"--dry-run\tPrint the planned changes instead of making them"}},
+		{[]string{"work", "bu"}, []string{"bugfix", "build"}},
+		{[]string{"work", "feature", ""}, nil},
+		{[]string{"worktree", "open", "m"}, []string{"master"}},
+		{[]string{"sweep", "--dry"}, []string{"--dry-run\tShow the commands that would run, deleting nothing"}},
+		{[]string{"branch", "--fe"}, []string{"--feature\tList feature branches"}},
+		{[]string{"wip", "-s", "msg", ""}, nil},
+		{[]string{"help", "sq"}, []string{"squash"}},
+		{[]string{"nope", ""}, nil},
+	}
+
+	for _, tt := range tests {
+		got := app.complete(tt.words)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("complete(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+// TestCompletionScripts tests that every shell gets a script
+func TestCompletionScripts(t *testing.T) {
+	app := createTestApp(t)
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		if err := app.runCompletion([]string{shell}); err != nil {
+			t.Errorf("completion %s failed: %v", shell, err)
+		}
+	}
+	if err := app.runCompletion([]string{"tcsh"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("expected a usage error for unknown shells, got %v", err)
+	}
+}
+
+// TestRegistry tests that every command of the usage can be run and has
+// help
+func TestRegistry(t *testing.T) {
+	app := createTestApp(t)
+	usage := app.usage()
+
+	seen := make(map[string]bool)
+	for _, cmd := range app.registry {
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			if seen[name] {
+				t.Errorf("%s is registered twice", name)
+			}
+			seen[name] = true
+		}
+		if cmd.Run == nil || cmd.Summary == "" {
+			t.Errorf("%s needs a handler and a summary", cmd.Name)
+		}
+		if !cmd.Hidden && !strings.Contains(usage, "  "+cmd.Name) {
+			t.Errorf("%s is missing from the usage", cmd.Name)
+		}
+		if help := cmd.Help(); !strings.HasPrefix(help, "Usage: git @ "+cmd.Name) || !strings.Contains(help, "-h, --help") {
+			t.Errorf("unexpected help of %s:\n%s", cmd.Name, help)
+		}
+	}
+}
+
+// TestRunHelp tests that help is printed outside a repository
+func TestRunHelp(t *testing.T) {
+	app := NewApp(&config.Config{})
+
+	for _, args := range [][]string{{"squash", "--help"}, {"pr", "status", "-h"}, {"help", "wip"}} {
+		if err := app.Run(args); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+	if err := app.Run([]string{"help", "nope"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("expected a usage error for unknown commands, got %v", err)
+	}
+	if err := app.Run([]string{"squash"}); !errs.Is(err, errs.NotARepo) {
+		t.Errorf("expected squash to need a repository, got %v", err)
+	}
+}
+
+// TestRunPlugin tests that unknown commands run git-@-<name> plugins
+func TestRunPlugin(t *testing.T) {
+	bin := t.TempDir()
+	script := "#!/bin/sh\n[ \"$1\" = fail ] && exit 4\nexit 0\n"
+	if err := os.WriteFile(bin+"/git-@-hello", []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	app := NewApp(&config.Config{})
+
+	if code := app.Execute([]string{"hello"}); code != 0 {
+		t.Errorf("expected the plugin to run outside a repository, got exit code %d", code)
+	}
+	if code := app.Execute([]string{"hello", "fail"}); code != 4 {
+		t.Errorf("expected the exit code of the plugin, got %d", code)
+	}
+	if err := app.Run([]string{"nope"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("expected a usage error without a plugin, got %v", err)
+	}
+
+	if got := app.complete([]string{"hell"}); strings.Join(got, "|") != "hello\tPlugin "+bin+"/git-@-hello" {
+		t.Errorf("expected plugins to complete, got %v", got)
+	}
+	if !strings.Contains(app.usage(), "Plugins:") {
+		t.Error("expected the usage to list the plugins")
+	}
+}
+
+// TestRunAlias tests that aliases run their commands in order
+func TestRunAlias(t *testing.T) {
+	bin := t.TempDir()
+	record := bin + "/record"
+	script := "#!/bin/sh\necho \"$*\" >> " + record + "\n[ \"$1\" = fail ] && exit 3\nexit 0\n"
+	if err := os.WriteFile(bin+"/git-@-rec", []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	fake := gittest.NewFake("feature-x")
+	fake.Set("at.alias.ship", "rec one $1; inner two $@")
+	fake.Set("at.alias.inner", "rec $@")
+	fake.Set("at.alias.stop", "rec fail; rec never")
+	fake.Set("at.alias.loop", "rec a; again")
+	fake.Set("at.alias.again", "loop")
+	app := NewApp(&config.Config{})
+	app.cmds = commands.NewManagerWithClients(&config.Config{}, fake, nil)
+
+	recorded := func() string {
+		data, _ := os.ReadFile(record)
+		os.Remove(record)
+		return strings.TrimSpace(string(data))
+	}
+
+	if err := app.Run([]string{"ship", "x"}); err != nil {
+		t.Fatalf("alias failed: %v", err)
+	}
+	if got := recorded(); got != "one x\ntwo x" {
+		t.Errorf("expected both commands with the argument, got %q", got)
+	}
+
+	if code := app.Execute([]string{"stop"}); code != 3 {
+		t.Errorf("expected the exit code of the failed command, got %d", code)
+	}
+	if got := recorded(); got != "fail" {
+		t.Errorf("expected the alias to stop at the failure, got %q", got)
+	}
+
+	err := app.Run([]string{"loop"})
+	if !errs.Is(err, errs.Usage) || !strings.Contains(err.Error(), "loop -> again -> loop") {
+		t.Errorf("expected an alias loop error, got %v", err)
+	}
+
+	if got := app.complete([]string{"shi"}); strings.Join(got, "|") != "ship\tAlias for rec one $1; inner two $@" {
+		t.Errorf("expected aliases to complete, got %q", got)
+	}
+}
+
+// TestRunTUIChecks tests that --tui needs a repository and no command. The
+// TUI itself needs a terminal; pkg/tui tests it.
+func TestRunTUIChecks(t *testing.T) {
+	if err := NewApp(&config.Config{}).Run([]string{"--tui"}); !errs.Is(err, errs.NotARepo) {
+		t.Errorf("expected --tui to need a repository, got %v", err)
+	}
+
+	repo := gittest.NewRepo(t)
+	if err := NewApp(&config.Config{RepoPath: repo.Dir}).Run([]string{"--tui", "info"}); !errs.Is(err, errs.Usage) {
+		t.Errorf("expected --tui with a command to be a usage error, got %v", err)
+	}
+}
+
+// TestDocsUpToDate tests that docs/COMMANDS.md matches the registry
+func TestDocsUpToDate(t *testing.T) {
+	want, err := os.ReadFile("../../docs/COMMANDS.md")
+	if err != nil {
+		t.Fatalf("failed to read the command reference: %v", err)
+	}
+	if got := createTestApp(t).docs(); got != string(want) {
+		t.Error("docs/COMMANDS.md is out of date, run 'make docs'")
+	}
+}
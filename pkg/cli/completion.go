@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/commands"
+	"github.com/potsed/gitAT/internal/errs"
+)
+
+// complete returns the candidates for the last of words, the arguments
+// after "git @", as "value\tdescription" lines
+func (a *App) complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	before := words[:len(words)-1]
+
+	var candidates []string
+	seen := make(map[string]bool)
+	add := func(value, help string) {
+		if strings.HasPrefix(value, current) && !seen[value] {
+			seen[value] = true
+			if help != "" {
+				value += "\t" + help
+			}
+			candidates = append(candidates, value)
+		}
+	}
+	addFlags := func(flags []commands.Flag) {
+		for _, flag := range flags {
+			if flag.Short != "" {
+				add("-"+flag.Short, flag.Help)
+			}
+			add("--"+flag.Long, flag.Help)
+		}
+	}
+	addCommands := func(cmds []*commands.Command) {
+		for _, cmd := range cmds {
+			if cmd.Hidden {
+				continue
+			}
+			for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+				add(name, cmd.Summary)
+			}
+		}
+	}
+
+	if len(before) == 0 {
+		if strings.HasPrefix(current, "-") {
+			addFlags(globalFlags)
+		} else {
+			addCommands(a.registry)
+			for _, alias := range a.cmds.Aliases() {
+				add(alias.Name, "Alias for "+alias.Expansion)
+			}
+			for _, plugin := range a.cmds.InstalledPlugins() {
+				add(plugin.Name, "Plugin "+plugin.Path)
+			}
+		}
+		return candidates
+	}
+
+	cmd := commands.FindCommand(a.registry, before[0])
+	if cmd == nil || cmd.Hidden {
+		return nil
+	}
+
+	// Walk the arguments, descending into a subcommand given first
+	var positional []string
+	for i := 1; i < len(before); i++ {
+		arg := before[i]
+		if !strings.HasPrefix(arg, "-") {
+			if sub := cmd.Subcommand(arg); sub != nil && len(positional) == 0 {
+				cmd = sub
+				continue
+			}
+			positional = append(positional, arg)
+			continue
+		}
+		flag, ok := findFlag(cmd, arg)
+		if !ok || flag.Value == "" {
+			continue
+		}
+		// The value of a flag, e.g. --auto <TAB>
+		if i == len(before)-1 {
+			for _, value := range a.candidates(flag.Values, flag.Source) {
+				add(value, "")
+			}
+			return candidates
+		}
+		i++
+	}
+
+	if strings.HasPrefix(current, "-") {
+		addFlags(cmd.AllFlags())
+		addFlags(globalFlags)
+		return candidates
+	}
+
+	if len(positional) == 0 && len(cmd.Subcommands) > 0 {
+		addCommands(cmd.Subcommands)
+		return candidates
+	}
+	if len(positional) < len(cmd.Args) {
+		arg := cmd.Args[len(positional)]
+		for _, value := range a.candidates(arg.Values, arg.Source) {
+			add(value, "")
+		}
+	}
+	return candidates
+}
+
+// findFlag returns the flag of a command, or a global flag, named as typed
+// on the command line
+func findFlag(cmd *commands.Command, name string) (commands.Flag, bool) {
+	for _, flags := range [][]commands.Flag{cmd.AllFlags(), globalFlags} {
+		for _, flag := range flags {
+			if name == "--"+flag.Long || (flag.Short != "" && name == "-"+flag.Short) {
+				return flag, true
+			}
+		}
+	}
+	return commands.Flag{}, false
+}
+
+// candidates returns fixed values, or the values of a completion source
+func (a *App) candidates(values []string, source string) []string {
+	switch source {
+	case "":
+		return values
+	case sourceCommands:
+		var names []string
+		for _, cmd := range a.registry {
+			if !cmd.Hidden {
+				names = append(names, cmd.Name)
+			}
+		}
+		return names
+	}
+	return a.cmds.Complete(source)
+}
+
+// runComplete handles the hidden __complete command used by the shell
+// scripts: it prints the candidates for the last argument, one per line
+func (a *App) runComplete(words []string) error {
+	for _, candidate := range a.complete(words) {
+		fmt.Fprintln(os.Stdout, candidate)
+	}
+	return nil
+}
+
+// runCompletion handles the completion command
+func (a *App) runCompletion(args []string) error {
+	if len(args) != 1 {
+		return a.runHelp([]string{"completion"})
+	}
+
+	switch args[0] {
+	case "bash":
+		fmt.Fprint(os.Stdout, bashCompletion)
+	case "zsh":
+		fmt.Fprint(os.Stdout, zshCompletion)
+	case "fish":
+		fmt.Fprint(os.Stdout, fishCompletion)
+	default:
+		return errs.New(errs.Usage, "Unsupported shell '%s'. Use bash, zsh or fish", args[0])
+	}
+	return nil
+}
+
+// The completion scripts ask "git @ __complete <words>" for the candidates,
+// so they follow the commands without being regenerated.
+
+const bashCompletion = `# bash completion for git @ (GitAT)
+#
+# Load it after git's completion, e.g. in ~/.bashrc:
+#   source <(git @ completion bash)
+
+# git's completion calls _git_@ for "git @ ..."
+_git_@ ()
+{
+	local start=$(( ${__git_cmd_idx:-1} + 1 ))
+	local IFS=$'\n'
+	__gitcomp_nl "$(git @ __complete "${words[@]:start:cword-start+1}" 2>/dev/null | cut -f1)"
+}
+
+# Completion of the git-@ binary itself
+_git_at ()
+{
+	local cur="${COMP_WORDS[COMP_CWORD]}"
+	local IFS=$'\n'
+	COMPREPLY=($(compgen -W "$(git-@ __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null | cut -f1)" -- "$cur"))
+}
+complete -o default -F _git_at git-@
+`
+
+const zshCompletion = `#compdef git-@
+# zsh completion for git @ (GitAT)
+#
+# Load it after compinit, e.g. in ~/.zshrc:
+#   source <(git @ completion zsh)
+
+# List @ among the git commands; git's completion then calls _git-@
+zstyle ':completion:*:*:git:*' user-commands @:'Git workflow management (GitAT)'
+
+_git-@ () {
+	local -a candidates
+	local line value desc
+	for line in "${(@f)$(git @ __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}"; do
+		[[ -z $line ]] && continue
+		value=${line%%$'\t'*}
+		desc=${line#*$'\t'}
+		[[ $desc == $line ]] && desc=
+		candidates+=("${value//:/\\:}${desc:+:$desc}")
+	done
+	_describe -t gitat 'git @' candidates
+}
+
+compdef _git-@ git-@
+`
+
+const fishCompletion = `# fish completion for git @ (GitAT)
+#
+# Install it with:
+#   git @ completion fish > ~/.config/fish/completions/git-@.fish
+# and source that file from ~/.config/fish/config.fish so "git @" completes.
+
+function __gitat_using_at
+    contains -- @ (commandline -opc)
+end
+
+function __gitat_complete
+    set -l tokens (commandline -opc)
+    set -l at (contains -i -- @ $tokens)
+    if test -n "$at"
+        set -e tokens[1..$at]
+    else
+        set -e tokens[1]
+    end
+    git @ __complete $tokens (commandline -ct) 2>/dev/null
+end
+
+complete -c git -n __fish_use_subcommand -f -a @ -d 'Git workflow management (GitAT)'
+complete -c git -n __gitat_using_at -f -a '(__gitat_complete)'
+complete -c git-@ -f -a '(__gitat_complete)'
+`
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/potsed/gitAT/internal/commands"
+	"github.com/potsed/gitAT/internal/errs"
+)
+
+// globalFlags are accepted by every command, see parseGlobalFlags
+var globalFlags = []commands.Flag{
+	{Long: "json", Help: "Print query results and errors as JSON"},
+	{Long: "verbose", Help: "Print every git command with its duration"},
+	{Long: "dry-run", Help: "Print the planned changes instead of making them"},
+}
+
+// sourceCommands completes command names, for git @ help <command>
+const sourceCommands = "commands"
+
+// builtins returns the commands of the CLI itself
+func (a *App) builtins() []*commands.Command {
+	return []*commands.Command{
+		{
+			Name:    "completion",
+			Hint:    "bash|zsh|fish",
+			Summary: "Print the shell completion script",
+			Description: `Print the shell completion script of git @. The scripts hook into git's
+own completion, so 'git @ <TAB>' completes commands, flags, branch names,
+work types and configured reviewers.`,
+			Args: []commands.Arg{{Name: "<shell>", Values: []string{"bash", "zsh", "fish"}}},
+			Examples: []commands.Example{
+				{Command: "git @ completion bash", Comment: "Print the bash script"},
+				{Command: "git @ completion zsh > ~/.zfunc/_git-@"},
+			},
+			Sections: []commands.Section{
+				{Title: "INSTALLATION", Body: `bash   Add to ~/.bashrc (after git's completion is loaded):
+         source <(git @ completion bash)
+zsh    Add to ~/.zshrc (after compinit):
+         source <(git @ completion zsh)
+fish   git @ completion fish > ~/.config/fish/completions/git-@.fish
+       and add to ~/.config/fish/config.fish:
+         source ~/.config/fish/completions/git-@.fish`},
+			},
+			NoRepo: true,
+			Run:    func(_ *commands.Manager, args []string) error { return a.runCompletion(args) },
+		},
+		{
+			Name:    "help",
+			Hint:    "[<command>]",
+			Summary: "Show the help of git @ or of a command",
+			Args:    []commands.Arg{{Name: "<command>", Source: sourceCommands}},
+			Examples: []commands.Example{
+				{Command: "git @ help", Comment: "List the commands"},
+				{Command: "git @ help squash", Comment: "Same as git @ squash --help"},
+			},
+			NoRepo: true,
+			Run:    func(_ *commands.Manager, args []string) error { return a.runHelp(args) },
+		},
+		{
+			Name:    "__complete",
+			Summary: "Print the completion candidates of the last word",
+			Hidden:  true,
+			NoRepo:  true,
+			Run:     func(_ *commands.Manager, args []string) error { return a.runComplete(args) },
+		},
+		{
+			Name:    "__docs",
+			Summary: "Print the command reference in Markdown",
+			Hidden:  true,
+			NoRepo:  true,
+			Run: func(*commands.Manager, []string) error {
+				fmt.Fprint(os.Stdout, a.docs())
+				return nil
+			},
+		},
+	}
+}
+
+// runHelp handles git @ help [<command> [<subcommand>]]
+func (a *App) runHelp(args []string) error {
+	if len(args) == 0 {
+		return a.showUsage()
+	}
+
+	cmd := commands.FindCommand(a.registry, args[0])
+	if cmd == nil {
+		return errs.New(errs.Usage, "unknown command: %s (see 'git @ help')", args[0])
+	}
+	if len(args) > 1 {
+		if sub := cmd.Subcommand(args[1]); sub != nil {
+			cmd = sub
+		}
+	}
+	return a.showHelp(cmd)
+}
+
+// showHelp prints the help of a command
+func (a *App) showHelp(cmd *commands.Command) error {
+	fmt.Fprint(os.Stdout, cmd.Help())
+	return nil
+}
+
+// showUsage displays the usage information
+func (a *App) showUsage() error {
+	fmt.Fprint(os.Stdout, a.usage())
+	return nil
+}
+
+// usage returns the list of commands and global options
+func (a *App) usage() string {
+	var b strings.Builder
+	b.WriteString("GitAT - Git Workflow Management Tool\n\nUsage: git @ <command> [options]\n\nCommands:\n")
+	for _, cmd := range a.registry {
+		if cmd.Hidden {
+			continue
+		}
+		name := strings.Join(append([]string{cmd.Name}, cmd.Aliases...), ", ")
+		fmt.Fprintf(&b, "  %-28s %s\n", strings.TrimSpace(name+" "+cmd.Hint), cmd.Summary)
+	}
+
+	if aliases := a.cmds.Aliases(); len(aliases) > 0 {
+		b.WriteString("\nAliases:\n")
+		for _, alias := range aliases {
+			fmt.Fprintf(&b, "  %-28s %s\n", alias.Name, alias.Expansion)
+		}
+	}
+	if plugins := a.cmds.InstalledPlugins(); len(plugins) > 0 {
+		b.WriteString("\nPlugins:\n")
+		for _, plugin := range plugins {
+			fmt.Fprintf(&b, "  %-28s %s\n", plugin.Name, plugin.Path)
+		}
+	}
+
+	b.WriteString("\nOptions:\n")
+	writeOptions(&b, append([]commands.Flag{
+		{Short: "h", Long: "help", Help: "Show this help message"},
+		{Short: "v", Long: "version", Help: "Show version information"},
+		{Long: "tui", Help: "Open the interactive terminal UI"},
+	}, globalFlags...))
+
+	b.WriteString(`
+Run 'git @ <command> --help' for the help of a command.
+
+Examples:
+  git @ work feature add-user-authentication
+  git @ save "Add user authentication system"
+  git @ pr
+  git @ sweep
+
+Exit codes: 0 success, 1 error, 2 usage, 3 not a repository, 4 detached HEAD,
+5 uncommitted changes, 6 conflict, 7 not found, 8 provider authentication,
+9 policy violation, 10 nothing to do, 130 cancelled
+
+For more information, visit: https://github.com/potsed/gitAT
+`)
+	return b.String()
+}
+
+// writeOptions lists flags in the layout of the usage
+func writeOptions(w io.Writer, flags []commands.Flag) {
+	for _, flag := range flags {
+		name := "    --" + flag.Long
+		if flag.Short != "" {
+			name = "-" + flag.Short + ", --" + flag.Long
+		}
+		fmt.Fprintf(w, "  %-28s %s\n", name, flag.Help)
+	}
+}
+
+// docs returns the command reference of docs/COMMANDS.md, generated from
+// the registry by 'make docs'
+func (a *App) docs() string {
+	var b strings.Builder
+	b.WriteString("# GitAT Command Reference\n\n")
+	b.WriteString("<!-- Generated from the command registry by 'make docs'. Do not edit. -->\n\n")
+	b.WriteString("Every command is run as `git @ <command>`. Global options:\n\n")
+	for _, flag := range globalFlags {
+		fmt.Fprintf(&b, "- `--%s`: %s\n", flag.Long, flag.Help)
+	}
+	b.WriteString("\nThey go before the first argument of the command or `--`; aliases and\n")
+	b.WriteString("plugins get their arguments unchanged.\n")
+
+	b.WriteString("\n| Command | Description |\n|---------|-------------|\n")
+	for _, cmd := range a.registry {
+		if !cmd.Hidden {
+			fmt.Fprintf(&b, "| [`%s`](#%s) | %s |\n", cmd.Name, anchor(cmd.Name), cmd.Summary)
+		}
+	}
+
+	for _, cmd := range a.registry {
+		if cmd.Hidden {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n%s.\n", cmd.Name, cmd.Summary)
+		if len(cmd.Aliases) > 0 {
+			fmt.Fprintf(&b, "\nAliases: `%s`\n", strings.Join(cmd.Aliases, "`, `"))
+		}
+		fmt.Fprintf(&b, "\n```text\n%s```\n", cmd.Help())
+		for _, sub := range cmd.Subcommands {
+			if sub.Description != "" {
+				fmt.Fprintf(&b, "\n### %s\n\n```text\n%s```\n", sub.Path(), sub.Help())
+			}
+		}
+	}
+	return b.String()
+}
+
+// anchor returns the GitHub anchor of a heading
+func anchor(heading string) string {
+	return strings.ToLower(strings.ReplaceAll(heading, " ", "-"))
+}
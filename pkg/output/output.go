@@ -1,6 +1,7 @@
 package output
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -52,6 +53,15 @@ var (
 	}
 )
 
+// jsonMode is set by --json: query commands then print a JSON document
+// instead of text
+var jsonMode bool
+
+func init() {
+	// Provide a default logger so output works before Init is called
+	Init()
+}
+
 // Init initializes the output package with a logger
 func Init() {
 	Logger = log.NewWithOptions(os.Stderr, log.Options{
@@ -63,6 +73,27 @@ func Init() {
 	})
 }
 
+// SetJSON switches query commands to JSON output. Log messages still go to
+// stderr, so stdout only holds the document.
+func SetJSON(enabled bool) {
+	jsonMode = enabled
+}
+
+// JSONEnabled reports whether --json was given
+func JSONEnabled() bool {
+	return jsonMode
+}
+
+// JSON prints v as an indented JSON document on stdout
+func JSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
 // Success logs a success message using the logger
 func Success(format string, args ...interface{}) {
 	Logger.Info(fmt.Sprintf(format, args...))
@@ -0,0 +1,297 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/potsed/gitAT/internal/commands"
+)
+
+// branchesModel is the Branches tab: the local branches grouped by work
+// type, to switch to, create and delete
+type branchesModel struct {
+	groups  []branchGroup
+	cursor  int // index in the branches of all groups
+	err     error
+	loaded  bool
+	confirm string // pending "delete" or "force delete" of the selection
+	input   textinput.Model
+	newType string // work type of the branch being named, "" when closed
+}
+
+// branchGroup is the branches of a work type; Type is "" for the others
+type branchGroup struct {
+	Type     string
+	Branches []commands.BranchEntry
+}
+
+// branchesMsg carries the loaded branches
+type branchesMsg struct {
+	branches []commands.BranchEntry
+	err      error
+}
+
+// branchDeletedMsg reports a deletion
+type branchDeletedMsg struct {
+	name    string
+	planned bool
+	err     error
+}
+
+func newBranchesModel() branchesModel {
+	input := textinput.New()
+	input.Placeholder = "short description, e.g. add-login"
+	return branchesModel{input: input}
+}
+
+// loadBranches loads the branches of the Branches tab
+func (m Model) loadBranches() tea.Cmd {
+	return m.locked(func() tea.Msg {
+		branches, err := m.opts.Manager.Branches()
+		return branchesMsg{branches: branches, err: err}
+	})
+}
+
+// groupBranches groups branches by work type, in the order of the types,
+// with the other branches first
+func groupBranches(branches []commands.BranchEntry) []branchGroup {
+	byType := make(map[string][]commands.BranchEntry)
+	for _, branch := range branches {
+		byType[branch.Type] = append(byType[branch.Type], branch)
+	}
+
+	var groups []branchGroup
+	for _, workType := range append([]string{""}, commands.WorkTypes()...) {
+		if len(byType[workType]) > 0 {
+			groups = append(groups, branchGroup{Type: workType, Branches: byType[workType]})
+		}
+	}
+	return groups
+}
+
+// selected returns the branch under the cursor and its group
+func (b branchesModel) selected() (commands.BranchEntry, branchGroup, bool) {
+	i := b.cursor
+	for _, group := range b.groups {
+		if i < len(group.Branches) {
+			return group.Branches[i], group, true
+		}
+		i -= len(group.Branches)
+	}
+	return commands.BranchEntry{}, branchGroup{}, false
+}
+
+// count returns the number of branches
+func (b branchesModel) count() int {
+	n := 0
+	for _, group := range b.groups {
+		n += len(group.Branches)
+	}
+	return n
+}
+
+// prompting reports whether a confirmation or the name prompt is open
+func (b branchesModel) prompting() bool {
+	return b.confirm != "" || b.newType != ""
+}
+
+func (m Model) updateBranches(msg tea.Msg) (Model, tea.Cmd) {
+	b := &m.branches
+	switch msg := msg.(type) {
+	case branchesMsg:
+		b.groups, b.err, b.loaded = groupBranches(msg.branches), msg.err, true
+		b.cursor = min(b.cursor, max(b.count()-1, 0))
+		return m, nil
+
+	case branchDeletedMsg:
+		switch {
+		case msg.err != nil && strings.Contains(msg.err.Error(), "not fully merged"):
+			m.message, m.failed = fmt.Sprintf("%s is not fully merged: press D to delete it anyway", msg.name), true
+		case msg.err != nil:
+			m.message, m.failed = errorMessage(msg.err), true
+		case msg.planned:
+			m.message, m.failed = fmt.Sprintf("Dry run: would delete %s", msg.name), false
+		default:
+			m.message, m.failed = fmt.Sprintf("Deleted %s", msg.name), false
+		}
+		return m, m.loadBranches()
+
+	case tea.KeyMsg:
+		if b.newType != "" {
+			return m.newBranchKey(msg)
+		}
+		if b.confirm != "" {
+			return m.confirmKey(msg)
+		}
+
+		branch, group, ok := b.selected()
+		switch msg.String() {
+		case "up", "k":
+			b.cursor = max(b.cursor-1, 0)
+		case "down", "j":
+			b.cursor = min(b.cursor+1, max(b.count()-1, 0))
+		case "r":
+			return m, m.loadBranches()
+		case "enter":
+			if ok && !m.commands.running {
+				return m.runBranchCommand("switch", branch.Name)
+			}
+		case "d", "D":
+			if ok {
+				b.confirm = map[string]string{"d": "delete", "D": "force delete"}[msg.String()]
+			}
+		case "n":
+			b.newType = group.Type
+			if b.newType == "" {
+				b.newType = "feature"
+			}
+			b.input.SetValue("")
+			return m, b.input.Focus()
+		}
+	}
+	return m, nil
+}
+
+// confirmKey answers the deletion confirmation
+func (m Model) confirmKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	b := &m.branches
+	action := b.confirm
+	b.confirm = ""
+	branch, _, ok := b.selected()
+	if !ok || (msg.String() != "y" && msg.String() != "Y") {
+		return m, nil
+	}
+
+	manager := m.opts.Manager
+	return m, m.locked(func() tea.Msg {
+		err := manager.DeleteBranch(branch.Name, action == "force delete")
+		return branchDeletedMsg{name: branch.Name, planned: manager.Plan() != nil, err: err}
+	})
+}
+
+// newBranchKey handles a key of the new branch prompt
+func (m Model) newBranchKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	b := &m.branches
+	switch msg.String() {
+	case "esc":
+		b.newType = ""
+		b.input.Blur()
+		return m, nil
+	case "enter":
+		workType, name := b.newType, strings.TrimSpace(b.input.Value())
+		b.newType = ""
+		b.input.Blur()
+		if name == "" {
+			return m, nil
+		}
+		return m.runBranchCommand("work", workType, name)
+	case "tab":
+		// Cycle the work type of the new branch
+		types := commands.WorkTypes()
+		for i, workType := range types {
+			if workType == b.newType {
+				b.newType = types[(i+1)%len(types)]
+				break
+			}
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	b.input, cmd = b.input.Update(msg)
+	return m, cmd
+}
+
+// runBranchCommand runs a git @ command for the selected branch, with its
+// output in the Commands tab
+func (m Model) runBranchCommand(args ...string) (Model, tea.Cmd) {
+	return m.startCommand(strings.Join(args, " "), [][]string{args})
+}
+
+func (b branchesModel) keys() string {
+	switch {
+	case b.newType != "":
+		return "enter create • tab change type • esc cancel"
+	case b.confirm != "":
+		return "y confirm • any other key cancel"
+	}
+	return "↑/↓ select • enter switch • n new work branch • d delete • D force delete • r refresh"
+}
+
+func (b branchesModel) view(width, height int) string {
+	if !b.loaded {
+		return dimStyle.Render("Loading…")
+	}
+	if b.err != nil {
+		return errorStyle.Render(errorMessage(b.err))
+	}
+
+	var lines []string
+	selectedLine, i := 0, 0
+	for _, group := range b.groups {
+		title := "Other branches"
+		if group.Type != "" {
+			title = group.Type
+		}
+		lines = append(lines, titleStyle.Render(fmt.Sprintf("%s (%d)", title, len(group.Branches))))
+		for _, branch := range group.Branches {
+			marker := "  "
+			if branch.Current {
+				marker = "* "
+			}
+			line := marker + branch.Name
+			if context := strings.Join(nonEmpty(branch.Product, branch.Feature, branch.Issue), "."); context != "" {
+				line += dimStyle.Render("  [" + context + "]")
+			}
+			switch {
+			case i == b.cursor:
+				line = selectedStyle.Render(truncate(marker+branch.Name, width))
+				selectedLine = len(lines)
+			case branch.Current:
+				line = currentStyle.Render(marker+branch.Name) + strings.TrimPrefix(line, marker+branch.Name)
+			}
+			lines = append(lines, truncate(line, width))
+			i++
+		}
+		lines = append(lines, "")
+	}
+	if len(lines) == 0 {
+		lines = []string{dimStyle.Render("No branches yet")}
+	}
+
+	// Keep the selection in view, leaving room for a prompt
+	rows := max(height-2, 3)
+	offset := max(selectedLine-rows+1, 0)
+	lines = lines[offset:min(offset+rows, len(lines))]
+
+	body := strings.Join(lines, "\n")
+	if branch, _, ok := b.selected(); ok && b.confirm != "" {
+		body += "\n" + errorStyle.Render(fmt.Sprintf("%s %s? (y/N)", capitalize(b.confirm), branch.Name))
+	}
+	if b.newType != "" {
+		body += "\n" + dimStyle.Render("New "+b.newType+" branch:") + " " + b.input.View()
+	}
+	return body
+}
+
+// nonEmpty returns the values that are not empty
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/potsed/gitAT/pkg/output"
+)
+
+// capture runs fn with stdout and stderr going to the returned output and
+// an empty stdin, so commands cannot draw over the TUI or wait for keys.
+// The TUI itself keeps the terminal it was started with.
+func capture(fn func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		r.Close()
+		w.Close()
+		return "", err
+	}
+	defer null.Close()
+
+	stdin, stdout, stderr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = null, w, w
+	// The logger holds the stderr it was created with
+	output.Init()
+	defer func() {
+		os.Stdin, os.Stdout, os.Stderr = stdin, stdout, stderr
+		output.Init()
+	}()
+
+	// Read while fn writes, so a long output cannot fill the pipe
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		r.Close()
+		close(done)
+	}()
+
+	err = fn()
+	w.Close()
+	<-done
+	return buf.String(), err
+}
@@ -0,0 +1,278 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/potsed/gitAT/internal/commands"
+	"github.com/potsed/gitAT/internal/errs"
+)
+
+// commandsModel is the Commands tab: the git @ commands, a prompt for the
+// arguments and the output of the last command
+type commandsModel struct {
+	list    []*commands.Command
+	cursor  int
+	offset  int // first command shown
+	input   textinput.Model
+	open    bool // the prompt is shown
+	inTerm  bool // the prompt runs the command in the terminal
+	running bool
+	spinner spinner.Model
+	output  viewport.Model
+	width   int
+}
+
+// commandDoneMsg reports a finished command
+type commandDoneMsg struct {
+	line   string
+	output string
+	err    error
+}
+
+func newCommandsModel(cmds []*commands.Command) commandsModel {
+	var list []*commands.Command
+	for _, cmd := range cmds {
+		if !cmd.Hidden {
+			list = append(list, cmd)
+		}
+	}
+
+	input := textinput.New()
+	input.Prompt = "git @ "
+	input.PromptStyle = titleStyle
+
+	output := viewport.New(40, 10)
+	output.SetContent(dimStyle.Render("Select a command and press enter. Its output shows here."))
+
+	return commandsModel{
+		list:    list,
+		input:   input,
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(titleStyle)),
+		output:  output,
+	}
+}
+
+// prompting reports whether the arguments prompt is open
+func (c commandsModel) prompting() bool {
+	return c.open
+}
+
+// listWidth is the width of the command list
+func (c commandsModel) listWidth() int {
+	return min(max(c.width/3, 24), 44)
+}
+
+func (c *commandsModel) resize(width, height int) {
+	c.width = width
+	c.output.Width = max(width-c.listWidth()-4, 10)
+	c.output.Height = max(height-5, 3)
+	c.input.Width = max(width-10, 10)
+}
+
+func (m Model) updateCommands(msg tea.Msg) (Model, tea.Cmd) {
+	c := &m.commands
+	switch msg := msg.(type) {
+	case commandDoneMsg:
+		c.running = false
+		result := successStyle.Render("✔ done")
+		if msg.err != nil {
+			result = errorStyle.Render(fmt.Sprintf("✘ exit %d: %s", errs.ExitCode(msg.err), errorMessage(msg.err)))
+		}
+		content := titleStyle.Render("$ git @ "+msg.line) + "\n" + strings.TrimRight(msg.output, "\n") + "\n\n" + result
+		c.output.SetContent(content)
+		c.output.GotoTop()
+
+		m.message, m.failed = "git @ "+msg.line+": done", false
+		if msg.err != nil {
+			m.message, m.failed = "git @ "+msg.line+": "+errorMessage(msg.err), true
+		}
+		// The command may have changed branches, config or the tree
+		return m, tea.Batch(m.loadStatus(), m.loadBranches())
+
+	case spinner.TickMsg:
+		if !c.running {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		c.spinner, cmd = c.spinner.Update(msg)
+		return m, cmd
+
+	case tea.KeyMsg:
+		if c.open {
+			return m.commandPromptKey(msg)
+		}
+		switch msg.String() {
+		case "up", "k":
+			c.move(-1)
+		case "down", "j":
+			c.move(1)
+		case "enter", "x":
+			if c.running || len(c.list) == 0 {
+				return m, nil
+			}
+			c.open, c.inTerm = true, msg.String() == "x"
+			c.input.SetValue(c.list[c.cursor].Name + " ")
+			c.input.CursorEnd()
+			return m, c.input.Focus()
+		default:
+			// pgup, pgdown, ctrl+u, ctrl+d scroll the output
+			var cmd tea.Cmd
+			c.output, cmd = c.output.Update(msg)
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+// commandPromptKey handles a key of the arguments prompt
+func (m Model) commandPromptKey(msg tea.KeyMsg) (Model, tea.Cmd) {
+	c := &m.commands
+	switch msg.String() {
+	case "esc":
+		c.open = false
+		c.input.Blur()
+		return m, nil
+	case "enter":
+		line := strings.TrimSpace(c.input.Value())
+		c.open = false
+		c.input.Blur()
+		if line == "" {
+			return m, nil
+		}
+		lines, err := commands.ExpandAlias(line, nil)
+		if err != nil {
+			return m, notify("", err)
+		}
+		if c.inTerm {
+			return m, m.runInTerminal(line, lines)
+		}
+		return m.startCommand(line, lines)
+	}
+
+	var cmd tea.Cmd
+	c.input, cmd = c.input.Update(msg)
+	return m, cmd
+}
+
+// move moves the cursor by delta, scrolling the list
+func (c *commandsModel) move(delta int) {
+	c.cursor = min(max(c.cursor+delta, 0), len(c.list)-1)
+	height := c.output.Height + 2
+	if c.cursor < c.offset {
+		c.offset = c.cursor
+	}
+	if c.cursor >= c.offset+height {
+		c.offset = c.cursor - height + 1
+	}
+}
+
+// startCommand runs command lines, showing their progress in the pane
+func (m Model) startCommand(line string, lines [][]string) (Model, tea.Cmd) {
+	c := &m.commands
+	c.running = true
+	c.output.SetContent(titleStyle.Render("$ git @ "+line) + "\n" + dimStyle.Render("Running…"))
+	return m, tea.Batch(m.runCommand(line, lines), c.spinner.Tick)
+}
+
+// runCommand runs command lines with their output captured for the pane.
+// Commands read no input: prompts answer no and forms fail, see
+// runInTerminal.
+func (m Model) runCommand(line string, lines [][]string) tea.Cmd {
+	opts := m.opts
+	return m.locked(func() tea.Msg {
+		out, err := capture(func() error {
+			if opts.Verbose {
+				opts.Manager.SetVerbose(os.Stderr)
+				defer opts.Manager.SetVerbose(nil)
+			}
+			for _, args := range lines {
+				if err := opts.Run(args); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		return commandDoneMsg{line: line, output: out, err: err}
+	})
+}
+
+// runInTerminal suspends the TUI to run a command line that asks for input
+func (m Model) runInTerminal(line string, lines [][]string) tea.Cmd {
+	if len(lines) != 1 {
+		return notify("", errs.New(errs.Usage, "Run one command at a time in the terminal"))
+	}
+	if m.opts.Executable == "" {
+		return notify("", errs.New(errs.Usage, "Cannot find the git @ executable"))
+	}
+
+	cmd := exec.Command(m.opts.Executable, append(append([]string(nil), m.opts.Flags...), lines[0]...)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if exit, ok := err.(*exec.ExitError); ok {
+			err = &errs.Exit{Name: "git @ " + line, Code: exit.ExitCode()}
+		}
+		return commandDoneMsg{line: line, output: dimStyle.Render("(ran in the terminal)"), err: err}
+	})
+}
+
+func (c commandsModel) keys() string {
+	switch {
+	case c.open && c.inTerm:
+		return "enter run in the terminal • esc cancel"
+	case c.open:
+		return "enter run • esc cancel • ';' separates commands"
+	}
+	return "↑/↓ select • enter run • x run in the terminal (prompts) • pgup/pgdown scroll output"
+}
+
+func (c commandsModel) view(width, height int) string {
+	listWidth := c.listWidth()
+	rows := max(height-5, 3) + 2
+
+	var lines []string
+	for i := c.offset; i < len(c.list) && i < c.offset+rows; i++ {
+		cmd := c.list[i]
+		name := fmt.Sprintf("%-10s", cmd.Name)
+		line := truncate(name+" "+dimStyle.Render(cmd.Summary), listWidth)
+		if i == c.cursor {
+			line = selectedStyle.Render(truncate(name+" "+cmd.Summary, listWidth))
+		}
+		lines = append(lines, line)
+	}
+	list := paneStyle.Width(listWidth).Height(rows).Render(strings.Join(lines, "\n"))
+
+	title := "Output"
+	if c.running {
+		title = c.spinner.View() + " Running"
+	}
+	pane := lipgloss.JoinVertical(lipgloss.Left, titleStyle.Render(title), c.output.View())
+	output := focusedPaneStyle.Width(max(width-listWidth-6, 10)).Height(rows).Render(pane)
+
+	body := lipgloss.JoinHorizontal(lipgloss.Top, list, output)
+	if c.open {
+		label := "Arguments"
+		if c.inTerm {
+			label = "Run in the terminal"
+		}
+		body += "\n" + dimStyle.Render(label) + " " + c.input.View()
+	}
+	return body
+}
+
+// notify returns a command showing a notification
+func notify(text string, err error) tea.Cmd {
+	return func() tea.Msg { return notifyMsg{text: text, err: err} }
+}
+
+// errorMessage returns the message of an error as the CLI prints it
+func errorMessage(err error) string {
+	return errs.Message(err)
+}
@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/potsed/gitAT/internal/commands"
+)
+
+// infoModel is the Info tab: configuration, repository details, statistics
+// and remotes, scrolled in a viewport
+type infoModel struct {
+	content viewport.Model
+	loaded  bool
+}
+
+// infoMsg carries the loaded information
+type infoMsg struct {
+	report commands.InfoReport
+	config map[string]interface{}
+	stats  commands.RepoStats
+	err    error
+}
+
+func newInfoModel() infoModel {
+	return infoModel{content: viewport.New(80, 10)}
+}
+
+func (i *infoModel) resize(width, height int) {
+	i.content.Width, i.content.Height = width, height
+}
+
+// loadInfo loads the Info tab
+func (m Model) loadInfo() tea.Cmd {
+	return m.locked(func() tea.Msg {
+		manager := m.opts.Manager
+		report, err := manager.Report()
+		return infoMsg{report: report, config: manager.Config(), stats: manager.Stats(), err: err}
+	})
+}
+
+func (m Model) updateInfo(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case infoMsg:
+		m.info.loaded = true
+		m.info.content.SetContent(infoContent(msg, m.width))
+		return m, nil
+	case tea.KeyMsg:
+		if msg.String() == "r" {
+			return m, m.loadInfo()
+		}
+	}
+
+	// Arrows and page keys scroll
+	var cmd tea.Cmd
+	m.info.content, cmd = m.info.content.Update(msg)
+	return m, cmd
+}
+
+// infoContent renders the sections of the Info tab
+func infoContent(msg infoMsg, width int) string {
+	if msg.err != nil {
+		return errorStyle.Render(errorMessage(msg.err))
+	}
+
+	var b strings.Builder
+	section := func(title string) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(titleStyle.Render(title) + "\n")
+	}
+	row := func(label, value string) {
+		fmt.Fprintf(&b, "%s%s\n", labelStyle.Render(label), truncate(value, width-16))
+	}
+
+	r := msg.report
+	section("Git repository")
+	row("Path", r.Repository)
+	row("Branch", orDash(r.Branch))
+	row("Trunk", r.Trunk)
+	row("Version", orDash(r.Version))
+	row("Label", orDash(r.Label))
+
+	section("Configuration (at.*)")
+	keys := make([]string, 0, len(msg.config))
+	for key := range msg.config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		b.WriteString(dimStyle.Render("Nothing configured yet") + "\n")
+	}
+	for _, key := range keys {
+		value := msg.config[key]
+		if values, ok := value.([]string); ok {
+			value = strings.Join(values, ", ")
+		}
+		fmt.Fprintf(&b, "%s %s\n", dimStyle.Render(key), value)
+	}
+
+	s := msg.stats
+	section("Statistics")
+	row("Commits", fmt.Sprint(s.Commits))
+	row("Authors", fmt.Sprint(s.Authors))
+	row("Branches", fmt.Sprint(s.Branches))
+	row("Tags", fmt.Sprint(s.Tags))
+	if !s.FirstCommit.IsZero() {
+		row("First commit", s.FirstCommit.Format("2006-01-02"))
+	}
+
+	section("Remotes")
+	if len(s.Remotes) == 0 {
+		b.WriteString(dimStyle.Render("No remotes") + "\n")
+	}
+	for _, remote := range s.Remotes {
+		row(remote.Name, remote.URL)
+	}
+	return b.String()
+}
+
+func (i infoModel) view() string {
+	if !i.loaded {
+		return dimStyle.Render("Loading…")
+	}
+	return i.content.View()
+}
@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/potsed/gitAT/internal/commands"
+)
+
+// statusInterval is how often the Status tab refreshes
+const statusInterval = 5 * time.Second
+
+// statusModel is the Status tab: the state of the repository, refreshed
+// while it is shown
+type statusModel struct {
+	report  commands.InfoReport
+	err     error
+	loaded  bool
+	updated time.Time
+}
+
+// statusMsg carries a loaded report
+type statusMsg struct {
+	report commands.InfoReport
+	err    error
+	at     time.Time
+}
+
+// statusTickMsg triggers the refresh of the Status tab
+type statusTickMsg time.Time
+
+func statusTick() tea.Cmd {
+	return tea.Tick(statusInterval, func(t time.Time) tea.Msg { return statusTickMsg(t) })
+}
+
+// loadStatus loads the report of the Status tab
+func (m Model) loadStatus() tea.Cmd {
+	return m.locked(func() tea.Msg {
+		report, err := m.opts.Manager.Report()
+		return statusMsg{report: report, err: err, at: time.Now()}
+	})
+}
+
+func (m Model) updateStatus(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case statusMsg:
+		m.status.report, m.status.err, m.status.updated = msg.report, msg.err, msg.at
+		m.status.loaded = true
+	case statusTickMsg:
+		// Refresh only what is shown, and not under a running command
+		if m.tab == statusTab && !m.commands.running {
+			return m, tea.Batch(m.loadStatus(), statusTick())
+		}
+		return m, statusTick()
+	case tea.KeyMsg:
+		if msg.String() == "r" {
+			return m, m.loadStatus()
+		}
+	}
+	return m, nil
+}
+
+func (s statusModel) view(width int) string {
+	if !s.loaded {
+		return dimStyle.Render("Loading…")
+	}
+	if s.err != nil {
+		return errorStyle.Render(errorMessage(s.err))
+	}
+
+	r := s.report
+	var b strings.Builder
+	row := func(label, value string) {
+		fmt.Fprintf(&b, "%s%s\n", labelStyle.Render(label), truncate(value, width-16))
+	}
+
+	branch := r.Branch
+	if branch == "" {
+		branch = "(detached HEAD)"
+	}
+	b.WriteString(titleStyle.Render("Repository") + "\n")
+	row("Current branch", currentStyle.Render(branch))
+	row("Working branch", orDash(r.WorkingBranch))
+	row("Repository", r.Repository)
+	row("Trunk", divergence(r.TrunkStatus, r.Trunk))
+	row("Upstream", divergence(r.Upstream, "none"))
+	if r.Type != "" {
+		row("Type", r.Type)
+	}
+	if context := r.Context(); context != "" {
+		row("Context", context)
+	}
+
+	b.WriteString("\n" + titleStyle.Render("Working directory") + "\n")
+	if c := r.Changes; c.Clean() {
+		row("Status", successStyle.Render("Clean"))
+	} else {
+		row("Status", errorStyle.Render("Modified"))
+		row("Changes", fmt.Sprintf("%d staged, %d unstaged, %d untracked, %d conflicted",
+			len(c.Staged), len(c.Unstaged), len(c.Untracked), len(c.Conflicted)))
+	}
+	row("Last updated", s.updated.Format("15:04:05"))
+
+	if len(r.Commits) > 0 {
+		b.WriteString("\n" + titleStyle.Render("Recent commits") + "\n")
+		for _, commit := range r.Commits {
+			b.WriteString(truncate(dimStyle.Render(commit.Short)+" "+commit.Subject, width) + "\n")
+		}
+	}
+
+	b.WriteString("\n" + titleStyle.Render("Quick actions") + "\n")
+	b.WriteString(dimStyle.Render("2 then enter: run a command (save, squash, pr, sync…)  •  3: switch, create or delete branches") + "\n")
+	return b.String()
+}
+
+// divergence describes how far HEAD is from a ref
+func divergence(d *commands.Divergence, missing string) string {
+	if d == nil {
+		return missing
+	}
+	return fmt.Sprintf("%s (%d ahead, %d behind)", d.Ref, d.Ahead, d.Behind)
+}
+
+// orDash returns value, or "-" when it is empty
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
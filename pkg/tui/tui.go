@@ -0,0 +1,291 @@
+// Package tui is the terminal user interface of git @ --tui. It shows the
+// repository through the queries of the commands manager and runs git @
+// commands with their output in a pane.
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/potsed/gitAT/internal/commands"
+)
+
+// Runner runs a git @ command line, the arguments after "git @"
+type Runner func(args []string) error
+
+// Options configures the TUI
+type Options struct {
+	Manager    *commands.Manager
+	Commands   []*commands.Command // listed in the Commands tab
+	Run        Runner
+	Executable string   // git @ binary, runs commands that need the terminal
+	Flags      []string // global flags passed to Executable, e.g. --dry-run
+	Verbose    bool     // show the git commands in the output pane
+}
+
+// Run opens the TUI and returns when the user quits
+func Run(opts Options) error {
+	opts.Manager.SetInteractive(false)
+	defer opts.Manager.SetInteractive(true)
+
+	_, err := tea.NewProgram(New(opts), tea.WithAltScreen()).Run()
+	return err
+}
+
+// tab is one of the views of the TUI
+type tab int
+
+const (
+	statusTab tab = iota
+	commandsTab
+	branchesTab
+	infoTab
+)
+
+var tabNames = []string{"Status", "Commands", "Branches", "Info"}
+
+// Colors of archive/TUI.md
+var (
+	primary   = lipgloss.Color("#7D56F4")
+	text      = lipgloss.Color("#FAFAFA")
+	secondary = lipgloss.Color("#666666")
+	failure   = lipgloss.Color("#FF0000")
+	success   = lipgloss.Color("#04B575")
+
+	activeTabStyle   = lipgloss.NewStyle().Bold(true).Foreground(text).Background(primary).Padding(0, 2)
+	tabStyle         = lipgloss.NewStyle().Foreground(secondary).Padding(0, 2)
+	titleStyle       = lipgloss.NewStyle().Bold(true).Foreground(primary)
+	labelStyle       = lipgloss.NewStyle().Foreground(secondary).Width(16)
+	dimStyle         = lipgloss.NewStyle().Foreground(secondary)
+	selectedStyle    = lipgloss.NewStyle().Bold(true).Foreground(text).Background(primary)
+	currentStyle     = lipgloss.NewStyle().Bold(true).Foreground(success)
+	errorStyle       = lipgloss.NewStyle().Foreground(failure)
+	successStyle     = lipgloss.NewStyle().Foreground(success)
+	paneStyle        = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(secondary).Padding(0, 1)
+	focusedPaneStyle = paneStyle.BorderForeground(primary)
+)
+
+// Model is the state of the TUI
+type Model struct {
+	opts Options
+	lock *sync.Mutex // one manager call at a time, see locked
+
+	tab           tab
+	width, height int
+
+	status   statusModel
+	commands commandsModel
+	branches branchesModel
+	info     infoModel
+
+	message string // notification of the footer
+	failed  bool   // the notification is an error
+}
+
+// New returns the TUI model
+func New(opts Options) Model {
+	return Model{
+		opts:     opts,
+		lock:     &sync.Mutex{},
+		width:    80,
+		height:   24,
+		commands: newCommandsModel(opts.Commands),
+		branches: newBranchesModel(),
+		info:     newInfoModel(),
+	}
+}
+
+// locked runs fn with the manager to itself. The manager is not safe for
+// concurrent use, and commands run while the status refreshes.
+func (m Model) locked(fn func() tea.Msg) tea.Cmd {
+	lock := m.lock
+	return func() tea.Msg {
+		lock.Lock()
+		defer lock.Unlock()
+		return fn()
+	}
+}
+
+// notifyMsg shows a notification in the footer
+type notifyMsg struct {
+	text string
+	err  error
+}
+
+// Init loads the status and starts its refresh
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.loadStatus(), m.loadBranches(), statusTick())
+}
+
+// Update handles a message
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.commands.resize(m.width, m.bodyHeight())
+		m.info.resize(m.width, m.bodyHeight())
+		return m, nil
+
+	case notifyMsg:
+		m.message, m.failed = msg.text, msg.err != nil
+		if msg.err != nil {
+			m.message = errorMessage(msg.err)
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		// Keys go to an open prompt first
+		if !m.typing() {
+			if next, cmd, ok := m.globalKey(msg); ok {
+				return next, cmd
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	switch m.tab {
+	case statusTab:
+		m, cmd = m.updateStatus(msg)
+	case commandsTab:
+		m, cmd = m.updateCommands(msg)
+	case branchesTab:
+		m, cmd = m.updateBranches(msg)
+	case infoTab:
+		m, cmd = m.updateInfo(msg)
+	}
+
+	// Loaded data reaches its tab whichever tab is shown
+	var other tea.Cmd
+	switch msg.(type) {
+	case statusMsg, statusTickMsg:
+		if m.tab != statusTab {
+			m, other = m.updateStatus(msg)
+		}
+	case branchesMsg:
+		if m.tab != branchesTab {
+			m, other = m.updateBranches(msg)
+		}
+	case infoMsg:
+		if m.tab != infoTab {
+			m, other = m.updateInfo(msg)
+		}
+	case commandDoneMsg, spinner.TickMsg:
+		if m.tab != commandsTab {
+			m, other = m.updateCommands(msg)
+		}
+	}
+	return m, tea.Batch(cmd, other)
+}
+
+// typing reports whether a prompt takes the keys
+func (m Model) typing() bool {
+	return m.commands.prompting() || m.branches.prompting()
+}
+
+// globalKey handles the keys of every tab
+func (m Model) globalKey(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	switch key := msg.String(); key {
+	case "q":
+		return m, tea.Quit, true
+	case "tab":
+		return m.switchTab((m.tab + 1) % tab(len(tabNames)))
+	case "shift+tab":
+		return m.switchTab((m.tab + tab(len(tabNames)) - 1) % tab(len(tabNames)))
+	case "1", "2", "3", "4":
+		return m.switchTab(tab(key[0] - '1'))
+	}
+	return m, nil, false
+}
+
+// switchTab shows t, refreshing its data
+func (m Model) switchTab(t tab) (Model, tea.Cmd, bool) {
+	m.tab = t
+	switch t {
+	case statusTab:
+		return m, m.loadStatus(), true
+	case branchesTab:
+		return m, m.loadBranches(), true
+	case infoTab:
+		return m, m.loadInfo(), true
+	}
+	return m, nil, true
+}
+
+// bodyHeight is the height left for the tab below the header and footer
+func (m Model) bodyHeight() int {
+	return max(m.height-5, 5)
+}
+
+// View renders the TUI
+func (m Model) View() string {
+	var tabs []string
+	for i, name := range tabNames {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if tab(i) == m.tab {
+			tabs = append(tabs, activeTabStyle.Render(label))
+		} else {
+			tabs = append(tabs, tabStyle.Render(label))
+		}
+	}
+	header := lipgloss.JoinHorizontal(lipgloss.Top, append([]string{titleStyle.Render("GitAT ") + " "}, tabs...)...)
+
+	var body string
+	switch m.tab {
+	case statusTab:
+		body = m.status.view(m.width)
+	case commandsTab:
+		body = m.commands.view(m.width, m.bodyHeight())
+	case branchesTab:
+		body = m.branches.view(m.width, m.bodyHeight())
+	case infoTab:
+		body = m.info.view()
+	}
+	body = lipgloss.NewStyle().Height(m.bodyHeight()).MaxHeight(m.bodyHeight()).Render(body)
+
+	return strings.Join([]string{header, "", body, m.footer()}, "\n")
+}
+
+// footer renders the notification and the keys of the tab
+func (m Model) footer() string {
+	var keys string
+	switch m.tab {
+	case statusTab:
+		keys = "r refresh"
+	case commandsTab:
+		keys = m.commands.keys()
+	case branchesTab:
+		keys = m.branches.keys()
+	case infoTab:
+		keys = "↑/↓ scroll • r refresh"
+	}
+	if !m.typing() {
+		keys += " • tab/1-4 switch tab • q quit"
+	}
+
+	message := ""
+	if m.message != "" {
+		style := successStyle
+		if m.failed {
+			style = errorStyle
+		}
+		message = style.Render(truncate(m.message, m.width)) + "\n"
+	}
+	return message + dimStyle.Render(truncate(keys, m.width))
+}
+
+// truncate shortens s to width cells, keeping its styles intact
+func truncate(s string, width int) string {
+	if width <= 1 {
+		return s
+	}
+	return ansi.Truncate(s, width, "…")
+}
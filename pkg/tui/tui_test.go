@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/potsed/gitAT/internal/commands"
+	"github.com/potsed/gitAT/internal/config"
+	"github.com/potsed/gitAT/internal/git/gittest"
+)
+
+// newTestModel returns a TUI on repo whose commands run through run
+func newTestModel(repo *gittest.Repo, run Runner) Model {
+	manager := commands.NewManagerWithClients(&config.Config{RepoPath: repo.Dir}, repo.Git, nil)
+	manager.SetInteractive(false)
+	return New(Options{Manager: manager, Commands: commands.Commands(), Run: run})
+}
+
+// press sends a key to the model
+func press(m Model, key string) (Model, tea.Cmd) {
+	var msg tea.KeyMsg
+	switch key {
+	case "tab":
+		msg = tea.KeyMsg{Type: tea.KeyTab}
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	default:
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+	next, cmd := m.Update(msg)
+	return next.(Model), cmd
+}
+
+// update sends a message to the model
+func update(m Model, msg tea.Msg) Model {
+	next, _ := m.Update(msg)
+	return next.(Model)
+}
+
+func TestTabs(t *testing.T) {
+	m := newTestModel(gittest.NewRepo(t), nil)
+
+	m, _ = press(m, "tab")
+	if m.tab != commandsTab {
+		t.Errorf("expected tab to show Commands, got %s", tabNames[m.tab])
+	}
+	m, _ = press(m, "4")
+	if m.tab != infoTab {
+		t.Errorf("expected 4 to show Info, got %s", tabNames[m.tab])
+	}
+	m, _ = press(m, "tab")
+	if m.tab != statusTab {
+		t.Errorf("expected tab to wrap to Status, got %s", tabNames[m.tab])
+	}
+
+	if _, cmd := press(m, "q"); cmd == nil {
+		t.Fatal("expected q to quit")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("expected q to quit")
+	}
+}
+
+func TestStatusTab(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Branch("feature-login").
+		Commit("a.txt", "a", "Add a")
+	m := newTestModel(repo, nil)
+
+	m = update(m, m.loadStatus()())
+	view := m.View()
+	for _, want := range []string{"feature-login", "Add a", "Initial commit"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected the status to show %q, got:\n%s", want, view)
+		}
+	}
+}
+
+func TestCommandsTab(t *testing.T) {
+	var ran [][]string
+	m := newTestModel(gittest.NewRepo(t), func(args []string) error {
+		ran = append(ran, args)
+		fmt.Printf("ran %s\n", strings.Join(args, " "))
+		if args[0] == "fail" {
+			return errors.New("it failed")
+		}
+		return nil
+	})
+	m, _ = press(m, "2")
+
+	m, _ = press(m, "enter")
+	if !m.typing() {
+		t.Fatal("expected enter to open the prompt")
+	}
+	if want := m.commands.list[0].Name + " "; m.commands.input.Value() != want {
+		t.Errorf("expected the prompt to hold %q, got %q", want, m.commands.input.Value())
+	}
+	// Keys go to the prompt, not the tabs
+	if m, _ = press(m, "q"); !m.typing() || m.tab != commandsTab {
+		t.Fatal("expected q to be typed in the prompt")
+	}
+
+	m.commands.input.SetValue("work feature 'add login'; fail")
+	m, cmd := press(m, "enter")
+	if cmd == nil || !m.commands.running {
+		t.Fatal("expected enter to run the command")
+	}
+
+	// Run the command itself, not the spinner
+	msg := m.runCommand("work feature 'add login'; fail", [][]string{{"work", "feature", "add login"}, {"fail"}})()
+	done, ok := msg.(commandDoneMsg)
+	if !ok {
+		t.Fatalf("expected the command to finish, got %T", msg)
+	}
+	if len(ran) != 2 || ran[0][2] != "add login" || ran[1][0] != "fail" {
+		t.Errorf("expected both commands to run, got %q", ran)
+	}
+	if !strings.Contains(done.output, "ran work feature add login") || !strings.Contains(done.output, "ran fail") {
+		t.Errorf("expected the output to be captured, got %q", done.output)
+	}
+
+	m = update(m, done)
+	if m.commands.running {
+		t.Error("expected the command to be done")
+	}
+	if !m.failed || !strings.Contains(m.message, "it failed") {
+		t.Errorf("expected the failure in the footer, got %q", m.message)
+	}
+	if view := m.View(); !strings.Contains(view, "ran fail") {
+		t.Errorf("expected the output in the pane, got:\n%s", view)
+	}
+}
+
+func TestBranchesTab(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Branch("hotfix-crash").
+		Branch("feature-login").
+		Branch("experiment").
+		Checkout("master")
+	m := newTestModel(repo, nil)
+	m, _ = press(m, "3")
+	m = update(m, m.loadBranches()())
+
+	var groups []string
+	for _, group := range m.branches.groups {
+		groups = append(groups, fmt.Sprintf("%s:%d", group.Type, len(group.Branches)))
+	}
+	if got := strings.Join(groups, " "); got != ":2 hotfix:1 feature:1" {
+		t.Errorf("expected other, hotfix and feature groups, got %q", got)
+	}
+
+	// The current branch is protected
+	m.branches.cursor = 1
+	if branch, _, _ := m.branches.selected(); branch.Name != "master" {
+		t.Fatalf("expected master selected, got %s", branch.Name)
+	}
+	m, _ = press(m, "d")
+	m, cmd := press(m, "y")
+	if deleted := cmd().(branchDeletedMsg); deleted.err == nil {
+		t.Error("expected deleting the current branch to fail")
+	}
+
+	// n answers the confirmation
+	m, _ = press(m, "down")
+	m, _ = press(m, "d")
+	if m, cmd = press(m, "n"); cmd != nil || m.branches.prompting() {
+		t.Error("expected n to cancel the deletion")
+	}
+
+	m, _ = press(m, "D")
+	m, cmd = press(m, "y")
+	m = update(m, cmd().(branchDeletedMsg))
+	if m.failed || m.message != "Deleted hotfix-crash" {
+		t.Errorf("expected hotfix-crash deleted, got %q", m.message)
+	}
+	if branches := repo.Run("branch", "--list", "hotfix-crash"); branches != "" {
+		t.Errorf("expected hotfix-crash to be gone, got %q", branches)
+	}
+
+	// n names a new branch of the selected type, tab cycles it
+	m = update(m, m.loadBranches()())
+	m.branches.cursor = 2
+	m, _ = press(m, "n")
+	if m.branches.newType != "feature" {
+		t.Errorf("expected a new feature branch, got %q", m.branches.newType)
+	}
+	m, _ = press(m, "tab")
+	if m.branches.newType == "feature" || !m.typing() {
+		t.Errorf("expected tab to change the type, got %q", m.branches.newType)
+	}
+	if m, _ = press(m, "esc"); m.typing() {
+		t.Error("expected esc to close the prompt")
+	}
+}
+
+func TestInfoContent(t *testing.T) {
+	repo := gittest.NewRepo(t).
+		Config("at.trunk", "master").
+		Origin("https://example.com/repo.git")
+	m := newTestModel(repo, nil)
+
+	msg := m.loadInfo()().(infoMsg)
+	content := infoContent(msg, 120)
+	for _, want := range []string{"Git repository", "at.trunk", "Statistics", "Commits", "origin", "https://example.com/repo.git"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected the info to show %q, got:\n%s", want, content)
+		}
+	}
+
+	if content := infoContent(infoMsg{err: errors.New("boom")}, 80); !strings.Contains(content, "boom") {
+		t.Errorf("expected the error, got %q", content)
+	}
+}